@@ -0,0 +1,227 @@
+package meniscus
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+//AddressFamily tells which IP versions the addresses of a host are dialed with, and in which order
+type AddressFamily int
+
+const (
+	//DualStack leaves the choice to the dialer of the transport, usually IPv6 first with a fast fallback to IPv4
+	DualStack AddressFamily = iota
+	//PreferIPv4 dials the IPv4 addresses of a host first, falling back to its IPv6 addresses
+	PreferIPv4
+	//PreferIPv6 dials the IPv6 addresses of a host first, falling back to its IPv4 addresses
+	PreferIPv6
+	//IPv4Only never dials IPv6 addresses
+	IPv4Only
+	//IPv6Only never dials IPv4 addresses
+	IPv6Only
+)
+
+//AddressFamilyPolicy configures how the addresses of hosts resolving to both IPv4 and IPv6 addresses are dialed
+type AddressFamilyPolicy struct {
+	Family AddressFamily
+	//FallbackDelay is how long the preferred family is tried alone before racing the other one, Happy Eyeballs style.
+	//It defaults to 300ms, a negative delay falls back only once every preferred address failed.
+	FallbackDelay time.Duration
+	Resolver      *net.Resolver // defaults to net.DefaultResolver
+}
+
+//WithAddressFamily dials the destinations of the requests according to policy, e.g. to keep hosts with broken AAAA
+//records from stalling bulks until their IPv6 connections time out. Only an *http.Client using an *http.Transport
+//can be configured, other clients are left as they are.
+func WithAddressFamily(policy AddressFamilyPolicy) ClientOption {
+	return func(cl *BulkClient) {
+		cl.addressFamily = &policy
+	}
+}
+
+// client returns a copy of client whose transport dials according to the policy,
+// or client itself when it cannot be configured
+func (p AddressFamilyPolicy) client(client HTTPClient) HTTPClient {
+	httpClient, ok := client.(*http.Client)
+	if !ok {
+		return client
+	}
+
+	roundTripper := httpClient.Transport
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+
+	transport, ok := roundTripper.(*http.Transport)
+	if !ok {
+		return client
+	}
+
+	dialer := newFamilyDialer(p, transport.DialContext)
+	configured := *httpClient
+	familyTransport := transport.Clone()
+	familyTransport.DialContext = dialer.DialContext
+	configured.Transport = familyTransport
+	return &configured
+}
+
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// familyDialer resolves host names itself to order their addresses by family
+type familyDialer struct {
+	policy AddressFamilyPolicy
+	dial   dialFunc
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+func newFamilyDialer(policy AddressFamilyPolicy, dial dialFunc) *familyDialer {
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+
+	if policy.FallbackDelay == 0 {
+		policy.FallbackDelay = 300 * time.Millisecond
+	}
+
+	resolver := policy.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &familyDialer{policy: policy, dial: dial, lookup: resolver.LookupIPAddr}
+}
+
+func (d *familyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || network != "tcp" || net.ParseIP(host) != nil {
+		return d.dial(ctx, network, address)
+	}
+
+	switch d.policy.Family {
+	case IPv4Only:
+		return d.dial(ctx, "tcp4", address)
+	case IPv6Only:
+		return d.dial(ctx, "tcp6", address)
+	case DualStack:
+		return d.dial(ctx, network, address)
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var ipv4, ipv6 []string
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			ipv4 = append(ipv4, net.JoinHostPort(addr.String(), port))
+		} else {
+			ipv6 = append(ipv6, net.JoinHostPort(addr.String(), port))
+		}
+	}
+
+	if d.policy.Family == PreferIPv4 {
+		return d.race(ctx, network, ipv4, ipv6)
+	}
+
+	return d.race(ctx, network, ipv6, ipv4)
+}
+
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// race dials the primary addresses one after the other, and the fallback ones as well once the fallback delay elapsed
+// or every primary address failed, returning the first connection established
+func (d *familyDialer) race(ctx context.Context, network string, primary, fallback []string) (net.Conn, error) {
+	if len(primary) == 0 {
+		primary, fallback = fallback, nil
+	}
+
+	if len(primary) == 0 {
+		return nil, &net.AddrError{Err: "no suitable address found"}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, 2)
+	start := func(addresses []string) {
+		go func() {
+			conn, err := d.dialSerial(ctx, network, addresses)
+			results <- dialResult{conn: conn, err: err}
+		}()
+	}
+
+	start(primary)
+	pending := 1
+	var fallbackTimer <-chan time.Time
+	if len(fallback) != 0 && d.policy.FallbackDelay > 0 {
+		timer := time.NewTimer(d.policy.FallbackDelay)
+		defer timer.Stop()
+		fallbackTimer = timer.C
+	}
+
+	var firstErr error
+	for {
+		select {
+		case <-fallbackTimer:
+			fallbackTimer = nil
+			start(fallback)
+			fallback = nil
+			pending++
+
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				if pending > 0 {
+					// the loser may still connect, after the context is cancelled
+					go func() {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}()
+				}
+				return result.conn, nil
+			}
+
+			if firstErr == nil {
+				firstErr = result.err
+			}
+
+			if len(fallback) != 0 {
+				fallbackTimer = nil
+				start(fallback)
+				fallback = nil
+				pending++
+			}
+
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+func (d *familyDialer) dialSerial(ctx context.Context, network string, addresses []string) (net.Conn, error) {
+	var firstErr error
+	for _, address := range addresses {
+		conn, err := d.dial(ctx, network, address)
+		if err == nil {
+			return conn, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, firstErr
+}
@@ -0,0 +1,113 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNetwork stalls dials to the addresses in stalled until cancelled, fails those in broken
+// and connects to any other one, recording the addresses dialed
+type fakeNetwork struct {
+	stalled map[string]bool
+	broken  map[string]bool
+
+	mu     sync.Mutex
+	dialed []string
+}
+
+func (n *fakeNetwork) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	n.mu.Lock()
+	n.dialed = append(n.dialed, network+" "+address)
+	n.mu.Unlock()
+
+	switch {
+	case n.stalled[address]:
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case n.broken[address]:
+		return nil, errors.New("connection refused")
+	}
+
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func (n *fakeNetwork) dialer(policy AddressFamilyPolicy) *familyDialer {
+	dialer := newFamilyDialer(policy, n.dial)
+	dialer.lookup = func(context.Context, string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("2001:db8::1")}, {IP: net.ParseIP("192.0.2.1")}}, nil
+	}
+	return dialer
+}
+
+func (n *fakeNetwork) attempts() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.dialed...)
+}
+
+func TestPreferIPv4DialsIPv4First(t *testing.T) {
+	network := &fakeNetwork{}
+
+	conn, err := network.dialer(AddressFamilyPolicy{Family: PreferIPv4}).DialContext(context.Background(), "tcp", "example.com:443")
+	require.NoError(t, err)
+	conn.Close()
+
+	assert.Equal(t, []string{"tcp 192.0.2.1:443"}, network.attempts())
+}
+
+func TestStalledIPv6FallsBackToIPv4AfterTheDelay(t *testing.T) {
+	network := &fakeNetwork{stalled: map[string]bool{"[2001:db8::1]:443": true}}
+	dialer := network.dialer(AddressFamilyPolicy{Family: PreferIPv6, FallbackDelay: 10 * time.Millisecond})
+
+	start := time.Now()
+	conn, err := dialer.DialContext(context.Background(), "tcp", "example.com:443")
+	require.NoError(t, err)
+	conn.Close()
+
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	assert.Equal(t, []string{"tcp [2001:db8::1]:443", "tcp 192.0.2.1:443"}, network.attempts())
+}
+
+func TestFailedPrimaryFallsBackAtOnceWithoutDelay(t *testing.T) {
+	network := &fakeNetwork{broken: map[string]bool{"[2001:db8::1]:443": true}}
+	dialer := network.dialer(AddressFamilyPolicy{Family: PreferIPv6, FallbackDelay: -1})
+
+	conn, err := dialer.DialContext(context.Background(), "tcp", "example.com:443")
+	require.NoError(t, err)
+	conn.Close()
+
+	assert.Equal(t, []string{"tcp [2001:db8::1]:443", "tcp 192.0.2.1:443"}, network.attempts())
+}
+
+func TestSingleFamilyPoliciesRestrictTheNetwork(t *testing.T) {
+	network := &fakeNetwork{}
+
+	conn, err := network.dialer(AddressFamilyPolicy{Family: IPv6Only}).DialContext(context.Background(), "tcp", "example.com:443")
+	require.NoError(t, err)
+	conn.Close()
+
+	assert.Equal(t, []string{"tcp6 example.com:443"}, network.attempts())
+}
+
+func TestAddressFamilyPolicyAppliesToTheClientTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	client := NewBulkHTTPClient(&http.Client{}, NonFailingTimeoutValue, WithAddressFamily(AddressFamilyPolicy{Family: PreferIPv4}))
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://localhost:"+port, nil)
+	_, errs := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil}, errs)
+}
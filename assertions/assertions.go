@@ -0,0 +1,95 @@
+// Package assertions checks the results of a bulk in the tests of services built on meniscus,
+// e.g. assert.NoError(t, assertions.AllSucceeded(bulkRequest.Results()))
+package assertions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"strings"
+)
+
+// maxListed bounds how many offending requests a failure lists
+const maxListed = 5
+
+//AllSucceeded fails unless every result has a 2xx response and no error
+func AllSucceeded(results []meniscus.Result) error {
+	return check(results, "did not succeed", func(result meniscus.Result) (string, bool) {
+		succeeded := result.Err == nil && result.Response != nil &&
+			result.Response.StatusCode >= 200 && result.Response.StatusCode < 300
+		return describe(result), succeeded
+	})
+}
+
+//AllStatus fails unless every result has a response with status
+func AllStatus(results []meniscus.Result, status int) error {
+	return check(results, fmt.Sprintf("did not get status %d", status), func(result meniscus.Result) (string, bool) {
+		return describe(result), result.Response != nil && result.Response.StatusCode == status
+	})
+}
+
+//NoErrorOfType fails if the error of any result is target or wraps it, e.g. meniscus.ErrRequestIgnored
+func NoErrorOfType(results []meniscus.Result, target error) error {
+	return check(results, fmt.Sprintf("failed with %q", target), func(result meniscus.Result) (string, bool) {
+		return describe(result), !errors.Is(result.Err, target)
+	})
+}
+
+//StatusCounts counts the results by the status code of their response, those without a response under 0
+func StatusCounts(results []meniscus.Result) map[int]int {
+	counts := map[int]int{}
+	for _, result := range results {
+		status := 0
+		if result.Response != nil {
+			status = result.Response.StatusCode
+		}
+		counts[status]++
+	}
+
+	return counts
+}
+
+//ErrorCounts counts the results by their error message, leaving out those without an error
+func ErrorCounts(results []meniscus.Result) map[string]int {
+	counts := map[string]int{}
+	for _, result := range results {
+		if result.Err != nil {
+			counts[result.Err.Error()]++
+		}
+	}
+
+	return counts
+}
+
+// check fails with the requests for which ok does not hold, listing the first ones with their description
+func check(results []meniscus.Result, failure string, ok func(meniscus.Result) (string, bool)) error {
+	var offending []string
+	for _, result := range results {
+		if description, passed := ok(result); !passed {
+			offending = append(offending, fmt.Sprintf("#%d: %s", result.Index, description))
+		}
+	}
+
+	if len(offending) == 0 {
+		return nil
+	}
+
+	listed := offending
+	if len(listed) > maxListed {
+		listed = append(listed[:maxListed:maxListed], "...")
+	}
+
+	return fmt.Errorf("%d of %d requests %s: %s", len(offending), len(results), failure, strings.Join(listed, ", "))
+}
+
+// describe summarises the outcome of a request
+func describe(result meniscus.Result) string {
+	switch {
+	case result.Err != nil:
+		return result.Err.Error()
+	case result.Response == nil:
+		return "no response"
+	default:
+		return fmt.Sprintf("status %d", result.Response.StatusCode)
+	}
+}
@@ -0,0 +1,58 @@
+package assertions
+
+import (
+	"errors"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func results(outcomes ...interface{}) []meniscus.Result {
+	var results []meniscus.Result
+	for i, outcome := range outcomes {
+		result := meniscus.Result{Index: i}
+		switch outcome := outcome.(type) {
+		case int:
+			result.Response = &http.Response{StatusCode: outcome}
+		case error:
+			result.Err = outcome
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func TestAllSucceeded(t *testing.T) {
+	assert.NoError(t, AllSucceeded(results(200, 204)))
+
+	err := AllSucceeded(results(200, 500, meniscus.ErrRequestIgnored))
+	assert.EqualError(t, err, "2 of 3 requests did not succeed: #1: status 500, #2: request ignored")
+}
+
+func TestAllStatus(t *testing.T) {
+	assert.NoError(t, AllStatus(results(202, 202), 202))
+	assert.EqualError(t, AllStatus(results(202, 200), 202), "1 of 2 requests did not get status 202: #1: status 200")
+}
+
+func TestNoErrorOfTypeMatchesWrappedErrors(t *testing.T) {
+	wrapped := &meniscus.TimeoutError{Source: meniscus.BulkDeadline, Phase: meniscus.PhaseQueued, Err: meniscus.ErrRequestIgnored}
+
+	assert.NoError(t, NoErrorOfType(results(200, errors.New("boom")), meniscus.ErrRequestIgnored))
+	assert.Error(t, NoErrorOfType(results(200, wrapped), meniscus.ErrRequestIgnored))
+}
+
+func TestFailuresListTheFirstOffendingRequests(t *testing.T) {
+	err := AllSucceeded(results(500, 500, 500, 500, 500, 500, 200))
+
+	assert.EqualError(t, err, "6 of 7 requests did not succeed: #0: status 500, #1: status 500, #2: status 500, "+
+		"#3: status 500, #4: status 500, ...")
+}
+
+func TestCounts(t *testing.T) {
+	outcomes := results(200, 200, 404, meniscus.ErrRequestIgnored, meniscus.ErrRequestIgnored)
+
+	assert.Equal(t, map[int]int{200: 2, 404: 1, 0: 2}, StatusCounts(outcomes))
+	assert.Equal(t, map[string]int{"request ignored": 2}, ErrorCounts(outcomes))
+}
@@ -0,0 +1,118 @@
+package meniscus
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+// spillConfig moves response bodies larger than threshold bytes to temporary files in dir
+type spillConfig struct {
+	threshold int64
+	dir       string
+}
+
+func (c spillConfig) enabled() bool {
+	return c.threshold > 0
+}
+
+// readBody copies the response body so it outlives the request context.
+// Bodies are buffered in memory, recycling the buffer on Close when a pool is given,
+// unless they exceed the spill threshold in which case they are streamed to a temporary file.
+func readBody(resp *http.Response, pool *bufferPool, spill spillConfig) (io.ReadCloser, error) {
+	if spill.enabled() && resp.ContentLength > spill.threshold {
+		return spillToFile(spill.dir, nil, resp.Body)
+	}
+
+	var buf *bytes.Buffer
+	if pool != nil {
+		buf = pool.get(resp.ContentLength)
+	} else {
+		buf = bytes.NewBuffer(make([]byte, 0, presize(resp.ContentLength)))
+	}
+
+	release := func() {
+		if pool != nil {
+			pool.put(buf)
+		}
+	}
+
+	var src io.Reader = resp.Body
+	if spill.enabled() {
+		src = io.LimitReader(resp.Body, spill.threshold+1)
+	}
+
+	if _, err := buf.ReadFrom(src); err != nil {
+		release()
+		return nil, err
+	}
+
+	if spill.enabled() && int64(buf.Len()) > spill.threshold {
+		defer release()
+		return spillToFile(spill.dir, buf.Bytes(), resp.Body)
+	}
+
+	if pool == nil {
+		return memoryBody{bytes.NewReader(buf.Bytes())}, nil
+	}
+
+	return &pooledBody{reader: bytes.NewReader(buf.Bytes()), buf: buf, pool: pool}, nil
+}
+
+// spillToFile writes prefix followed by the rest of src to a temporary file, removed when the body is closed
+func spillToFile(dir string, prefix []byte, src io.Reader) (io.ReadCloser, error) {
+	file, err := ioutil.TempFile(dir, "meniscus-body-")
+	if err != nil {
+		return nil, err
+	}
+
+	body := &fileBody{file: file}
+	if _, err := file.Write(prefix); err != nil {
+		body.Close()
+		return nil, err
+	}
+
+	if _, err := io.Copy(file, src); err != nil {
+		body.Close()
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		body.Close()
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// memoryBody is an unpooled in memory body, seekable like the other buffered bodies
+type memoryBody struct {
+	*bytes.Reader
+}
+
+func (memoryBody) Close() error {
+	return nil
+}
+
+type fileBody struct {
+	file *os.File
+}
+
+func (b *fileBody) Read(p []byte) (int, error) {
+	return b.file.Read(p)
+}
+
+func (b *fileBody) Seek(offset int64, whence int) (int64, error) {
+	return b.file.Seek(offset, whence)
+}
+
+func (b *fileBody) Close() error {
+	err := b.file.Close()
+	if removeErr := os.Remove(b.file.Name()); err == nil && !os.IsNotExist(removeErr) {
+		err = removeErr
+	}
+
+	return err
+}
@@ -0,0 +1,65 @@
+package meniscus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+//BodyCipher encrypts request bodies before they are sent and decrypts response bodies once received.
+//Both run on the worker pools of the bulk, so the crypto work of large bulks is spread over the workers.
+//Either function may be left nil. Decrypted responses are always buffered, in memory, even with WithUnbufferedResponses.
+type BodyCipher struct {
+	Encrypt func(req *http.Request, plaintext []byte) ([]byte, error)
+	Decrypt func(resp *http.Response, ciphertext []byte) ([]byte, error)
+}
+
+// encrypt returns a copy of req sending the ciphertext of its body, or req with a client error when it cannot
+func (c BodyCipher) encrypt(req *http.Request) (*http.Request, error) {
+	if c.Encrypt == nil || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	plaintext, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return req, &codedError{code: CodeClientErr, msg: fmt.Sprintf("error while reading request body: %s", err), cause: err}
+	}
+
+	ciphertext, err := c.Encrypt(req, plaintext)
+	if err != nil {
+		return req, &codedError{code: CodeClientErr, msg: fmt.Sprintf("error while encrypting request body: %s", err), cause: err}
+	}
+
+	encrypted := *req
+	encrypted.Body = ioutil.NopCloser(bytes.NewReader(ciphertext))
+	encrypted.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(ciphertext)), nil
+	}
+	encrypted.ContentLength = int64(len(ciphertext))
+	return &encrypted, nil
+}
+
+// decrypt replaces the buffered body of resp with its plaintext, failing with a body read error
+func (c BodyCipher) decrypt(resp *http.Response) error {
+	if c.Decrypt == nil {
+		return nil
+	}
+
+	ciphertext, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return &codedError{code: CodeBodyReadErr, msg: fmt.Sprintf("error while reading response body: %s", err), cause: err}
+	}
+
+	plaintext, err := c.Decrypt(resp, ciphertext)
+	if err != nil {
+		return &codedError{code: CodeBodyReadErr, msg: fmt.Sprintf("error while decrypting response body: %s", err), cause: err}
+	}
+
+	resp.Body = memoryBody{bytes.NewReader(plaintext)}
+	resp.ContentLength = int64(len(plaintext))
+	return nil
+}
@@ -0,0 +1,79 @@
+package meniscus
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func xor(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i := range data {
+		out[i] = data[i] ^ 0x5a
+	}
+
+	return out
+}
+
+func TestBodyCipherEncryptsRequestsAndDecryptsResponses(t *testing.T) {
+	var received [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		received = append(received, body)
+		w.Write(xor(append([]byte("echo "), xor(body)...)))
+	}))
+	defer server.Close()
+
+	cipher := BodyCipher{
+		Encrypt: func(req *http.Request, plaintext []byte) ([]byte, error) { return xor(plaintext), nil },
+		Decrypt: func(resp *http.Response, ciphertext []byte) ([]byte, error) { return xor(ciphertext), nil },
+	}
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue,
+		WithBodyCipher(cipher), WithUnbufferedResponses())
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte("hello")))
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil}, errs)
+	assert.Equal(t, [][]byte{xor([]byte("hello"))}, received)
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, "echo hello", string(body))
+}
+
+func TestBodyCipherFailuresFailTheRequest(t *testing.T) {
+	cipher := BodyCipher{
+		Decrypt: func(resp *http.Response, ciphertext []byte) ([]byte, error) { return nil, errors.New("bad tag") },
+	}
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithBodyCipher(cipher))
+
+	bulkRequest := newBulkClientWithNRequests(1, "http://example.com")
+	_, errs := client.Do(bulkRequest)
+
+	assert.EqualError(t, errs[0], "error while decrypting response body: bad tag")
+	assert.Equal(t, CodeBodyReadErr, Code(errs[0]))
+}
+
+func TestBodyCipherEncryptionFailuresAreClientErrors(t *testing.T) {
+	failure := errors.New("no key")
+	cipher := BodyCipher{
+		Encrypt: func(req *http.Request, plaintext []byte) ([]byte, error) { return nil, failure },
+	}
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithBodyCipher(cipher))
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader([]byte("hello")))
+	_, errs := client.Do(NewBulkRequest([]*http.Request{req}, 1, 1))
+
+	assert.Contains(t, errs[0].Error(), "error while encrypting request body: no key")
+	assert.Equal(t, CodeClientErr, Code(errs[0]))
+	assert.True(t, errors.Is(errs[0], failure))
+}
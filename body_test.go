@@ -0,0 +1,75 @@
+package meniscus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadBodySpillsLargeBodiesToTemporaryFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err, "no errors")
+	defer os.RemoveAll(dir)
+
+	content := bytes.Repeat([]byte("a"), 100)
+	for _, contentLength := range []int64{int64(len(content)), -1} {
+		resp := &http.Response{Body: ioutil.NopCloser(bytes.NewReader(content)), ContentLength: contentLength}
+
+		body, err := readBody(resp, nil, spillConfig{threshold: 10, dir: dir})
+		require.NoError(t, err, "no errors")
+
+		files, _ := filepath.Glob(filepath.Join(dir, "*"))
+		assert.Len(t, files, 1)
+
+		read, _ := ioutil.ReadAll(body)
+		assert.Equal(t, content, read)
+
+		body.Close()
+		files, _ = filepath.Glob(filepath.Join(dir, "*"))
+		assert.Len(t, files, 0)
+	}
+}
+
+func TestReadBodyKeepsSmallBodiesInMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err, "no errors")
+	defer os.RemoveAll(dir)
+
+	resp := &http.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte("small"))), ContentLength: -1}
+	body, err := readBody(resp, newBufferPool(), spillConfig{threshold: 10, dir: dir})
+	require.NoError(t, err, "no errors")
+	defer body.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Len(t, files, 0)
+	read, _ := ioutil.ReadAll(body)
+	assert.Equal(t, "small", string(read))
+}
+
+func TestBulkHTTPClientRemovesSpilledBodiesOnCloseAllResponses(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err, "no errors")
+	defer os.RemoveAll(dir)
+
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithSpillToDisk(1, dir))
+
+	bulkRequest := newBulkClientWithNRequests(1, server.URL)
+	bulkRequest.requests[0].URL.RawQuery = "kind=fast"
+	responses, errs := client.Do(bulkRequest)
+	assert.Nil(t, errs[0])
+
+	read, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, "fast", string(read))
+
+	bulkRequest.CloseAllResponses()
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Len(t, files, 0)
+}
@@ -0,0 +1,150 @@
+package meniscus
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	minPooledBuffer = 1 << 10
+	maxPooledBuffer = 16 << 20
+	maxPresize      = maxPooledBuffer
+)
+
+var errReadOnClosedBody = errors.New("read on closed response body")
+
+//BufferPoolStats reports how often response buffers were recycled
+type BufferPoolStats struct {
+	Gets   uint64
+	Hits   uint64
+	Misses uint64
+	Puts   uint64
+}
+
+//HitRate is the fraction of buffers served from the pool
+func (s BufferPoolStats) HitRate() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.Gets)
+}
+
+// bufferPool keeps response buffers in size classes growing by a factor of 4,
+// so a buffer picked for a known Content-Length never has to grow while reading
+type bufferPool struct {
+	classes []sync.Pool
+	sizes   []int
+
+	gets, hits, misses, puts uint64
+}
+
+func newBufferPool() *bufferPool {
+	pool := &bufferPool{}
+	for size := minPooledBuffer; size <= maxPooledBuffer; size *= 4 {
+		pool.sizes = append(pool.sizes, size)
+	}
+	pool.classes = make([]sync.Pool, len(pool.sizes))
+
+	return pool
+}
+
+func (p *bufferPool) get(contentLength int64) *bytes.Buffer {
+	atomic.AddUint64(&p.gets, 1)
+	need := presize(contentLength)
+
+	for i, size := range p.sizes {
+		if size < need {
+			continue
+		}
+
+		if buf, ok := p.classes[i].Get().(*bytes.Buffer); ok {
+			atomic.AddUint64(&p.hits, 1)
+			return buf
+		}
+
+		atomic.AddUint64(&p.misses, 1)
+		return bytes.NewBuffer(make([]byte, 0, size))
+	}
+
+	atomic.AddUint64(&p.misses, 1)
+	return bytes.NewBuffer(make([]byte, 0, need))
+}
+
+func (p *bufferPool) put(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+
+	for i := len(p.sizes) - 1; i >= 0; i-- {
+		if buf.Cap() >= p.sizes[i] && buf.Cap() < p.sizes[i]*4 {
+			buf.Reset()
+			p.classes[i].Put(buf)
+			atomic.AddUint64(&p.puts, 1)
+			return
+		}
+	}
+}
+
+func (p *bufferPool) stats() BufferPoolStats {
+	return BufferPoolStats{
+		Gets:   atomic.LoadUint64(&p.gets),
+		Hits:   atomic.LoadUint64(&p.hits),
+		Misses: atomic.LoadUint64(&p.misses),
+		Puts:   atomic.LoadUint64(&p.puts),
+	}
+}
+
+// presize returns the capacity needed to read a body of contentLength without growing,
+// bytes.Buffer.ReadFrom always wants bytes.MinRead spare bytes before reading
+func presize(contentLength int64) int {
+	if contentLength < 0 || contentLength > maxPresize {
+		return bytes.MinRead
+	}
+
+	return int(contentLength) + bytes.MinRead
+}
+
+type pooledBody struct {
+	mu     sync.Mutex
+	reader *bytes.Reader
+	buf    *bytes.Buffer
+	pool   *bufferPool
+}
+
+func (b *pooledBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.reader == nil {
+		return 0, errReadOnClosedBody
+	}
+
+	return b.reader.Read(p)
+}
+
+func (b *pooledBody) Seek(offset int64, whence int) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.reader == nil {
+		return 0, errReadOnClosedBody
+	}
+
+	return b.reader.Seek(offset, whence)
+}
+
+func (b *pooledBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.buf != nil {
+		b.pool.put(b.buf)
+		b.buf = nil
+		b.reader = nil
+	}
+
+	return nil
+}
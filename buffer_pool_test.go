@@ -0,0 +1,61 @@
+package meniscus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestBufferPoolRecyclesBuffersOfClosedResponses(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithBufferPool())
+
+	query := url.Values{}
+	query.Set("kind", "fast")
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", query), nil)
+		require.NoError(t, err, "no errors")
+
+		bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+		responses, _ := client.Do(bulkRequest)
+		body, err := ioutil.ReadAll(responses[0].Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "fast", string(body))
+		bulkRequest.CloseAllResponses()
+
+		_, err = responses[0].Body.Read(make([]byte, 1))
+		assert.Equal(t, errReadOnClosedBody, err)
+	}
+
+	stats := client.BufferPoolStats()
+	assert.Equal(t, uint64(2), stats.Gets)
+	assert.Equal(t, uint64(2), stats.Puts)
+	assert.True(t, stats.Hits <= 1)
+}
+
+func TestReadBodyPresizesFromContentLength(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 10000)
+	resp := &http.Response{Body: ioutil.NopCloser(bytes.NewReader(content)), ContentLength: int64(len(content))}
+	pool := newBufferPool()
+
+	body, err := readBody(resp, pool, spillConfig{})
+	require.NoError(t, err, "no errors")
+
+	pooled := body.(*pooledBody)
+	assert.Equal(t, 16<<10, pooled.buf.Cap())
+	read, _ := ioutil.ReadAll(body)
+	assert.Equal(t, content, read)
+}
+
+func TestPresizeIgnoresUnknownAndHugeContentLength(t *testing.T) {
+	assert.Equal(t, bytes.MinRead, presize(-1))
+	assert.Equal(t, bytes.MinRead, presize(1<<40))
+	assert.Equal(t, 100+bytes.MinRead, presize(100))
+}
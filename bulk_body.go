@@ -0,0 +1,72 @@
+package meniscus
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// bulkBody is an unbuffered response body bound to the bulk it was received in: once the bulk is cancelled,
+// times out or its responses are closed, reads fail with ErrBulkClosed rather than with whatever the transport returns
+type bulkBody struct {
+	io.ReadCloser
+	ctx context.Context
+
+	mu       sync.Mutex
+	consumed bool // read to the end or closed
+}
+
+func newBulkBody(ctx context.Context, body io.ReadCloser) *bulkBody {
+	return &bulkBody{ReadCloser: body, ctx: ctx}
+}
+
+func (b *bulkBody) Read(p []byte) (int, error) {
+	if b.ctx.Err() != nil {
+		return 0, ErrBulkClosed
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	switch {
+	case err == io.EOF:
+		b.consume()
+	case err != nil && b.ctx.Err() != nil:
+		return n, ErrBulkClosed
+	}
+
+	return n, err
+}
+
+func (b *bulkBody) Close() error {
+	b.consume()
+	return b.ReadCloser.Close()
+}
+
+func (b *bulkBody) consume() {
+	b.mu.Lock()
+	b.consumed = true
+	b.mu.Unlock()
+}
+
+func (b *bulkBody) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.consumed
+}
+
+//OpenBodies returns the indices of the responses whose body is still being consumed, neither read to the end nor closed.
+//Only unbuffered bodies are tracked, see WithUnbufferedResponses, it is meant to find out which ones
+//a caller is still streaming before cancelling the bulk or closing its responses.
+func (r *RoundTrip) OpenBodies() []int {
+	var open []int
+	for index, response := range r.responses {
+		if response == nil {
+			continue
+		}
+
+		if body, ok := response.Body.(*bulkBody); ok && body.open() {
+			open = append(open, r.origin(index))
+		}
+	}
+
+	return open
+}
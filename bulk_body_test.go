@@ -0,0 +1,80 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// streamingClient streams the bodies of /stream through a pipe whose writer is handed to writers
+func streamingClient(writers chan<- *io.PipeWriter) HTTPClientFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		resp := syntheticResponse(req, http.StatusOK)
+		if req.URL.Path == "/stream" {
+			reader, writer := io.Pipe()
+			writers <- writer
+			resp.Body = reader
+		} else {
+			resp.Body = ioutil.NopCloser(strings.NewReader("done"))
+		}
+		return resp, nil
+	}
+}
+
+func TestUnbufferedBodiesFailWithErrBulkClosedOnceTheBulkIsCancelled(t *testing.T) {
+	writers := make(chan *io.PipeWriter, 1)
+	client := NewBulkHTTPClient(streamingClient(writers), NonFailingTimeoutValue,
+		WithUnbufferedResponses())
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://example.com/stream", nil).AddGet("http://example.com/short", nil)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, []int{0, 1}, bulkRequest.OpenBodies())
+
+	body, err := ioutil.ReadAll(responses[1].Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", string(body))
+	assert.Equal(t, []int{0}, bulkRequest.OpenBodies())
+
+	writer := <-writers
+	defer writer.Close()
+	go writer.Write([]byte("chunk"))
+	chunk := make([]byte, 5)
+	_, err = io.ReadFull(responses[0].Body, chunk)
+	assert.NoError(t, err)
+	assert.Equal(t, "chunk", string(chunk))
+
+	bulkRequest.Cancel()
+	_, err = responses[0].Body.Read(chunk)
+	assert.Equal(t, ErrBulkClosed, err)
+	assert.Equal(t, CodeBodyReadErr, Code(err))
+	assert.Equal(t, []int{0}, bulkRequest.OpenBodies())
+}
+
+func TestUnbufferedBodiesFailWithErrBulkClosedOnceTheResponsesAreClosed(t *testing.T) {
+	writers := make(chan *io.PipeWriter, 1)
+	client := NewBulkHTTPClient(streamingClient(writers), NonFailingTimeoutValue,
+		WithUnbufferedResponses())
+
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/stream", nil)
+	responses, _ := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	_, err := responses[0].Body.Read(make([]byte, 1))
+	assert.Equal(t, ErrBulkClosed, err)
+	assert.Empty(t, bulkRequest.OpenBodies())
+}
+
+func TestBufferedBodiesAreNotTracked(t *testing.T) {
+	client := NewBulkHTTPClient(streamingClient(nil), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/short", nil)
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Empty(t, bulkRequest.OpenBodies())
+}
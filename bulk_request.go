@@ -1,8 +1,12 @@
 package meniscus
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"sort"
 	"sync"
+	"time"
 )
 
 //Request ..
@@ -12,21 +16,73 @@ type Request interface {
 
 //RoundTrip ...
 type RoundTrip struct {
+	id                     string
+	tenant                 string
 	requests               []*http.Request
 	fireRequestsWorkers    int
 	responses              []*http.Response
 	processResponseWorkers int
+	clientFireWorkers      int // those of the client running the bulk, for counts the bulk leaves unset
+	clientProcessWorkers   int
 	errors                 []error
+	connections            []ConnectionInfo
+	values                 []interface{}
+	latencies              []time.Duration
+	queueWaits             []time.Duration
+	handles                map[int]*RequestHandle
+	tags                   map[int]Tags
+	invalid                map[int]error // requests not to be sent, that the builders could not build or were filtered out
+	conditions             map[int]func() bool
+	streams                map[int]func(io.Writer) error
+	captures               map[int]variableCapture
+	variables              *Variables
+	origins                []int // indices the requests had in the bulk they were retried from
+	retryPass              bool  // the requests are sent again after an earlier Do, see Counts
+	via                    map[int]viaAddress
+	policies               map[int]requestPolicy
+	handlers               map[int]ResponseHandler
+	attempts               map[int]int // runs of Do the requests failed in before, in the bulks they were retried from
+	interruption           *Interruption
+	release                func()
+	dispatched             time.Time
+	monitor                *saturationMonitor
+
+	parent    context.Context
+	nextPhase func([]Result) []*http.Request
+	aggregate interface{}
+
+	mu        sync.Mutex
+	progress  []Result
+	cancel    context.CancelFunc
+	cancelled bool
+	tracker   *stateTracker
+	running   bool
+	followUp  *RoundTrip // the phase added by Then, once it started
+	stream    *resultStream
 }
 
-//NewBulkRequest ...
-func NewBulkRequest(requests []*http.Request, fireRequestsWorkers int, processResponseWorkers int) *RoundTrip {
-	return &RoundTrip{
-		requests:               requests,
-		fireRequestsWorkers:    fireRequestsWorkers,
-		responses:              []*http.Response{},
-		processResponseWorkers: processResponseWorkers,
+//DefaultWorkers caps the workers of each kind of a bulk created without worker counts
+const DefaultWorkers = 10
+
+//NewBulkRequest creates a bulk of requests, optionally followed by its number of fire request workers
+//and process response workers. Omitted or non positive counts default to one worker per request,
+//up to DefaultWorkers, the process response workers defaulting to the fire request workers.
+//Clients created WithFireWorkers or WithProcessWorkers override these defaults.
+func NewBulkRequest(requests []*http.Request, workers ...int) *RoundTrip {
+	bulkRequest := &RoundTrip{
+		id:        nextBulkID(),
+		requests:  requests,
+		responses: []*http.Response{},
+	}
+
+	if len(workers) > 0 {
+		bulkRequest.fireRequestsWorkers = workers[0]
+	}
+	if len(workers) > 1 {
+		bulkRequest.processResponseWorkers = workers[1]
 	}
+
+	return bulkRequest
 }
 
 //AddRequest ...
@@ -35,6 +91,92 @@ func (r *RoundTrip) AddRequest(request *http.Request) *RoundTrip {
 	return r
 }
 
+//AddRequestIf adds request to the bulk, to be sent only if condition holds when a worker picks it up.
+//The condition can depend on the results of earlier requests of the bulk, see Results.
+//Requests not sent fail with ErrRequestSkipped.
+func (r *RoundTrip) AddRequestIf(request *http.Request, condition func() bool) *RoundTrip {
+	if r.conditions == nil {
+		r.conditions = map[int]func() bool{}
+	}
+
+	r.conditions[len(r.requests)] = condition
+	r.requests = append(r.requests, request)
+	return r
+}
+
+//Filter drops the requests for which keep returns false before the bulk is run. Dropped requests keep their index
+//as tombstones failing with ErrRequestFiltered without being sent, so results still line up with the requests added.
+func (r *RoundTrip) Filter(keep func(i int, request *http.Request) bool) *RoundTrip {
+	for i, request := range r.requests {
+		if r.invalid[i] != nil || keep(i, request) {
+			continue
+		}
+
+		if r.invalid == nil {
+			r.invalid = map[int]error{}
+		}
+		r.invalid[i] = ErrRequestFiltered
+	}
+
+	return r
+}
+
+//AddTaggedRequest adds request to the bulk, labelled with tags
+func (r *RoundTrip) AddTaggedRequest(request *http.Request, tags Tags) *RoundTrip {
+	if r.tags == nil {
+		r.tags = map[int]Tags{}
+	}
+
+	r.tags[len(r.requests)] = tags
+	r.requests = append(r.requests, request)
+	return r
+}
+
+//AddCancellableRequest adds request to the bulk and returns a handle to cancel it on its own
+func (r *RoundTrip) AddCancellableRequest(request *http.Request) *RequestHandle {
+	handle := &RequestHandle{index: len(r.requests)}
+	if r.handles == nil {
+		r.handles = map[int]*RequestHandle{}
+	}
+
+	r.handles[handle.index] = handle
+	r.requests = append(r.requests, request)
+	return handle
+}
+
+//SetContext makes ctx the parent of the contexts the requests are fired with, so that its values,
+//e.g. the current trace span, reach the middlewares. Cancelling ctx aborts the bulk.
+func (r *RoundTrip) SetContext(ctx context.Context) *RoundTrip {
+	r.parent = ctx
+	return r
+}
+
+// parentContext is the context the bulk timeout is derived from
+func (r *RoundTrip) parentContext() context.Context {
+	if r.parent == nil {
+		return context.Background()
+	}
+
+	return r.parent
+}
+
+//ID identifies the bulk in profiler labels, it defaults to a process wide sequence number
+func (r *RoundTrip) ID() string {
+	return r.id
+}
+
+//SetID replaces the identifier of the bulk
+func (r *RoundTrip) SetID(id string) *RoundTrip {
+	r.id = id
+	return r
+}
+
+//SetTenant sets the key the bulk is accounted under in a SharedPool
+func (r *RoundTrip) SetTenant(tenant string) *RoundTrip {
+	r.tenant = tenant
+	return r
+}
+
 //CloseAllResponses ...
 func (r *RoundTrip) CloseAllResponses() {
 	for _, response := range r.responses {
@@ -42,16 +184,205 @@ func (r *RoundTrip) CloseAllResponses() {
 			response.Body.Close()
 		}
 	}
+
+	if r.release != nil {
+		r.release()
+	}
+}
+
+//Connections returns the connection used by each request, in the same order as the requests.
+//It is only populated when the client was built WithConnectionDiagnostics.
+func (r *RoundTrip) Connections() []ConnectionInfo {
+	return r.connections
+}
+
+//Values returns the values set by the post processor for each request, in the same order as the requests
+func (r *RoundTrip) Values() []interface{} {
+	return r.values
+}
+
+//Results returns a snapshot of the results collected so far, ordered by request index.
+//It is safe to call while Do is running, once Do returns it holds one result per request.
+func (r *RoundTrip) Results() Results {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make(Results, len(r.progress))
+	copy(results, r.progress)
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+	return results
+}
+
+//Each calls fn with the response or error of every request, in index order, and closes each response body once fn returns,
+//so fn must be done reading it. Responses are nil for failed requests. It goes over a snapshot of Results,
+//making it safe to call while Do is running, when it only visits the results collected so far.
+func (r *RoundTrip) Each(fn func(i int, resp *http.Response, err error)) {
+	for _, result := range r.Results() {
+		visit(result, fn)
+	}
+}
+
+// visit hands result to fn and closes its body, even when fn panics
+func visit(result Result, fn func(i int, resp *http.Response, err error)) {
+	if result.Response != nil {
+		defer result.Response.Body.Close()
+	}
+
+	fn(result.Index, result.Response, result.Err)
+}
+
+//Cancel aborts a running Do. Results collected so far are kept and requests still in flight are ignored.
+func (r *RoundTrip) Cancel() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.cancelled = true
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// fireWorkers is the number of workers firing the requests of the bulk
+func (r *RoundTrip) fireWorkers() int {
+	if r.fireRequestsWorkers > 0 {
+		return r.fireRequestsWorkers
+	}
+
+	if r.clientFireWorkers > 0 {
+		return r.clientFireWorkers
+	}
+
+	if len(r.requests) < DefaultWorkers {
+		return len(r.requests)
+	}
+
+	return DefaultWorkers
+}
+
+// processWorkers is the number of workers processing the responses of the bulk
+func (r *RoundTrip) processWorkers() int {
+	if r.processResponseWorkers > 0 {
+		return r.processResponseWorkers
+	}
+
+	if r.clientProcessWorkers > 0 {
+		return r.clientProcessWorkers
+	}
+
+	return r.fireWorkers()
+}
+
+func (r *RoundTrip) requestContext(ctx context.Context, index int) context.Context {
+	ctx = withScope(ctx, r.id, index, r.tags[index])
+	if r.variables != nil {
+		ctx = context.WithValue(ctx, variablesKey{}, r.variables)
+	}
+
+	if via, ok := r.via[index]; ok {
+		ctx = context.WithValue(ctx, viaKey{}, via)
+	}
+
+	if policy, ok := r.policies[index]; ok {
+		ctx = context.WithValue(ctx, policyKey{}, policy)
+	}
+
+	if handle, ok := r.handles[index]; ok {
+		return handle.bind(ctx)
+	}
+
+	return ctx
+}
+
+// bind returns req bound to ctx, a fresh copy of it when it was already sent by an earlier Do of the bulk
+func (r *RoundTrip) bind(req *http.Request, ctx context.Context, index int) *http.Request {
+	if scopeOfRequest(req) == nil {
+		return req.WithContext(ctx)
+	}
+
+	cloned, err := CloneForAttempt(ctx, req)
+	if err != nil {
+		if r.invalid == nil {
+			r.invalid = map[int]error{}
+		}
+		r.invalid[index] = err
+	}
+
+	return cloned
+}
+
+func (r *RoundTrip) start(cancel context.CancelFunc) {
+	r.mu.Lock()
+	r.progress = nil
+	r.interruption = nil
+	r.cancel = cancel
+	r.cancelled = false
+	r.tracker = newStateTracker(len(r.requests))
+	r.mu.Unlock()
+}
+
+func (r *RoundTrip) recordProgress(result Result) {
+	r.mu.Lock()
+	r.progress = append(r.progress, result)
+	stream := r.stream
+	r.mu.Unlock()
+
+	// sent outside the lock, so that a consumer calling Results, State or Cancel does not block the bulk
+	if stream != nil {
+		stream.send(result)
+	}
+}
+
+func (r *RoundTrip) streamResult(result Result) {
+	r.mu.Lock()
+	stream := r.stream
+	r.mu.Unlock()
+
+	if stream != nil {
+		stream.send(result)
+	}
+}
+
+func (r *RoundTrip) finish() {
+	results := make([]Result, len(r.requests))
+	for i := range r.requests {
+		results[i] = Result{
+			Index:     r.origin(i),
+			Request:   r.requests[i],
+			Response:  r.responses[i],
+			Err:       r.errors[i],
+			Value:     r.values[i],
+			Tags:      r.tags[i],
+			Latency:   r.latencies[i],
+			QueueWait: r.queueWaits[i],
+			Scope:     scopeOfRequest(r.requests[i]),
+		}
+	}
+
+	r.mu.Lock()
+	r.progress = results
+	r.mu.Unlock()
+	r.tracker.finish()
 }
 
 func (r *RoundTrip) publishAllRequests(requestList chan<- requestParcel, stopProcessing <-chan struct{}, publishWg *sync.WaitGroup) {
 LOOP:
 	for index := range r.requests {
 		reqParcel := requestParcel{
-			request: r.requests[index],
-			index:   index,
+			request:   r.requests[index],
+			index:     index,
+			bulkID:    r.id,
+			tags:      r.tags[index],
+			invalid:   r.invalid[index],
+			condition: r.conditions[index],
+			stream:    r.streams[index],
+			handler:   r.handlers[index],
+			queued:    r.dispatched,
+			monitor:   r.monitor,
+			tracker:   r.tracker,
 		}
 
+		r.tracker.advance(index, StatePublishing)
 		select {
 		case requestList <- reqParcel:
 		case <-stopProcessing:
@@ -62,9 +393,11 @@ LOOP:
 	publishWg.Done()
 }
 
-func (r *RoundTrip) addRequestIgnoredErrors() {
+// addRequestIgnoredErrors fails the requests left without an outcome. Once reduced, a response is not kept,
+// so only the requests that were never collected are ignored.
+func (r *RoundTrip) addRequestIgnoredErrors(states []ExecutionState, reduced bool) {
 	for i, response := range r.responses {
-		if response == nil && r.errors[i] == nil {
+		if response == nil && r.errors[i] == nil && (!reduced || states[i] != StateDone) {
 			r.errors[i] = ErrRequestIgnored
 		}
 	}
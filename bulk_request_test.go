@@ -0,0 +1,180 @@
+package meniscus
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultsSnapshotAndCancelWhileDoIsRunning(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	release := make(chan struct{})
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("kind") == "slow" {
+			select {
+			case <-release:
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+		return httpclient.Do(req)
+	}), time.Minute)
+	defer close(release)
+
+	queryFast := url.Values{}
+	queryFast.Set("kind", "fast")
+	querySlow := url.Values{}
+	querySlow.Set("kind", "slow")
+
+	reqOne, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", queryFast), nil)
+	require.NoError(t, err, "no errors")
+	reqTwo, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", querySlow), nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{reqOne, reqTwo}, 2, 2)
+	done := make(chan []error)
+	go func() {
+		_, errs := client.Do(bulkRequest)
+		done <- errs
+	}()
+
+	assert.Eventually(t, func() bool { return len(bulkRequest.Results()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, bulkRequest.Results()[0].Index)
+
+	bulkRequest.Cancel()
+	errs := <-done
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Nil(t, errs[0])
+	assert.Equal(t, ErrRequestIgnored, errs[1])
+
+	results := bulkRequest.Results()
+	assert.Len(t, results, 2)
+	assert.Equal(t, http.StatusOK, results[0].Response.StatusCode)
+	assert.Equal(t, ErrRequestIgnored, results[1].Err)
+}
+
+func TestFilterLeavesTombstonesInPlaceOfDroppedRequests(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/b", nil).
+		AddGet("http://example.com/c", nil).
+		Filter(func(i int, request *http.Request) bool { return request.URL.Path != "/b" })
+	_, errs := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, ErrRequestFiltered, nil}, errs)
+	assert.Equal(t, map[string]int{"/a": 1, "/c": 1}, calls)
+	assert.Equal(t, 1, bulkRequest.Results()[1].Index)
+	assert.Empty(t, bulkRequest.RetryFailed().requests)
+}
+
+func TestDoRefusesABulkThatIsAlreadyExecuting(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-release
+		return syntheticResponse(req, http.StatusOK), nil
+	}), time.Minute)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://example.com", nil)
+	done := make(chan []error)
+	go func() {
+		_, errs := client.Do(bulkRequest)
+		done <- errs
+	}()
+
+	<-started
+	responses, errs := client.Do(bulkRequest)
+	assert.Nil(t, responses)
+	assert.Equal(t, []error{ErrAlreadyExecuting}, errs)
+
+	close(release)
+	assert.Equal(t, []error{nil}, <-done)
+	bulkRequest.CloseAllResponses()
+}
+
+func TestNewBulkRequestDefaultsWorkersToTheRequestCount(t *testing.T) {
+	requests := func(n int) []*http.Request {
+		return make([]*http.Request, n)
+	}
+
+	bulkRequest := NewBulkRequest(requests(3))
+	assert.Equal(t, 3, bulkRequest.fireWorkers())
+	assert.Equal(t, 3, bulkRequest.processWorkers())
+
+	bulkRequest = NewBulkRequest(requests(25))
+	assert.Equal(t, DefaultWorkers, bulkRequest.fireWorkers())
+	assert.Equal(t, DefaultWorkers, bulkRequest.processWorkers())
+
+	bulkRequest = NewBulkRequest(requests(25), 4)
+	assert.Equal(t, 4, bulkRequest.fireWorkers())
+	assert.Equal(t, 4, bulkRequest.processWorkers())
+
+	bulkRequest = NewBulkRequest(requests(25), 0, 2)
+	assert.Equal(t, DefaultWorkers, bulkRequest.fireWorkers())
+	assert.Equal(t, 2, bulkRequest.processWorkers())
+
+	calls := map[string]int{}
+	bulkRequest = NewBulkRequest(nil).AddGet("http://example.com/a", nil).AddGet("http://example.com/b", nil)
+	_, errs := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue).Do(bulkRequest)
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, map[string]int{"/a": 1, "/b": 1}, calls)
+}
+
+func TestEachVisitsEveryRequestAndClosesItsBody(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/fail" {
+			return nil, errors.New("connection reset")
+		}
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(req.URL.Path))
+		return resp, nil
+	}), NonFailingTimeoutValue, WithUnbufferedResponses())
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/fail", nil).
+		AddGet("http://example.com/b", nil)
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+	assert.Equal(t, []int{0, 2}, bulkRequest.OpenBodies())
+
+	var visited []string
+	bulkRequest.Each(func(i int, resp *http.Response, err error) {
+		if err != nil {
+			visited = append(visited, fmt.Sprintf("%d: %s", i, Code(err)))
+			return
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		visited = append(visited, fmt.Sprintf("%d: %s", i, body))
+	})
+
+	assert.Equal(t, []string{"0: /a", "1: MENISCUS_CLIENT_ERR", "2: /b"}, visited)
+	assert.Empty(t, bulkRequest.OpenBodies())
+}
+
+func TestEachClosesTheBodyWhenTheCallbackPanics(t *testing.T) {
+	client := NewBulkHTTPClient(streamingClient(nil), NonFailingTimeoutValue, WithUnbufferedResponses())
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/short", nil)
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Panics(t, func() {
+		bulkRequest.Each(func(int, *http.Response, error) { panic("boom") })
+	})
+	assert.Empty(t, bulkRequest.OpenBodies())
+}
@@ -0,0 +1,156 @@
+package meniscus
+
+import "sync"
+
+//ExecutionState is a stage of the execution of a bulk or of one of its requests
+type ExecutionState int
+
+const (
+	//StatePending is a bulk not running, or a request not handed to the fire workers yet
+	StatePending ExecutionState = iota
+	//StatePublishing is a bulk still handing requests to its fire workers, or a request waiting for a fire worker
+	StatePublishing
+	//StateFiring is a request being sent, until its response headers are received
+	StateFiring
+	//StateProcessing is a request whose response is being read, parsed or post processed
+	StateProcessing
+	//StateCollecting is a request whose result is being recorded, and folded when the client has a Reducer
+	StateCollecting
+	//StateDone is a bulk whose Do returned, or a request with a result, including one ignored at the deadline
+	StateDone
+)
+
+const executionStates = int(StateDone) + 1
+
+var executionStateNames = [executionStates]string{"pending", "publishing", "firing", "processing", "collecting", "done"}
+
+func (s ExecutionState) String() string {
+	if s < 0 || int(s) >= executionStates {
+		return "unknown"
+	}
+
+	return executionStateNames[s]
+}
+
+//BulkState is a snapshot of where a bulk and its requests are, see RoundTrip.State
+type BulkState struct {
+	State    ExecutionState         // that of the least advanced request while Do runs
+	Requests map[ExecutionState]int // number of requests in each state, including those of later phases
+}
+
+//State returns where the bulk and its requests are, it is safe to call while Do is running,
+//e.g. from a debug endpoint, to see where a stuck bulk is spending its time
+func (r *RoundTrip) State() BulkState {
+	r.mu.Lock()
+	tracker, running, followUp := r.tracker, r.running, r.followUp
+	r.mu.Unlock()
+
+	if tracker == nil {
+		snapshot := BulkState{State: StatePending, Requests: map[ExecutionState]int{}}
+		if len(r.requests) > 0 {
+			snapshot.Requests[StatePending] = len(r.requests)
+		}
+		return snapshot
+	}
+
+	counts := tracker.counts()
+	if followUp != nil {
+		for state, count := range followUp.State().Requests {
+			counts[state] += count
+		}
+	}
+
+	snapshot := BulkState{State: StateDone, Requests: map[ExecutionState]int{}}
+	for state := executionStates - 1; state >= 0; state-- {
+		if counts[state] > 0 {
+			snapshot.Requests[ExecutionState(state)] = counts[state]
+			snapshot.State = ExecutionState(state)
+		}
+	}
+
+	switch {
+	case !running:
+		snapshot.State = StateDone
+	case snapshot.State == StatePending:
+		snapshot.State = StatePublishing
+	case snapshot.State == StateDone:
+		// the results are being gathered, or the next phase built
+		snapshot.State = StateCollecting
+	}
+
+	return snapshot
+}
+
+// run marks the bulk as running until the returned function is called, it returns false when the bulk is already running
+func (r *RoundTrip) run() (func(), bool) {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return nil, false
+	}
+	r.running = true
+	r.followUp = nil
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+	}, true
+}
+
+// follow makes the requests of phase count towards the state of r
+func (r *RoundTrip) follow(phase *RoundTrip) {
+	r.mu.Lock()
+	r.followUp = phase
+	r.mu.Unlock()
+}
+
+// stateTracker follows the state of every request of one run of a bulk, states only ever move forward
+// so that workers racing to record them cannot corrupt the counts
+type stateTracker struct {
+	mu     sync.Mutex
+	states []ExecutionState
+	count  [executionStates]int
+}
+
+func newStateTracker(requests int) *stateTracker {
+	tracker := &stateTracker{states: make([]ExecutionState, requests)}
+	tracker.count[StatePending] = requests
+	return tracker
+}
+
+// advance moves the request at index to state, unless it is already past it
+func (t *stateTracker) advance(index int, state ExecutionState) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	if current := t.states[index]; state > current {
+		t.count[current]--
+		t.count[state]++
+		t.states[index] = state
+	}
+	t.mu.Unlock()
+}
+
+// finish moves every request to StateDone
+func (t *stateTracker) finish() {
+	for index := range t.states {
+		t.advance(index, StateDone)
+	}
+}
+
+// snapshot returns the state of every request
+func (t *stateTracker) snapshot() []ExecutionState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ExecutionState(nil), t.states...)
+}
+
+func (t *stateTracker) counts() [executionStates]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.count
+}
@@ -0,0 +1,80 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// waitForState polls the state of bulkRequest until it equals expected or a second elapsed
+func waitForState(t *testing.T, bulkRequest *RoundTrip, expected BulkState) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && !assert.ObjectsAreEqual(expected, bulkRequest.State()) {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, expected, bulkRequest.State())
+}
+
+func TestStateCountsTheRequestsInFlightAndWaitingForAWorker(t *testing.T) {
+	release := make(chan struct{})
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		<-release
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://example.com/a", nil).AddGet("http://example.com/b", nil)
+	assert.Equal(t, BulkState{State: StatePending, Requests: map[ExecutionState]int{StatePending: 2}}, bulkRequest.State())
+
+	done := make(chan struct{})
+	go func() {
+		client.Do(bulkRequest)
+		close(done)
+	}()
+
+	waitForState(t, bulkRequest, BulkState{State: StatePublishing, Requests: map[ExecutionState]int{StatePublishing: 1, StateFiring: 1}})
+	close(release)
+	<-done
+
+	assert.Equal(t, BulkState{State: StateDone, Requests: map[ExecutionState]int{StateDone: 2}}, bulkRequest.State())
+}
+
+func TestStateShowsResultsBeingCollected(t *testing.T) {
+	release := make(chan struct{})
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithReducer(func(acc interface{}, result Result) interface{} {
+		<-release
+		return acc
+	}))
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://example.com/a", nil)
+	done := make(chan struct{})
+	go func() {
+		client.Do(bulkRequest)
+		close(done)
+	}()
+
+	waitForState(t, bulkRequest, BulkState{State: StateCollecting, Requests: map[ExecutionState]int{StateCollecting: 1}})
+	close(release)
+	<-done
+}
+
+func TestStateCountsTheRequestsOfLaterPhases(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+
+	next, _ := http.NewRequest(http.MethodGet, "http://example.com/b", nil)
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/a", nil).
+		Then(func([]Result) []*http.Request { return []*http.Request{next} })
+	client.Do(bulkRequest)
+
+	assert.Equal(t, BulkState{State: StateDone, Requests: map[ExecutionState]int{StateDone: 2}}, bulkRequest.State())
+}
+
+func TestExecutionStateNames(t *testing.T) {
+	assert.Equal(t, "firing", StateFiring.String())
+	assert.Equal(t, "unknown", ExecutionState(42).String())
+}
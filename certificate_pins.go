@@ -0,0 +1,147 @@
+package meniscus
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+//CertificatePins maps a host to the base64 encoded SHA-256 hashes of the subject public keys it may present.
+//A connection is accepted when any certificate of the chain matches a pin, hosts without pins are not checked.
+//Requests to a pinned host not made over TLS fail with ErrPinMismatch, without being sent.
+type CertificatePins map[string][]string
+
+//SPKIHash returns the pin of cert, in the form used by CertificatePins
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func (p CertificatePins) pinned(host string) bool {
+	_, ok := p[strings.ToLower(host)]
+	return ok
+}
+
+// unencrypted tells whether req goes to a pinned host without TLS, so that no certificate could be checked
+func (p CertificatePins) unencrypted(req *http.Request) bool {
+	return !strings.EqualFold(req.URL.Scheme, "https") && p.pinned(req.URL.Hostname())
+}
+
+func (p CertificatePins) verify(host string, certs []*x509.Certificate) error {
+	pins, ok := p[strings.ToLower(host)]
+	if !ok {
+		return nil
+	}
+
+	for _, cert := range certs {
+		hash := SPKIHash(cert)
+		for _, pin := range pins {
+			if pin == hash {
+				return nil
+			}
+		}
+	}
+
+	return ErrPinMismatch
+}
+
+// pin makes client fail requests to pinned hosts presenting none of their pins.
+// With an *http.Client the check runs during the TLS handshake, before anything is sent,
+// using a copy of its transport per pinned host since the handshake does not know the host of an IP address.
+// Other clients are checked once the response is received, and the response is discarded.
+func (p CertificatePins) pin(client HTTPClient) HTTPClient {
+	if httpClient, ok := client.(*http.Client); ok {
+		if transport, ok := p.pinnedTransport(httpClient.Transport); ok {
+			pinned := *httpClient
+			pinned.Transport = transport
+			return &pinned
+		}
+	}
+
+	return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if p.unencrypted(req) {
+			return nil, ErrPinMismatch
+		}
+
+		resp, err := client.Do(req)
+		if err != nil || !p.pinned(req.URL.Hostname()) {
+			return resp, err
+		}
+
+		if resp.TLS == nil {
+			resp.Body.Close()
+			return nil, ErrPinMismatch
+		}
+
+		if err := p.verify(req.URL.Hostname(), resp.TLS.PeerCertificates); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+
+		return resp, nil
+	})
+}
+
+func (p CertificatePins) pinnedTransport(roundTripper http.RoundTripper) (http.RoundTripper, bool) {
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+
+	base, ok := roundTripper.(*http.Transport)
+	if !ok {
+		return nil, false
+	}
+
+	transports := make(map[string]http.RoundTripper, len(p))
+	for host := range p {
+		host := strings.ToLower(host)
+		transport := base.Clone()
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return p.verify(host, state.PeerCertificates)
+		}
+		transports[host] = transport
+	}
+
+	return &pinnedTransport{pins: p, base: base, transports: transports}, true
+}
+
+type pinnedTransport struct {
+	pins       CertificatePins
+	base       http.RoundTripper
+	transports map[string]http.RoundTripper
+}
+
+//RoundTrip also covers the redirects followed by the client, e.g. from https to http on a pinned host
+func (t *pinnedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.pins.unencrypted(req) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, ErrPinMismatch
+	}
+
+	if transport, ok := t.transports[strings.ToLower(req.URL.Hostname())]; ok {
+		return transport.RoundTrip(req)
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+func (t *pinnedTransport) CloseIdleConnections() {
+	closeIdleConnections(t.base)
+	for _, transport := range t.transports {
+		closeIdleConnections(transport)
+	}
+}
+
+func closeIdleConnections(roundTripper http.RoundTripper) {
+	if closer, ok := roundTripper.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
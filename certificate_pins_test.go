@@ -0,0 +1,59 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func pinnedBulk(server *httptest.Server, client HTTPClient, pin string) []error {
+	serverURL, _ := url.Parse(server.URL)
+	bulkClient := NewBulkHTTPClient(client, NonFailingTimeoutValue,
+		WithCertificatePins(CertificatePins{serverURL.Hostname(): {pin}}))
+
+	bulkRequest := newBulkClientWithNRequests(1, server.URL)
+	_, errs := bulkClient.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+	return errs
+}
+
+func TestCertificatePinsAreCheckedDuringTheHandshake(t *testing.T) {
+	served := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		served++
+	}))
+	defer server.Close()
+
+	assert.Equal(t, []error{nil}, pinnedBulk(server, server.Client(), SPKIHash(server.Certificate())))
+	assert.Equal(t, []error{ErrPinMismatch}, pinnedBulk(server, server.Client(), "AAAA"))
+	assert.Equal(t, 1, served)
+}
+
+func TestCertificatePinsAreCheckedOnTheResponseOfOtherClients(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	client := HTTPClientFunc(server.Client().Do)
+
+	assert.Equal(t, []error{nil}, pinnedBulk(server, client, SPKIHash(server.Certificate())))
+	assert.Equal(t, []error{ErrPinMismatch}, pinnedBulk(server, client, "AAAA"))
+}
+
+func TestHostsWithoutPinsAreNotChecked(t *testing.T) {
+	pins := CertificatePins{"example.com": {"AAAA"}}
+	assert.NoError(t, pins.verify("example.org", nil))
+	assert.Equal(t, ErrPinMismatch, pins.verify("EXAMPLE.com", nil))
+}
+
+func TestRequestsToPinnedHostsFailWithoutTLS(t *testing.T) {
+	served := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		served++
+	}))
+	defer server.Close()
+
+	assert.Equal(t, []error{ErrPinMismatch}, pinnedBulk(server, &http.Client{}, "AAAA"))
+	assert.Equal(t, []error{ErrPinMismatch}, pinnedBulk(server, HTTPClientFunc(http.DefaultClient.Do), "AAAA"))
+	assert.Equal(t, 0, served)
+}
@@ -0,0 +1,82 @@
+package meniscus
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+//ChaosConfig configures the faults injected by ChaosMiddleware.
+//Percentages are in the range [0, 100] and are evaluated independently for every request.
+type ChaosConfig struct {
+	LatencyPercentage float64
+	Latency           time.Duration
+
+	ErrorPercentage float64
+	Error           error // defaults to ErrInjectedFault
+
+	StatusPercentage float64
+	StatusCode       int // defaults to http.StatusServiceUnavailable
+
+	Clock  Clock  // defaults to RealClock
+	Random Random // defaults to GlobalRandom, see SeededRandom
+}
+
+//ChaosMiddleware injects latency, errors or 5xx responses into a percentage of requests.
+//Requests hit by an error or status fault never reach the wrapped client.
+func ChaosMiddleware(config ChaosConfig) Middleware {
+	if config.Error == nil {
+		config.Error = ErrInjectedFault
+	}
+
+	if config.StatusCode == 0 {
+		config.StatusCode = http.StatusServiceUnavailable
+	}
+
+	if config.Clock == nil {
+		config.Clock = RealClock()
+	}
+
+	if config.Random == nil {
+		config.Random = GlobalRandom()
+	}
+
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if chance(config.Random, config.LatencyPercentage) {
+				if err := sleep(req.Context(), config.Clock, config.Latency); err != nil {
+					return nil, err
+				}
+			}
+
+			if chance(config.Random, config.ErrorPercentage) {
+				return nil, config.Error
+			}
+
+			if chance(config.Random, config.StatusPercentage) {
+				return syntheticResponse(req, config.StatusCode), nil
+			}
+
+			return next.Do(req)
+		})
+	}
+}
+
+func chance(random Random, percentage float64) bool {
+	return percentage > 0 && random()*100 < percentage
+}
+
+func syntheticResponse(req *http.Request, statusCode int) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode: statusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}
+}
@@ -0,0 +1,91 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestChaosMiddlewareInjectsErrorsForAllRequests(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue,
+		WithMiddleware(ChaosMiddleware(ChaosConfig{ErrorPercentage: 100})))
+
+	bulkRequest := newBulkClientWithNRequests(3, server.URL)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	for i := range responses {
+		assert.Nil(t, responses[i])
+		assert.EqualError(t, errs[i], "http client error: "+ErrInjectedFault.Error())
+	}
+}
+
+func TestChaosMiddlewareInjectsStatusCodes(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue,
+		WithMiddleware(ChaosMiddleware(ChaosConfig{StatusPercentage: 100, StatusCode: http.StatusBadGateway})))
+
+	query := url.Values{}
+	query.Set("kind", "fast")
+	req, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", query), nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Nil(t, errs[0])
+	assert.Equal(t, http.StatusBadGateway, responses[0].StatusCode)
+}
+
+func TestChaosMiddlewareInjectedLatencyRespectsBulkTimeout(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, FailingTimeoutValue,
+		WithMiddleware(ChaosMiddleware(ChaosConfig{LatencyPercentage: 100, Latency: time.Second})))
+
+	bulkRequest := newBulkClientWithNRequests(2, server.URL)
+	start := time.Now()
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.True(t, time.Since(start) < time.Second)
+	for _, e := range errs {
+		assert.Equal(t, ErrRequestIgnored, e)
+	}
+}
+
+func TestChaosMiddlewareInjectsTheSameFaultsForTheSameSeed(t *testing.T) {
+	faults := func(seed int64) []bool {
+		client := NewBulkHTTPClient(countingClient(map[string]int{}), NonFailingTimeoutValue,
+			WithMiddleware(ChaosMiddleware(ChaosConfig{ErrorPercentage: 50, Random: SeededRandom(seed)})))
+
+		bulkRequest := NewBulkRequest(nil, 1, 1)
+		for i := 0; i < 20; i++ {
+			bulkRequest.AddGet("http://example.com", nil)
+		}
+		_, errs := client.Do(bulkRequest)
+		bulkRequest.CloseAllResponses()
+
+		var failed []bool
+		for _, err := range errs {
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+
+	first := faults(7)
+	assert.Equal(t, first, faults(7))
+	assert.NotEqual(t, first, faults(8))
+	assert.Contains(t, first, true)
+	assert.Contains(t, first, false)
+}
@@ -0,0 +1,184 @@
+package meniscus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+//Checkpoint is the outstanding work of a bulk, the requests that did not succeed yet, in a form that can be
+//persisted as JSON and restored later, e.g. so that a long running migration bulk resumes after a deploy
+type Checkpoint struct {
+	ID             string              `json:"id"`
+	Tenant         string              `json:"tenant,omitempty"`
+	FireWorkers    int                 `json:"fire_workers,omitempty"`
+	ProcessWorkers int                 `json:"process_workers,omitempty"`
+	Requests       []CheckpointRequest `json:"requests"`
+}
+
+//CredentialHeaders are the headers Checkpoint leaves out of the requests it persists, see CheckpointWithCredentials
+var CredentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key", "X-Auth-Token"}
+
+//CheckpointRequest is a request of a Checkpoint, with the index it had in the bulk it was first added to
+type CheckpointRequest struct {
+	Index     int           `json:"index"`
+	Method    string        `json:"method"`
+	URL       string        `json:"url"`
+	Header    http.Header   `json:"header,omitempty"`
+	Body      []byte        `json:"body,omitempty"`
+	Tags      Tags          `json:"tags,omitempty"`
+	Timeout   time.Duration `json:"timeout,omitempty"` // of its RequestSpec, if any
+	Retries   int           `json:"retries,omitempty"` // of its RequestSpec, if any
+	Attempts  int           `json:"attempts"`          // runs of Do it was sent in and failed
+	LastError string        `json:"last_error,omitempty"`
+}
+
+//Checkpoint returns the requests of the bulk that did not succeed in its last Do, or all of them before it ran,
+//like RetryFailed it leaves out those that could not be built, were skipped or cancelled. It is meant to be taken
+//once Do returned, e.g. after cancelling the context of DoWithContext on shutdown. Conditions, streamed bodies,
+//captures, response handlers and connect-to addresses cannot be persisted and are dropped, requests with a body
+//but no GetBody fail the checkpoint with ErrBodyNotReplayable, requests without a URL with a client error.
+//Checkpoints are written in clear, so the CredentialHeaders of the requests are left out, to be set again on the
+//restored bulk, e.g. by a Middleware.
+func (r *RoundTrip) Checkpoint() (Checkpoint, error) {
+	return r.checkpoint(false)
+}
+
+//CheckpointWithCredentials returns the checkpoint of the bulk like Checkpoint, keeping the CredentialHeaders of its
+//requests. Whoever stores the checkpoint must keep it as safe as the credentials it holds.
+func (r *RoundTrip) CheckpointWithCredentials() (Checkpoint, error) {
+	return r.checkpoint(true)
+}
+
+func (r *RoundTrip) checkpoint(credentials bool) (Checkpoint, error) {
+	checkpoint := Checkpoint{
+		ID:             r.id,
+		Tenant:         r.tenant,
+		FireWorkers:    r.fireRequestsWorkers,
+		ProcessWorkers: r.processResponseWorkers,
+		Requests:       []CheckpointRequest{},
+	}
+
+	ran := len(r.errors) == len(r.requests)
+	for index, req := range r.requests {
+		if r.invalid[index] != nil || (ran && !r.outstanding(index)) {
+			continue
+		}
+
+		if _, ok := r.streams[index]; ok {
+			return Checkpoint{}, ErrBodyNotReplayable
+		}
+
+		if req.URL == nil {
+			return Checkpoint{}, &codedError{code: CodeClientErr, msg: fmt.Sprintf("request %d without a URL cannot be checkpointed", r.origin(index))}
+		}
+
+		body, err := replayableBody(req)
+		if err != nil {
+			return Checkpoint{}, err
+		}
+
+		request := CheckpointRequest{
+			Index:    r.origin(index),
+			Method:   req.Method,
+			URL:      req.URL.String(),
+			Body:     body,
+			Tags:     r.tags[index],
+			Timeout:  r.policies[index].timeout,
+			Retries:  r.policies[index].retries,
+			Attempts: r.attempts[index],
+		}
+		if header := persistedHeader(req.Header, credentials); len(header) > 0 {
+			request.Header = header
+		}
+		if ran {
+			request.Attempts = r.failedAttempts(index)
+			request.LastError = r.errors[index].Error()
+		}
+
+		checkpoint.Requests = append(checkpoint.Requests, request)
+	}
+
+	return checkpoint, nil
+}
+
+//Restore returns a bulk sending the requests of the checkpoint again, under its ID, tenant and workers.
+//Results keep the indices the requests had in the bulk the checkpoint was taken from, see Results.Merge.
+func (c Checkpoint) Restore() *RoundTrip {
+	restored := NewBulkRequest(nil, c.FireWorkers, c.ProcessWorkers).SetTenant(c.Tenant)
+	restored.retryPass = true
+	if len(c.ID) != 0 {
+		restored.SetID(c.ID)
+	}
+
+	for _, request := range c.Requests {
+		position := len(restored.requests)
+
+		var body io.Reader
+		if request.Body != nil {
+			body = bytes.NewReader(request.Body)
+		}
+		restored.addBuilt(request.Method, request.URL, request.Header, body)
+
+		if request.Tags != nil {
+			if restored.tags == nil {
+				restored.tags = map[int]Tags{}
+			}
+			restored.tags[position] = request.Tags
+		}
+
+		if request.Timeout > 0 || request.Retries > 0 {
+			if restored.policies == nil {
+				restored.policies = map[int]requestPolicy{}
+			}
+			restored.policies[position] = requestPolicy{timeout: request.Timeout, retries: request.Retries}
+		}
+
+		if request.Attempts > 0 {
+			if restored.attempts == nil {
+				restored.attempts = map[int]int{}
+			}
+			restored.attempts[position] = request.Attempts
+		}
+
+		restored.origins = append(restored.origins, request.Index)
+	}
+
+	return restored
+}
+
+// persistedHeader returns header without its CredentialHeaders unless credentials are kept
+func persistedHeader(header http.Header, credentials bool) http.Header {
+	if credentials {
+		return header
+	}
+
+	persisted := header.Clone()
+	for _, key := range CredentialHeaders {
+		persisted.Del(key)
+	}
+
+	return persisted
+}
+
+// replayableBody reads the body of req from GetBody, nil for requests without a body
+func replayableBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, ErrBodyNotReplayable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return ioutil.ReadAll(body)
+}
@@ -0,0 +1,124 @@
+package meniscus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckpointRestoresTheRequestsThatDidNotSucceed(t *testing.T) {
+	var bodies []string
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Body != nil {
+			body, _ := ioutil.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+		}
+		if req.URL.Path == "/ok" || req.Header.Get("x-deployed") == "true" {
+			return syntheticResponse(req, http.StatusOK), nil
+		}
+		return nil, errors.New("connection refused")
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).SetID("migration").SetTenant("sg").
+		AddGet("http://example.com/ok", nil).
+		AddSpec(RequestSpec{Method: http.MethodPut, URL: "http://example.com/orders/1", Body: []byte(`{"state":"done"}`),
+			Timeout: time.Second, Tags: Tags{"table": "orders"}}).
+		AddRequestIf(mustRequest(t, "http://example.com/skipped"), func() bool { return false }).
+		AddGet("http://example.com/drivers/1", nil)
+	client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	checkpoint, err := bulkRequest.Checkpoint()
+	assert.NoError(t, err)
+	data, err := json.Marshal(checkpoint)
+	assert.NoError(t, err)
+
+	var restored Checkpoint
+	assert.NoError(t, json.Unmarshal(data, &restored))
+	assert.Equal(t, checkpoint, restored)
+	assert.Equal(t, "migration", restored.ID)
+	if assert.Len(t, restored.Requests, 2) {
+		assert.Equal(t, CheckpointRequest{Index: 1, Method: http.MethodPut, URL: "http://example.com/orders/1",
+			Body: []byte(`{"state":"done"}`), Tags: Tags{"table": "orders"}, Timeout: time.Second,
+			Attempts: 1, LastError: "http client error: connection refused"}, restored.Requests[0])
+		assert.Equal(t, 3, restored.Requests[1].Index)
+	}
+
+	resumed := restored.Restore()
+	for _, req := range resumed.requests {
+		req.Header.Set("x-deployed", "true")
+	}
+	_, errs := client.Do(resumed)
+	defer resumed.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, "migration", resumed.ID())
+	assert.Equal(t, []string{`{"state":"done"}`, `{"state":"done"}`}, bodies)
+	assert.Equal(t, []int{1, 3}, []int{resumed.Results()[0].Index, resumed.Results()[1].Index})
+}
+
+func TestCheckpointCountsTheAttemptsOfEachRequest(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/a"), NonFailingTimeoutValue)
+	refused := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/a", nil)
+	checkpoint, err := bulkRequest.Checkpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, checkpoint.Requests[0].Attempts)
+
+	refused.Do(bulkRequest)
+	retry := bulkRequest.RetryFailed()
+	refused.Do(retry)
+	checkpoint, _ = retry.Checkpoint()
+	assert.Equal(t, 2, checkpoint.Requests[0].Attempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	interrupted := checkpoint.Restore()
+	client.DoWithContext(ctx, interrupted)
+	checkpoint, _ = interrupted.Checkpoint()
+	assert.Equal(t, 2, checkpoint.Requests[0].Attempts, "requests never sent do not count")
+}
+
+func TestCheckpointFailsOnBodiesThatCannotBeReplayed(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/orders", ioutil.NopCloser(strings.NewReader("order")))
+	req.GetBody = nil
+
+	_, err := NewBulkRequest([]*http.Request{req}).Checkpoint()
+
+	assert.Equal(t, ErrBodyNotReplayable, err)
+}
+
+func TestCheckpointLeavesOutCredentialsUnlessAskedFor(t *testing.T) {
+	req := mustRequest(t, "http://example.com/orders/1")
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	req.Header.Set("Cookie", "session=s3cr3t")
+	req.Header.Set("X-Request-Id", "42")
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+
+	checkpoint, err := bulkRequest.Checkpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, http.Header{"X-Request-Id": {"42"}}, checkpoint.Requests[0].Header)
+	assert.Equal(t, "Bearer s3cr3t", req.Header.Get("Authorization"))
+
+	checkpoint, err = bulkRequest.CheckpointWithCredentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer s3cr3t", checkpoint.Requests[0].Header.Get("Authorization"))
+	assert.Equal(t, "session=s3cr3t", checkpoint.Requests[0].Header.Get("Cookie"))
+}
+
+func TestCheckpointFailsOnRequestsWithoutAURL(t *testing.T) {
+	bulkRequest := NewBulkRequest([]*http.Request{{Method: http.MethodGet}}, 1, 1)
+
+	_, err := bulkRequest.Checkpoint()
+
+	assert.Equal(t, CodeClientErr, Code(err))
+}
@@ -0,0 +1,132 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+//Cooldown returns how long a Chunker pauses before its next chunk, given the results of the last one
+type Cooldown func(last Results) time.Duration
+
+//FixedCooldown pauses for d between chunks
+func FixedCooldown(d time.Duration) Cooldown {
+	return func(Results) time.Duration {
+		return d
+	}
+}
+
+//ErrorRateCooldown pauses between min and max, in proportion to the share of the requests of the last chunk
+//that failed or got a 5xx response, so that a struggling downstream gets more time to recover
+func ErrorRateCooldown(min, max time.Duration) Cooldown {
+	return func(last Results) time.Duration {
+		sent, failed := 0, 0
+		for _, result := range last {
+			switch {
+			case result.Err == nil:
+				sent++
+				if result.Response != nil && result.Response.StatusCode >= http.StatusInternalServerError {
+					failed++
+				}
+			case Code(result.Err) != CodeSkipped && Code(result.Err) != CodeIgnored:
+				sent++
+				failed++
+			}
+		}
+
+		if sent == 0 {
+			return min
+		}
+
+		return min + time.Duration(float64(max-min)*float64(failed)/float64(sent))
+	}
+}
+
+//Chunker sends a large number of requests as consecutive bulks of at most size requests through a BulkClient,
+//each bulk getting the whole timeout of the client, pausing for its Cooldown between bulks
+//as a pressure valve for fragile downstreams
+type Chunker struct {
+	client   *BulkClient
+	size     int
+	workers  int
+	cooldown Cooldown
+
+	probe         HealthProbe
+	probePause    time.Duration
+	probeAttempts int
+}
+
+//NewChunker returns a Chunker sending chunks of up to size requests through client, with workers workers each.
+//The cooldown may be nil for chunks sent back to back.
+func NewChunker(client *BulkClient, size int, workers int, cooldown Cooldown) *Chunker {
+	return &Chunker{client: client, size: size, workers: workers, cooldown: cooldown}
+}
+
+//Do sends requests chunk after chunk and returns their results, indexed by their position in requests.
+//Once ctx is done, the chunks not started yet are not sent and their requests fail with ErrRequestIgnored.
+//Once a HealthProbe found the downstream unhealthy, they fail with ErrDownstreamUnhealthy instead.
+//A chunk failing as a whole, e.g. with ErrConcurrencyBudgetExceeded, fails each of its requests with that error.
+//The bulk of each chunk is released once its responses were closed.
+func (c *Chunker) Do(ctx context.Context, requests []*http.Request) Results {
+	size := c.size
+	if size <= 0 {
+		size = len(requests)
+	}
+
+	var unhealthy bool
+	results := make(Results, 0, len(requests))
+	for offset := 0; offset < len(requests); offset += size {
+		if offset > 0 && c.cooldown != nil {
+			sleep(ctx, c.client.clock, c.cooldown(results[len(results)-size:]))
+		}
+
+		end := offset + size
+		if end > len(requests) {
+			end = len(requests)
+		}
+
+		if !unhealthy && ctx.Err() == nil {
+			unhealthy = !c.healthy(ctx)
+		}
+
+		switch {
+		case unhealthy:
+			results = append(results, failedChunk(requests[offset:end], offset, ErrDownstreamUnhealthy)...)
+			continue
+		case ctx.Err() != nil:
+			results = append(results, failedChunk(requests[offset:end], offset, ErrRequestIgnored)...)
+			continue
+		}
+
+		results = append(results, c.chunk(ctx, requests[offset:end], offset)...)
+	}
+
+	return results
+}
+
+// chunk sends requests as one bulk, offset being the position of its first request
+func (c *Chunker) chunk(ctx context.Context, requests []*http.Request, offset int) Results {
+	bulkRequest := NewBulkRequest(requests, c.workers, c.workers)
+	responses, errs := c.client.DoWithContext(ctx, bulkRequest)
+	if responses == nil {
+		return failedChunk(requests, offset, errs[0])
+	}
+
+	releaseOnceClosed(bulkRequest, responses)
+	chunk := bulkRequest.Results()
+	for i := range chunk {
+		chunk[i].Index += offset
+	}
+
+	return chunk
+}
+
+// failedChunk fails every request of a chunk with err
+func failedChunk(requests []*http.Request, offset int, err error) Results {
+	results := make(Results, len(requests))
+	for i, request := range requests {
+		results[i] = Result{Index: offset + i, Request: request, Err: err}
+	}
+
+	return results
+}
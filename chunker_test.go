@@ -0,0 +1,78 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func requestsTo(t *testing.T, n int) []*http.Request {
+	var requests []*http.Request
+	for i := 0; i < n; i++ {
+		requests = append(requests, mustRequest(t, "http://example.com/"+strconv.Itoa(i)))
+	}
+
+	return requests
+}
+
+func TestChunkerSendsRequestsInChunksWithACooldownBetweenThem(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+	var cooldowns []int
+	chunker := NewChunker(client, 2, 2, func(last Results) time.Duration {
+		cooldowns = append(cooldowns, last[0].Index)
+		return 10 * time.Millisecond
+	})
+
+	start := time.Now()
+	results := chunker.Do(context.Background(), requestsTo(t, 5))
+
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	assert.Equal(t, []int{0, 2}, cooldowns)
+	assert.Len(t, calls, 5)
+	for i, result := range results {
+		assert.Equal(t, i, result.Index)
+		assert.NoError(t, result.Err)
+		body, _ := ioutil.ReadAll(result.Response.Body)
+		result.Response.Body.Close()
+		assert.Equal(t, "/"+strconv.Itoa(i), string(body))
+	}
+}
+
+func TestChunkerIgnoresTheChunksLeftOnceTheContextIsDone(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+	ctx, cancel := context.WithCancel(context.Background())
+	chunker := NewChunker(client, 2, 2, func(Results) time.Duration {
+		cancel()
+		return time.Hour
+	})
+
+	results := chunker.Do(ctx, requestsTo(t, 5))
+
+	assert.Len(t, calls, 2)
+	assert.Len(t, results, 5)
+	for _, result := range results[2:] {
+		assert.Equal(t, ErrRequestIgnored, result.Err)
+	}
+	for _, result := range results[:2] {
+		result.Response.Body.Close()
+	}
+}
+
+func TestErrorRateCooldownGrowsWithTheFailuresOfTheLastChunk(t *testing.T) {
+	cooldown := ErrorRateCooldown(time.Second, 5*time.Second)
+	ok := &http.Response{StatusCode: http.StatusOK}
+	unavailable := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+	assert.Equal(t, time.Second, cooldown(Results{{Response: ok}, {Response: ok}}))
+	assert.Equal(t, 3*time.Second, cooldown(Results{{Response: ok}, {Response: unavailable}}))
+	assert.Equal(t, 5*time.Second, cooldown(Results{{Err: errors.New("connection reset")}, {Err: ErrAlreadySucceeded}}))
+	assert.Equal(t, time.Second, cooldown(Results{{Err: ErrRequestIgnored}}))
+	assert.Equal(t, 2*time.Second, FixedCooldown(2*time.Second)(nil))
+}
@@ -1,13 +1,12 @@
 package meniscus
 
 import (
-	"bytes"
 	"context"
-	"errors"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
 	"sync"
 	"time"
 )
@@ -19,85 +18,283 @@ type HTTPClient interface {
 
 //BulkClient ...
 type BulkClient struct {
-	httpclient HTTPClient
-	timeout    time.Duration
+	httpclient  HTTPClient
+	timeout     time.Duration
+	middlewares []Middleware
+	clock       Clock
+
+	stagedTimeouts  StagedTimeouts
+	forceClose      bool
+	connDiagnostics bool
+	unbuffered      bool
+	bufferPool      *bufferPool
+	spill           spillConfig
+
+	postProcessor      PostProcessor
+	postProcessWorkers int
+	postProcessQueue   int
+
+	profilerLabels bool
+	goroutines     *goroutineBudget
+	sharedPool     *SharedPool
+	routes         RouteNormalizer
+	destinations   *DestinationPolicy
+	pins           CertificatePins
+	verifier       ResponseVerifier
+	cipher         BodyCipher
+	expectContinue expectContinue
+	uploadPolicy   UploadPolicy
+	reducer        Reducer
+	cache          *ResponseCache
+	allowList      headerAllowList
+	cloner         RequestCloner
+	connectTo      connectTo
+	addressFamily  *AddressFamilyPolicy
+	portGuard      *PortGuard
+	ports          *portTracker
+	transportCheck *transportCheck
+
+	saturationCallback   func(SaturationEvent)
+	saturationThresholds SaturationThresholds
+	timeoutAttribution   bool
+	clientTimeoutCheck   bool
+	errorHandler         ErrorHandler
+	registry             *Registry
+	registryName         string
+	name                 string
+	misconfigured        error // the ClientTimeoutError of the client, when checked
+	rampUp               time.Duration
+	limiter              Limiter
+	retry                *retryPolicy
+	fireWorkers          int
+	processWorkers       int
+	logger               Logger
 }
 
 type requestParcel struct {
-	request *http.Request
-	index   int
+	request   *http.Request
+	index     int
+	bulkID    string
+	tags      Tags
+	invalid   error
+	condition func() bool
+	stream    func(io.Writer) error
+	handler   ResponseHandler
+	queued    time.Time // when the bulk was dispatched to the fire workers
+	monitor   *saturationMonitor
+	tracker   *stateTracker
 }
 
 type roundTripParcel struct {
-	response *http.Response
-	request  *http.Request // this is required to recreate a http.Response with a new http.Request without a context
-	err      error
-	index    int
+	response  *http.Response
+	request   *http.Request // this is required to recreate a http.Response with a new http.Request without a context
+	err       error
+	index     int
+	conn      *ConnectionInfo
+	value     interface{}
+	admitted  bool // received before the deadline and handed to the post processors
+	bulkID    string
+	tags      Tags
+	unsent    error // the reason the request was not sent
+	latency   time.Duration
+	queueWait time.Duration
+	streamed  error // returned by the producer of a streamed body
+	handler   ResponseHandler
 }
 
 //NewBulkHTTPClient ...
-func NewBulkHTTPClient(client HTTPClient, timeout time.Duration) *BulkClient {
-	return &BulkClient{
-		httpclient: client,
+func NewBulkHTTPClient(client HTTPClient, timeout time.Duration, opts ...ClientOption) *BulkClient {
+	cl := &BulkClient{
 		timeout:    timeout,
+		clock:      RealClock(),
+		goroutines: &goroutineBudget{},
 	}
+
+	for _, opt := range opts {
+		opt(cl)
+	}
+
+	if cl.transportCheck != nil {
+		cl.transportCheck.client = client
+	}
+
+	if cl.clientTimeoutCheck {
+		cl.misconfigured = checkClientTimeout(client, cl.timeout)
+	}
+
+	if cl.registry != nil && len(cl.registryName) == 0 {
+		cl.registryName = cl.name
+	}
+
+	// first, so that the dialers wrapping the transport later all dial through its checks
+	if cl.destinations != nil {
+		client = cl.destinations.guard(client)
+	}
+
+	if cl.addressFamily != nil {
+		client = cl.addressFamily.client(client)
+	}
+
+	if cl.portGuard != nil {
+		cl.ports = newPortTracker(*cl.portGuard, cl.clock)
+		client = cl.ports.client(client)
+	}
+
+	if cl.expectContinue.enabled() {
+		client = cl.expectContinue.client(client)
+	}
+
+	if len(cl.pins) != 0 {
+		client = cl.pins.pin(client)
+	}
+
+	if cl.connectTo.hosts != nil {
+		client = cl.connectTo.client(client)
+	}
+
+	if httpClient, ok := client.(*http.Client); ok && cl.ports != nil {
+		cl.ports.closeIdle = httpClient.CloseIdleConnections
+	}
+
+	cl.httpclient = Chain(client, append(cl.middlewares, cl.builtinMiddlewares()...)...)
+	return cl
 }
 
 type roundTripChannels struct {
-	requestList        chan requestParcel
-	receivedResponses  chan roundTripParcel
-	processedResponses chan roundTripParcel
-	collectResponses   chan []roundTripParcel
+	requestList            chan requestParcel
+	receivedResponses      chan roundTripParcel
+	processedResponses     chan roundTripParcel
+	postProcessedResponses chan roundTripParcel
+	postProcessGate        *admissionGate
+	collectResponses       chan []roundTripParcel
 }
 
-func newRoundTripChannels() roundTripChannels {
-	return roundTripChannels{
+func newRoundTripChannels(postProcess bool, postProcessQueue int) roundTripChannels {
+	channels := roundTripChannels{
 		requestList:        make(chan requestParcel),
 		receivedResponses:  make(chan roundTripParcel),
 		processedResponses: make(chan roundTripParcel),
 		collectResponses:   make(chan []roundTripParcel),
 	}
+
+	if postProcess {
+		channels.processedResponses = make(chan roundTripParcel, postProcessQueue)
+		channels.postProcessedResponses = make(chan roundTripParcel)
+		channels.postProcessGate = &admissionGate{}
+	}
+
+	return channels
+}
+
+// results is the channel carrying the responses out of the last stage of the pipeline
+func (ch *roundTripChannels) results() chan roundTripParcel {
+	if ch.postProcessedResponses != nil {
+		return ch.postProcessedResponses
+	}
+
+	return ch.processedResponses
 }
 
 //Do ...
 func (cl *BulkClient) Do(bulkRequest *RoundTrip) ([]*http.Response, []error) {
+	return cl.run(bulkRequest, nil)
+}
+
+// do runs the bulk until it completes, its timeout elapses or parent is done
+func (cl *BulkClient) do(parent context.Context, bulkRequest *RoundTrip) ([]*http.Response, []error) {
+	bulkRequest.clientFireWorkers, bulkRequest.clientProcessWorkers = cl.fireWorkers, cl.processWorkers
+
 	noOfRequests := len(bulkRequest.requests)
 	if noOfRequests == 0 {
 		return nil, []error{ErrNoRequests}
 	}
 
+	if cl.misconfigured != nil {
+		return nil, []error{cl.misconfigured}
+	}
+
+	if !cl.goroutines.acquire(cl.workerGoroutines(bulkRequest) + 1) {
+		return nil, []error{ErrConcurrencyBudgetExceeded}
+	}
+
 	bulkRequest.responses = make([]*http.Response, noOfRequests)
 	bulkRequest.errors = make([]error, noOfRequests)
+	bulkRequest.connections = make([]ConnectionInfo, noOfRequests)
+	bulkRequest.values = make([]interface{}, noOfRequests)
+	bulkRequest.latencies = make([]time.Duration, noOfRequests)
+	bulkRequest.queueWaits = make([]time.Duration, noOfRequests)
 
-	roundTripChannels := newRoundTripChannels()
+	roundTripChannels := newRoundTripChannels(cl.postProcessor != nil, cl.postProcessQueue)
+
+	// the publisher reads the bulk, it must be done before the bulk can be run again
+	var publishWg sync.WaitGroup
+	publishWg.Add(1)
+	defer publishWg.Wait()
 
 	stopProcessing := make(chan struct{})
 	defer close(stopProcessing)
 
-	ctx, cancel := context.WithTimeout(context.Background(), cl.timeout)
-	defer cancel()
+	ctx, cancel := withClockTimeout(parent, cl.clock, cl.timeout)
+	bulkRequest.start(cancel)
+	if len(bulkRequest.tenant) != 0 {
+		ctx = withTenant(ctx, bulkRequest.tenant)
+	}
+	if len(cl.name) != 0 {
+		ctx = withClientName(ctx, cl.name)
+	}
+	if cl.cloner != nil {
+		ctx = context.WithValue(ctx, clonerKey{}, cl.cloner)
+	}
+	if cl.unbuffered {
+		bulkRequest.release = cancel
+	} else {
+		defer cancel()
+	}
 
 	for index, req := range bulkRequest.requests {
-		bulkRequest.requests[index] = req.WithContext(ctx)
+		reqCtx := bulkRequest.requestContext(ctx, index)
+		if cl.routes != nil && req != nil {
+			ScopeOf(reqCtx).Route = cl.routes(req.URL)
+		}
+		bulkRequest.requests[index] = bulkRequest.bind(req, reqCtx, index)
+	}
+
+	bulkRequest.dispatched = cl.clock.Now()
+	bulkRequest.monitor = nil
+	if cl.saturationCallback != nil {
+		bulkRequest.monitor = newSaturationMonitor(bulkRequest, cl.saturationThresholds, cl.saturationCallback)
+		bulkRequest.monitor.client = cl.name
+	}
+	if cl.transportCheck != nil {
+		cl.transportCheck.check(bulkRequest)
 	}
 
 	go cl.responseMux(ctx,
 		bulkRequest,
-		roundTripChannels.processedResponses,
+		roundTripChannels.results(),
+		roundTripChannels.postProcessGate,
 		roundTripChannels.collectResponses)
 	go cl.workerManager(ctx,
 		bulkRequest,
 		&roundTripChannels,
-		stopProcessing)
+		stopProcessing,
+		&publishWg)
 
-	cl.completionListener(bulkRequest, roundTripChannels.collectResponses)
+	cl.completionListener(ctx, bulkRequest, roundTripChannels.collectResponses)
 
 	return bulkRequest.responses, bulkRequest.errors
 }
 
-func (cl *BulkClient) completionListener(bulkRequest *RoundTrip, collectResponses chan []roundTripParcel) {
+func (cl *BulkClient) completionListener(ctx context.Context, bulkRequest *RoundTrip, collectResponses chan []roundTripParcel) {
 	responses := <-collectResponses
 	for _, resParcel := range responses {
+		if resParcel.conn != nil {
+			bulkRequest.connections[resParcel.index] = *resParcel.conn
+		}
+		bulkRequest.values[resParcel.index] = resParcel.value
+		bulkRequest.latencies[resParcel.index] = resParcel.latency
+		bulkRequest.queueWaits[resParcel.index] = resParcel.queueWait
+
 		if resParcel.err != nil {
 			bulkRequest.updateErrorForIndex(resParcel.err, resParcel.index)
 		} else {
@@ -106,24 +303,37 @@ func (cl *BulkClient) completionListener(bulkRequest *RoundTrip, collectResponse
 	}
 
 	close(collectResponses)
-	bulkRequest.addRequestIgnoredErrors()
+	states := bulkRequest.tracker.snapshot()
+	bulkRequest.addRequestIgnoredErrors(states, cl.reducer != nil)
+	if ctx.Err() != nil {
+		bulkRequest.interrupt(ctx.Err(), states)
+	}
+	cl.attributeDeadline(ctx, bulkRequest)
+	bulkRequest.finish()
 }
 
 func (cl *BulkClient) responseMux(ctx context.Context,
 	bulkRequest *RoundTrip,
-	processedResponses <-chan roundTripParcel, collectResponses chan<- []roundTripParcel) {
+	processedResponses <-chan roundTripParcel,
+	postProcessGate *admissionGate,
+	collectResponses chan<- []roundTripParcel) {
+	defer cl.goroutines.release(1)
 
 	var arrayOfResponses []roundTripParcel
+	done, admitted := 0, 0
 LOOP:
-	for done := 0; done < len(bulkRequest.requests); {
+	for done < len(bulkRequest.requests) {
 		select {
 		case <-ctx.Done():
 			break LOOP
 
 		case resParcel, isOpen := <-processedResponses:
 			if isOpen {
-				arrayOfResponses = append(arrayOfResponses, resParcel)
+				arrayOfResponses = append(arrayOfResponses, cl.collect(bulkRequest, resParcel))
 				done++
+				if resParcel.admitted {
+					admitted++
+				}
 			} else {
 				break LOOP
 			}
@@ -131,28 +341,58 @@ LOOP:
 
 	}
 
+	// responses received before the deadline are not dropped because they are still being post processed
+	if postProcessGate != nil && done < len(bulkRequest.requests) {
+		for pending := postProcessGate.close() - admitted; pending > 0; {
+			resParcel, isOpen := <-processedResponses
+			if !isOpen {
+				break
+			}
+
+			if resParcel.admitted {
+				arrayOfResponses = append(arrayOfResponses, cl.collect(bulkRequest, resParcel))
+				pending--
+			} else {
+				closeParcel(resParcel)
+			}
+		}
+	}
+
 	collectResponses <- arrayOfResponses
 }
 
-func (cl *BulkClient) workerManager(ctx context.Context, bulkRequest *RoundTrip, roundTripChannels *roundTripChannels, stopProcessing chan struct{}) {
-	var publishWg, fireWg, processWg sync.WaitGroup
+func (cl *BulkClient) workerManager(ctx context.Context,
+	bulkRequest *RoundTrip,
+	roundTripChannels *roundTripChannels,
+	stopProcessing chan struct{},
+	publishWg *sync.WaitGroup) {
+	defer cl.goroutines.release(cl.workerGoroutines(bulkRequest))
+
+	var fireWg, processWg, postProcessWg sync.WaitGroup
 
-	publishWg.Add(1)
 	go bulkRequest.publishAllRequests(roundTripChannels.requestList,
 		stopProcessing,
-		&publishWg)
+		publishWg)
 
-	cl.fireRequestsManager(bulkRequest.fireRequestsWorkers,
+	cl.fireRequestsManager(ctx,
+		bulkRequest.fireWorkers(),
 		roundTripChannels.requestList,
 		roundTripChannels.receivedResponses,
 		stopProcessing,
 		&fireWg)
 	cl.processRequestsManager(ctx,
-		bulkRequest.processResponseWorkers,
+		bulkRequest.processWorkers(),
 		roundTripChannels.receivedResponses,
 		roundTripChannels.processedResponses,
+		roundTripChannels.postProcessGate,
 		stopProcessing,
 		&processWg)
+	if roundTripChannels.postProcessedResponses != nil {
+		cl.postProcessRequestsManager(roundTripChannels.processedResponses,
+			roundTripChannels.postProcessedResponses,
+			stopProcessing,
+			&postProcessWg)
+	}
 
 	publishWg.Wait()
 	close(roundTripChannels.requestList)
@@ -162,9 +402,15 @@ func (cl *BulkClient) workerManager(ctx context.Context, bulkRequest *RoundTrip,
 
 	processWg.Wait()
 	close(roundTripChannels.processedResponses)
+
+	if roundTripChannels.postProcessedResponses != nil {
+		postProcessWg.Wait()
+		close(roundTripChannels.postProcessedResponses)
+	}
 }
 
-func (cl *BulkClient) fireRequestsManager(fireRequestsWorkers int,
+func (cl *BulkClient) fireRequestsManager(ctx context.Context,
+	fireRequestsWorkers int,
 	requestList <-chan requestParcel,
 	recievedResponses chan<- roundTripParcel,
 	stopProcessing <-chan struct{},
@@ -172,7 +418,7 @@ func (cl *BulkClient) fireRequestsManager(fireRequestsWorkers int,
 
 	for nWorker := 0; nWorker < fireRequestsWorkers; nWorker++ {
 		fireWg.Add(1)
-		go cl.fireRequests(requestList, recievedResponses, stopProcessing, fireWg)
+		go cl.fireRequests(ctx, cl.rampDelay(nWorker, fireRequestsWorkers), requestList, recievedResponses, stopProcessing, fireWg)
 	}
 
 }
@@ -180,23 +426,55 @@ func (cl *BulkClient) fireRequestsManager(fireRequestsWorkers int,
 func (cl *BulkClient) processRequestsManager(ctx context.Context,
 	processResponseWorkers int,
 	recievedResponses <-chan roundTripParcel, processedResponses chan<- roundTripParcel,
+	postProcessGate *admissionGate,
 	stopProcessing <-chan struct{}, processWg *sync.WaitGroup) {
 
 	for mWorker := 0; mWorker < processResponseWorkers; mWorker++ {
 		processWg.Add(1)
-		go cl.processRequests(ctx, recievedResponses, processedResponses, stopProcessing, processWg)
+		go cl.processRequests(ctx, recievedResponses, processedResponses, postProcessGate, stopProcessing, processWg)
 	}
 
 }
 
-func (cl *BulkClient) fireRequests(reqList <-chan requestParcel,
+func (cl *BulkClient) fireRequests(ctx context.Context,
+	delay time.Duration,
+	reqList <-chan requestParcel,
 	receivedResponses chan<- roundTripParcel,
 	stopProcessing <-chan struct{},
 	fireWg *sync.WaitGroup) {
 
+	if delay > 0 {
+		// requests picked up once the bulk ended are left unsent below
+		sleep(ctx, cl.clock, delay)
+	}
+
 LOOP:
 	for reqParcel := range reqList {
-		result := cl.executeRequest(reqParcel)
+		if ctx.Err() != nil {
+			// the bulk ended while the request was waiting for a worker, it is left unsent
+			continue
+		}
+
+		queueWait := cl.clock.Now().Sub(reqParcel.queued)
+		reqParcel.monitor.pickedUp(reqParcel.index, queueWait)
+		reqParcel.tracker.advance(reqParcel.index, StateFiring)
+		if scope := ScopeOf(reqParcel.request.Context()); scope != nil {
+			scope.QueueWait = queueWait
+			if cl.timeoutAttribution {
+				scope.pickUp(cl.clock.Now())
+				reqParcel.request = tracePhases(reqParcel.request, scope)
+			}
+		}
+
+		var result roundTripParcel
+		cl.profile(reqParcel.bulkID, reqParcel.tags, reqParcel.request, func(req *http.Request) {
+			reqParcel.request = req
+			result = cl.executeRequest(reqParcel)
+		})
+		reqParcel.monitor.done()
+		reqParcel.tracker.advance(reqParcel.index, StateProcessing)
+		result.queueWait = queueWait
+
 		select {
 		case receivedResponses <- result:
 		case <-stopProcessing:
@@ -212,29 +490,114 @@ LOOP:
 }
 
 func (cl *BulkClient) executeRequest(reqParcel requestParcel) roundTripParcel {
-	resp, err := cl.httpclient.Do(reqParcel.request)
+	req := reqParcel.request
+
+	var conn *ConnectionInfo
+	if cl.connDiagnostics {
+		conn = &ConnectionInfo{}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), conn.trace()))
+	}
+
+	unsent := reqParcel.invalid
+	if unsent == nil && reqParcel.condition != nil && !reqParcel.condition() {
+		unsent = ErrRequestSkipped
+	}
+
+	if vars := VariablesOf(req.Context()); unsent == nil && vars != nil {
+		req, unsent = vars.resolve(req)
+	}
+
+	address := cl.connectTo.address(req)
+	if unsent == nil && len(address) != 0 && !cl.connectTo.routed {
+		unsent = ErrConnectToUnsupported
+	}
+
+	var resp *http.Response
+	err := unsent
+	if err == nil && cl.destinations != nil {
+		err = cl.destinations.check(req.Context(), req.URL)
+	}
+
+	if err == nil && cl.destinations != nil && len(address) != 0 {
+		err = cl.destinations.check(req.Context(), &url.URL{Scheme: req.URL.Scheme, Host: address})
+	}
+
+	var body *bodyStream
+	if err == nil && reqParcel.stream != nil {
+		req, body = stream(req, reqParcel.stream)
+	}
+
+	if err == nil {
+		req, err = cl.cipher.encrypt(req)
+	}
+
+	if err == nil {
+		req, err = cl.uploadPolicy.frame(req)
+	}
+
+	if err == nil && cl.expectContinue.enabled() {
+		req = cl.expectContinue.prepare(req)
+	}
+
+	var latency time.Duration
+	if err == nil {
+		start := cl.clock.Now()
+		resp, err = cl.httpclient.Do(req)
+		latency = cl.clock.Now().Sub(start)
+	}
+
+	var streamed error
+	if body != nil {
+		streamed = body.wait(reqParcel.request.Context(), err)
+	}
+
+	if streamed != nil && resp != nil {
+		resp.Body.Close()
+		resp = nil
+	}
 
 	return roundTripParcel{
 		request:  reqParcel.request,
 		response: resp,
 		err:      err,
 		index:    reqParcel.index,
+		conn:     conn,
+		bulkID:   reqParcel.bulkID,
+		tags:     reqParcel.tags,
+		handler:  reqParcel.handler,
+		unsent:   unsent,
+		latency:  latency,
+		streamed: streamed,
 	}
 }
 
 func (cl *BulkClient) processRequests(ctx context.Context,
 	resList <-chan roundTripParcel,
 	processedResponses chan<- roundTripParcel,
+	postProcessGate *admissionGate,
 	stopProcessing <-chan struct{},
 	processWg *sync.WaitGroup) {
 
 LOOP:
 	for resParcel := range resList {
-		result := cl.parseResponse(ctx, resParcel)
+		var result roundTripParcel
+		cl.profile(resParcel.bulkID, resParcel.tags, resParcel.request, func(*http.Request) {
+			result = handleResponse(cl.parseResponse(ctx, resParcel), resParcel.handler)
+		})
+		result.request = resParcel.request
+		result.bulkID = resParcel.bulkID
+		result.tags = resParcel.tags
+		result.latency = resParcel.latency
+		result.queueWait = resParcel.queueWait
+		result.conn = resParcel.conn
+		if postProcessGate != nil && result.err == nil {
+			result.admitted = postProcessGate.admit()
+		}
 
 		select {
 		case processedResponses <- result:
 		case <-stopProcessing:
+			closeParcel(result)
 			break LOOP
 		}
 	}
@@ -246,29 +609,65 @@ LOOP:
 // It is easy to read from the response object later after we're done processing all requests or we timeout.
 // We do not want to be reading from a response for which the request has been canceled.
 // We simply close the original response at the end of this function.
+// With unbuffered responses the caller reads the original body before the deadline, so it is passed through untouched.
 func (cl *BulkClient) parseResponse(ctx context.Context, res roundTripParcel) roundTripParcel {
-	if res.response != nil {
+	if res.response != nil && cl.buffered() {
 		defer res.response.Body.Close()
 	}
 
+	if res.unsent != nil {
+		return roundTripParcel{err: res.unsent, index: res.index}
+	}
+
+	if res.streamed != nil {
+		return roundTripParcel{err: cl.errorf(CodeClientErr, res.streamed, "error while streaming request body: %s", res.streamed), index: res.index}
+	}
+
 	if res.err != nil && (ctx.Err() == context.Canceled || ctx.Err() == context.DeadlineExceeded) {
 		return roundTripParcel{err: ErrRequestIgnored, index: res.index}
 	}
 
+	if violation := policyViolation(res.err); violation != nil {
+		return roundTripParcel{err: violation, index: res.index}
+	}
+
+	if res.err != nil && requestCancelled(ctx, res.request) {
+		return roundTripParcel{err: ErrRequestCancelled, index: res.index}
+	}
+
+	if timeout := cl.attributeTimeout(res.request, res.err); timeout != nil {
+		return roundTripParcel{err: timeout, index: res.index}
+	}
+
 	if res.err != nil {
-		return roundTripParcel{err: fmt.Errorf("http client error: %s", res.err), index: res.index}
+		return roundTripParcel{err: cl.errorf(CodeClientErr, res.err, "http client error: %s", res.err), index: res.index}
 	}
 
 	if res.response == nil {
-		return roundTripParcel{err: errors.New("no response received"), index: res.index}
+		return roundTripParcel{err: &codedError{code: CodeClientErr, msg: "no response received"}, index: res.index}
 	}
 
-	bs, err := ioutil.ReadAll(res.response.Body)
-	if err != nil {
-		return roundTripParcel{err: fmt.Errorf("error while reading response body: %s", err), index: res.index}
+	if !cl.buffered() {
+		res.response.Body = newBulkBody(ctx, res.response.Body)
+		return roundTripParcel{response: res.response, index: res.index}
+	}
+
+	if cl.allowList.enabled {
+		return roundTripParcel{response: cl.allowList.strip(res.response, res.request.WithContext(context.Background())), index: res.index}
+	}
+
+	body, err := readBody(res.response, cl.bufferPool, cl.spill)
+	if err != nil && requestCancelled(ctx, res.request) {
+		return roundTripParcel{err: ErrRequestCancelled, index: res.index}
+	}
+
+	if timeout := cl.attributeTimeout(res.request, err); timeout != nil {
+		return roundTripParcel{err: timeout, index: res.index}
 	}
 
-	body := ioutil.NopCloser(bytes.NewReader(bs))
+	if err != nil {
+		return roundTripParcel{err: cl.errorf(CodeBodyReadErr, err, "error while reading response body: %s", err), index: res.index}
+	}
 
 	newResponse := http.Response{
 		Body:       body,
@@ -278,6 +677,17 @@ func (cl *BulkClient) parseResponse(ctx context.Context, res roundTripParcel) ro
 		Request:    res.request.WithContext(context.Background()),
 	}
 
+	if cl.verifier != nil {
+		if err := cl.verify(&newResponse); err != nil {
+			body.Close()
+			return roundTripParcel{err: err, index: res.index}
+		}
+	}
+
+	if err := cl.cipher.decrypt(&newResponse); err != nil {
+		return roundTripParcel{err: err, index: res.index}
+	}
+
 	result := roundTripParcel{
 		response: &newResponse,
 		err:      err,
@@ -286,3 +696,12 @@ func (cl *BulkClient) parseResponse(ctx context.Context, res roundTripParcel) ro
 
 	return result
 }
+
+//BufferPoolStats reports on response buffer recycling, it is empty unless the client was built WithBufferPool
+func (cl *BulkClient) BufferPoolStats() BufferPoolStats {
+	if cl.bufferPool == nil {
+		return BufferPoolStats{}
+	}
+
+	return cl.bufferPool.stats()
+}
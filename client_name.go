@@ -0,0 +1,41 @@
+package meniscus
+
+import (
+	"context"
+	"fmt"
+)
+
+//WithName names the client, e.g. "pricing-fanout", so that shared dashboards can tell clients apart.
+//The name is set on the RequestScope of its requests, read by the telemetry middlewares, on its profiler labels
+//and saturation events, is the default name of its Registry metrics and prefixes the error messages it formats.
+//Errors returned as is, e.g. ErrRequestIgnored, are left untouched so that they can still be compared.
+func WithName(name string) ClientOption {
+	return func(cl *BulkClient) {
+		cl.name = name
+	}
+}
+
+//Name returns the name the client was given WithName
+func (cl *BulkClient) Name() string {
+	return cl.name
+}
+
+type clientNameKey struct{}
+
+func withClientName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, clientNameKey{}, name)
+}
+
+func clientNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(clientNameKey{}).(string)
+	return name
+}
+
+// errorf formats an error of the client with code, prefixed with its name when it has one, cause being what it wraps
+func (cl *BulkClient) errorf(code ErrorCode, cause error, format string, args ...interface{}) error {
+	if len(cl.name) != 0 {
+		format = cl.name + ": " + format
+	}
+
+	return &codedError{code: code, msg: fmt.Sprintf(format, args...), cause: cause}
+}
@@ -0,0 +1,44 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestClientNameLabelsItsRequestsMetricsAndErrors(t *testing.T) {
+	registry := NewRegistry()
+	var scopes []*RequestScope
+	client := NewBulkHTTPClient(flakyClient("/a"), NonFailingTimeoutValue,
+		WithName("pricing-fanout"),
+		WithRegistry(registry, ""),
+		WithMiddleware(func(next HTTPClient) HTTPClient {
+			return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				scopes = append(scopes, ScopeOf(req.Context()))
+				return next.Do(req)
+			})
+		}))
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://example.com/a", nil).AddGet("http://example.com/b", nil)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, "pricing-fanout", client.Name())
+	assert.EqualError(t, errs[0], "pricing-fanout: http client error: connection reset")
+	assert.NoError(t, errs[1])
+	if assert.Len(t, scopes, 2) {
+		assert.Equal(t, "pricing-fanout", scopes[0].Client)
+	}
+	assert.Equal(t, "pricing-fanout", bulkRequest.Results()[1].Scope.Client)
+	assert.Equal(t, 2, registry.Snapshot().Clients["pricing-fanout"].Requests)
+}
+
+func TestUnnamedClientsLeaveTheirErrorsAlone(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/a"), NonFailingTimeoutValue)
+
+	_, errs := client.Do(NewBulkRequest(nil).AddGet("http://example.com/a", nil))
+
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "http client error: connection reset")
+	assert.Empty(t, client.Name())
+}
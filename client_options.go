@@ -0,0 +1,55 @@
+package meniscus
+
+import "time"
+
+//Logger receives a line summing up every bulk run by the client, a *log.Logger is a Logger
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+//WithTimeout overrides the timeout given to NewBulkHTTPClient, for clients built from a list of options
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(cl *BulkClient) {
+		cl.timeout = timeout
+	}
+}
+
+//WithFireWorkers sets the number of fire request workers of the bulks run by the client
+//that were created without one, in place of one worker per request up to DefaultWorkers
+func WithFireWorkers(workers int) ClientOption {
+	return func(cl *BulkClient) {
+		cl.fireWorkers = workers
+	}
+}
+
+//WithProcessWorkers sets the number of process response workers of the bulks run by the client
+//that were created without one, in place of their number of fire request workers
+func WithProcessWorkers(workers int) ClientOption {
+	return func(cl *BulkClient) {
+		cl.processWorkers = workers
+	}
+}
+
+//WithLogger has the client log the outcome of every bulk it runs to logger
+func WithLogger(logger Logger) ClientOption {
+	return func(cl *BulkClient) {
+		cl.logger = logger
+	}
+}
+
+// logBulk logs the outcome of the last run of bulkRequest, err being the error of a bulk that failed as a whole
+func (cl *BulkClient) logBulk(bulkRequest *RoundTrip, err error) {
+	prefix := "meniscus: "
+	if len(cl.name) != 0 {
+		prefix += cl.name + ": "
+	}
+
+	if err != nil {
+		cl.logger.Printf("%sbulk %s failed: %s", prefix, bulkRequest.id, err)
+		return
+	}
+
+	counts := bulkRequest.Counts()
+	cl.logger.Printf("%sbulk %s of %d requests: %d succeeded, %d failed, %d ignored, %d skipped, %d retried",
+		prefix, bulkRequest.id, len(bulkRequest.requests), counts.Succeeded, counts.Failed, counts.Ignored, counts.Skipped, counts.Retried)
+}
@@ -0,0 +1,74 @@
+package meniscus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientWorkersApplyToBulksWithoutWorkers(t *testing.T) {
+	var inFlight, peak int32
+	release := make(chan struct{})
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			seen := atomic.LoadInt32(&peak)
+			if current <= seen || atomic.CompareAndSwapInt32(&peak, seen, current) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&inFlight, -1)
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithFireWorkers(2), WithProcessWorkers(1))
+
+	bulkRequest := NewBulkRequest(nil)
+	for i := 0; i < 5; i++ {
+		bulkRequest.AddGet("http://example.com", nil)
+	}
+	assert.Equal(t, 5, bulkRequest.fireWorkers())
+
+	done := make(chan []error)
+	go func() {
+		_, errs := client.Do(bulkRequest)
+		done <- errs
+	}()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&inFlight) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, bulkRequest.fireWorkers())
+	assert.Equal(t, 1, bulkRequest.processWorkers())
+	close(release)
+	assert.Equal(t, make([]error, 5), <-done)
+	bulkRequest.CloseAllResponses()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+
+	explicit := NewBulkRequest(nil, 3)
+	explicit.clientFireWorkers = 2
+	assert.Equal(t, 3, explicit.fireWorkers())
+}
+
+func TestWithTimeoutOverridesTheTimeoutArgument(t *testing.T) {
+	client := NewBulkHTTPClient(nil, NonFailingTimeoutValue, WithTimeout(time.Second))
+	assert.Equal(t, time.Second, client.timeout)
+}
+
+func TestWithLoggerLogsEveryBulk(t *testing.T) {
+	var out bytes.Buffer
+	client := NewBulkHTTPClient(flakyClient("/b"), NonFailingTimeoutValue,
+		WithName("drivers"), WithLogger(log.New(&out, "", 0)))
+
+	bulkRequest := NewBulkRequest(nil, 2, 2).
+		SetID("backfill").
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/b", nil)
+	client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+	client.Do(NewBulkRequest(nil).SetID("empty"))
+
+	assert.Equal(t, "meniscus: drivers: bulk backfill of 2 requests: 1 succeeded, 1 failed, 0 ignored, 0 skipped, 0 retried\n"+
+		"meniscus: drivers: bulk empty failed: no requests provided\n", out.String())
+}
@@ -0,0 +1,36 @@
+package meniscus
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//ClientTimeoutError is returned, with WithClientTimeoutCheck, by a bulk whose http.Client times out before the bulk does
+type ClientTimeoutError struct {
+	ClientTimeout time.Duration
+	BulkTimeout   time.Duration
+}
+
+func (e *ClientTimeoutError) Error() string {
+	return fmt.Sprintf("http client timeout of %s is shorter than the bulk timeout of %s", e.ClientTimeout, e.BulkTimeout)
+}
+
+//WithClientTimeoutCheck makes Do fail with a *ClientTimeoutError, without sending any request, when the Timeout
+//of the http.Client given to NewBulkHTTPClient is shorter than the bulk timeout. Requests of such a client fail
+//with Client.Timeout errors well before the bulk deadline, which is seldom what was meant.
+func WithClientTimeoutCheck() ClientOption {
+	return func(cl *BulkClient) {
+		cl.clientTimeoutCheck = true
+	}
+}
+
+// checkClientTimeout returns the ClientTimeoutError of client, nil when its timeout does not cut the bulk short
+func checkClientTimeout(client HTTPClient, timeout time.Duration) error {
+	httpClient, ok := client.(*http.Client)
+	if !ok || httpClient.Timeout <= 0 || httpClient.Timeout >= timeout {
+		return nil
+	}
+
+	return &ClientTimeoutError{ClientTimeout: httpClient.Timeout, BulkTimeout: timeout}
+}
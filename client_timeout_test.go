@@ -0,0 +1,40 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type transportFunc func(*http.Request) (*http.Response, error)
+
+func (f transportFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientTimeoutCheckFailsBulksCutShortByTheHTTPClient(t *testing.T) {
+	calls := map[string]int{}
+	httpclient := &http.Client{Timeout: 100 * time.Millisecond, Transport: transportFunc(countingClient(calls))}
+	client := NewBulkHTTPClient(httpclient, time.Second, WithClientTimeoutCheck())
+
+	responses, errs := client.Do(NewBulkRequest(nil, 1, 1).AddGet("http://example.com", nil))
+
+	assert.Nil(t, responses)
+	assert.Equal(t, []error{&ClientTimeoutError{ClientTimeout: 100 * time.Millisecond, BulkTimeout: time.Second}}, errs)
+	assert.Equal(t, "http client timeout of 100ms is shorter than the bulk timeout of 1s", errs[0].Error())
+	assert.Empty(t, calls, "no request was sent")
+}
+
+func TestClientTimeoutCheckAcceptsLongerOrNoHTTPClientTimeouts(t *testing.T) {
+	for _, timeout := range []time.Duration{0, time.Second, time.Minute} {
+		calls := map[string]int{}
+		httpclient := &http.Client{Timeout: timeout, Transport: transportFunc(countingClient(calls))}
+		client := NewBulkHTTPClient(httpclient, time.Second, WithClientTimeoutCheck())
+
+		_, errs := client.Do(NewBulkRequest(nil, 1, 1).AddGet("http://example.com", nil))
+
+		assert.Equal(t, []error{nil}, errs)
+		assert.Equal(t, 1, calls[""])
+	}
+}
@@ -0,0 +1,138 @@
+package meniscus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//Clock is the source of time used for timeouts and delays, it can be replaced to control time in tests
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+//Timer is the subset of time.Timer used by the bulk client
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+//RealClock returns the Clock backed by the time package
+func RealClock() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// sleep waits for d on clock, returning early with the context error if ctx is done first
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// afterFunc calls f in its own goroutine once d has elapsed on clock, the returned function stops the timer
+func afterFunc(clock Clock, d time.Duration, f func()) func() bool {
+	if _, ok := clock.(realClock); ok {
+		return time.AfterFunc(d, f).Stop
+	}
+
+	timer := clock.NewTimer(d)
+	stop := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		select {
+		case <-timer.C():
+			f()
+		case <-stop:
+		}
+	}()
+
+	return func() bool {
+		stopped := timer.Stop()
+		once.Do(func() { close(stop) })
+		return stopped
+	}
+}
+
+// withClockTimeout behaves like context.WithTimeout but measures the timeout on clock
+func withClockTimeout(parent context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(parent, timeout)
+	}
+
+	cancelCtx, cancel := context.WithCancel(parent)
+	ctx := &clockDeadlineContext{Context: cancelCtx, deadline: clock.Now().Add(timeout)}
+	timer := clock.NewTimer(timeout)
+
+	go func() {
+		select {
+		case <-timer.C():
+			ctx.expire()
+			cancel()
+		case <-cancelCtx.Done():
+			timer.Stop()
+		}
+	}()
+
+	return ctx, cancel
+}
+
+type clockDeadlineContext struct {
+	context.Context
+	deadline time.Time
+
+	mu      sync.Mutex
+	expired bool
+}
+
+func (c *clockDeadlineContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *clockDeadlineContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.expired {
+		return context.DeadlineExceeded
+	}
+
+	return c.Context.Err()
+}
+
+func (c *clockDeadlineContext) expire() {
+	c.mu.Lock()
+	if c.Context.Err() == nil {
+		c.expired = true
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,66 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBulkClientTimeoutIsMeasuredOnTheInjectedClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	blockingClient := HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	client := NewBulkHTTPClient(blockingClient, time.Minute, WithClock(clock))
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err, "no errors")
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+
+	done := make(chan []error)
+	go func() {
+		_, errs := client.Do(bulkRequest)
+		done <- errs
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Second)
+
+	select {
+	case <-done:
+		t.Fatal("bulk request finished before the fake timeout elapsed")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	assert.Equal(t, []error{ErrRequestIgnored}, <-done)
+}
+
+func TestChaosMiddlewareLatencyUsesTheInjectedClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	okClient := HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusOK), nil
+	})
+	client := NewBulkHTTPClient(okClient, time.Hour, WithClock(clock),
+		WithMiddleware(ChaosMiddleware(ChaosConfig{LatencyPercentage: 100, Latency: time.Minute, Clock: clock})))
+
+	bulkRequest := newBulkClientWithNRequests(2, "http://example.com")
+
+	done := make(chan []*http.Response)
+	go func() {
+		responses, _ := client.Do(bulkRequest)
+		done <- responses
+	}()
+
+	clock.BlockUntil(3)
+	clock.Advance(time.Minute)
+
+	responses := <-done
+	defer bulkRequest.CloseAllResponses()
+	for _, resp := range responses {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
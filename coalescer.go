@@ -0,0 +1,147 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//Coalescer gathers requests submitted one at a time into bulks fired through a BulkClient,
+//so that callers sending a request each still share connections and workers.
+//A bulk is fired once window elapsed after its first request or once it holds maxBatch requests.
+type Coalescer struct {
+	client   *BulkClient
+	window   time.Duration
+	maxBatch int
+	workers  int
+
+	mu         sync.Mutex
+	pending    []coalescedRequest
+	generation int // of the pending batch, so that a window elapsing as the batch fills up does not take the next one
+	stop       func() bool
+}
+
+type coalescedRequest struct {
+	request *http.Request
+	done    chan coalescedResult
+}
+
+type coalescedResult struct {
+	response *http.Response
+	err      error
+}
+
+//NewCoalescer returns a Coalescer firing bulks of up to maxBatch requests through client, with workers workers each
+func NewCoalescer(client *BulkClient, window time.Duration, maxBatch int, workers int) *Coalescer {
+	return &Coalescer{client: client, window: window, maxBatch: maxBatch, workers: workers}
+}
+
+//Submit adds request to the next bulk and waits for its outcome. The caller closes the response body.
+//Cancelling the context of request aborts it within the bulk, failing it with ErrRequestCancelled.
+func (c *Coalescer) Submit(request *http.Request) (*http.Response, error) {
+	done := make(chan coalescedResult, 1)
+
+	c.mu.Lock()
+	c.pending = append(c.pending, coalescedRequest{request: request, done: done})
+	if len(c.pending) == 1 {
+		generation := c.generation
+		c.stop = afterFunc(c.client.clock, c.window, func() { c.flush(generation) })
+	}
+
+	if c.maxBatch > 0 && len(c.pending) >= c.maxBatch {
+		c.stop()
+		batch := c.take()
+		c.mu.Unlock()
+		go c.fire(batch)
+	} else {
+		c.mu.Unlock()
+	}
+
+	result := <-done
+	return result.response, result.err
+}
+
+// flush fires the batch of generation once its window elapsed, unless it was already fired when full
+func (c *Coalescer) flush(generation int) {
+	c.mu.Lock()
+	if generation != c.generation {
+		c.mu.Unlock()
+		return
+	}
+	batch := c.take()
+	c.mu.Unlock()
+
+	c.fire(batch)
+}
+
+func (c *Coalescer) take() []coalescedRequest {
+	batch := c.pending
+	c.pending = nil
+	c.generation++
+	return batch
+}
+
+func (c *Coalescer) fire(batch []coalescedRequest) {
+	if len(batch) == 0 {
+		return
+	}
+
+	fired := make(chan struct{})
+	bulkRequest := NewBulkRequest(nil, c.workers, c.workers)
+	for _, pending := range batch {
+		ctx := pending.request.Context()
+		if ctx.Done() == nil {
+			bulkRequest.AddRequest(pending.request)
+			continue
+		}
+
+		// the request is fired with a context of the bulk, the cancellation of its own is carried through its handle
+		handle := bulkRequest.AddCancellableRequest(pending.request)
+		go cancelWith(ctx, handle, fired)
+	}
+
+	responses, errs := c.client.Do(bulkRequest)
+	close(fired)
+	releaseOnceClosed(bulkRequest, responses)
+
+	for i, pending := range batch {
+		var response *http.Response
+		if responses != nil {
+			response = responses[i]
+		}
+
+		err := errs[0]
+		if len(errs) == len(batch) {
+			err = errs[i]
+		}
+		pending.done <- coalescedResult{response: response, err: err}
+	}
+}
+
+// cancelWith cancels handle once ctx is done, until fired is closed
+func cancelWith(ctx context.Context, handle *RequestHandle, fired <-chan struct{}) {
+	select {
+	case <-ctx.Done():
+		handle.Cancel()
+	case <-fired:
+	}
+}
+
+// releaseOnceClosed releases bulkRequest once each of its responses was closed or read to the end,
+// which unbuffered responses depend on, for helpers handing the responses of their bulks out one by one
+func releaseOnceClosed(bulkRequest *RoundTrip, responses []*http.Response) {
+	var open sync.WaitGroup
+	for _, response := range responses {
+		if response != nil {
+			open.Add(1)
+			var once sync.Once
+			response.Body = &releasingBody{ReadCloser: response.Body, release: func() { once.Do(open.Done) }}
+		}
+	}
+
+	go func() {
+		open.Wait()
+		bulkRequest.CloseAllResponses()
+	}()
+}
@@ -0,0 +1,124 @@
+package meniscus
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoalescerFiresSubmittedRequestsTogether(t *testing.T) {
+	var mu sync.Mutex
+	bulks := map[string]int{}
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		bulk, _ := pprof.Label(req.Context(), "meniscus_bulk")
+		mu.Lock()
+		bulks[bulk]++
+		mu.Unlock()
+
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(req.URL.Path))
+		return resp, nil
+	}), NonFailingTimeoutValue, WithProfilerLabels())
+	coalescer := NewCoalescer(client, time.Hour, 3, 3)
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/"+strconv.Itoa(i), nil)
+			resp, err := coalescer.Submit(req)
+			assert.NoError(t, err)
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			bodies[i] = string(body)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"/0", "/1", "/2"}, bodies)
+	assert.Len(t, bulks, 1)
+}
+
+func TestCoalescerFiresPartialBatchesAfterTheWindow(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue, WithUnbufferedResponses())
+	coalescer := NewCoalescer(client, 5*time.Millisecond, 100, 2)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?kind=fast", nil)
+	resp, err := coalescer.Submit(req)
+
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "fast", string(body))
+}
+
+func TestCoalescerWindowElapsingAsABatchFillsUpDoesNotTakeTheNextOne(t *testing.T) {
+	calls := map[string]int{}
+	var mu sync.Mutex
+	backend := countingClient(calls)
+	clock := NewFakeClock(time.Now())
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return backend(req)
+	}), NonFailingTimeoutValue, WithClock(clock))
+	coalescer := NewCoalescer(client, time.Second, 2, 2)
+
+	submit := func(path string, wg *sync.WaitGroup) {
+		defer wg.Done()
+		resp, err := coalescer.Submit(mustRequest(t, "http://example.com"+path))
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	var full sync.WaitGroup
+	full.Add(2)
+	go submit("/0", &full)
+	go submit("/1", &full)
+	full.Wait()
+
+	var next sync.WaitGroup
+	next.Add(1)
+	go submit("/2", &next)
+	assert.Eventually(t, func() bool {
+		coalescer.mu.Lock()
+		defer coalescer.mu.Unlock()
+		return len(coalescer.pending) == 1
+	}, time.Second, time.Millisecond)
+
+	// the window of the first batch elapsing while it was fired full
+	coalescer.flush(0)
+	coalescer.mu.Lock()
+	assert.Len(t, coalescer.pending, 1)
+	coalescer.mu.Unlock()
+
+	clock.Advance(time.Second)
+	next.Wait()
+	assert.Equal(t, map[string]int{"/0": 1, "/1": 1, "/2": 1}, calls)
+}
+
+func TestCoalescerKeepsTheCancellationOfTheSubmittedRequests(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}), NonFailingTimeoutValue)
+	coalescer := NewCoalescer(client, time.Hour, 1, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := coalescer.Submit(req.WithContext(ctx))
+	assert.Equal(t, ErrRequestCancelled, err)
+}
@@ -0,0 +1,32 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestConditionalRequestsAreSkippedWhenTheirConditionFails(t *testing.T) {
+	var sent []string
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.URL.Path)
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+
+	enabled := false
+	flagged, _ := http.NewRequest(http.MethodGet, "http://example.com/flagged", nil)
+	always, _ := http.NewRequest(http.MethodGet, "http://example.com/always", nil)
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddRequestIf(flagged, func() bool { return enabled }).
+		AddRequestIf(always, func() bool { return true })
+
+	_, errs := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+	assert.Equal(t, []error{ErrRequestSkipped, nil}, errs)
+	assert.Equal(t, []string{"/always"}, sent)
+
+	enabled = true
+	_, errs = client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+	assert.Equal(t, []error{nil, nil}, errs)
+}
@@ -0,0 +1,29 @@
+package meniscus
+
+import (
+	"net/http/httptrace"
+	"time"
+)
+
+//ConnectionInfo describes the connection a request was sent on
+type ConnectionInfo struct {
+	Reused     bool          // the connection was previously used for another request
+	WasIdle    bool          // the connection was taken from the idle pool
+	IdleTime   time.Duration // how long the connection sat in the idle pool
+	RemoteAddr string
+	LocalAddr  string
+}
+
+func (c *ConnectionInfo) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			c.Reused = info.Reused
+			c.WasIdle = info.WasIdle
+			c.IdleTime = info.IdleTime
+			if info.Conn != nil {
+				c.RemoteAddr = info.Conn.RemoteAddr().String()
+				c.LocalAddr = info.Conn.LocalAddr().String()
+			}
+		},
+	}
+}
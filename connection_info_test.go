@@ -0,0 +1,28 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConnectionDiagnosticsReportConnectionReuse(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithConnectionDiagnostics())
+
+	first := newBulkClientWithNRequests(1, server.URL)
+	client.Do(first)
+	first.CloseAllResponses()
+
+	second := newBulkClientWithNRequests(1, server.URL)
+	client.Do(second)
+	second.CloseAllResponses()
+
+	assert.False(t, first.Connections()[0].Reused)
+	assert.True(t, second.Connections()[0].Reused)
+	assert.True(t, second.Connections()[0].WasIdle)
+	assert.Equal(t, strings.TrimPrefix(server.URL, "http://"), second.Connections()[0].RemoteAddr)
+}
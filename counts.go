@@ -0,0 +1,36 @@
+package meniscus
+
+//Counts summarizes the outcome of the requests of a bulk, see RoundTrip.Counts
+type Counts struct {
+	Succeeded int // a response was received, whatever its status
+	Failed    int
+	Ignored   int // left unanswered when the bulk timed out or was cancelled, or cancelled through their RequestHandle
+	Skipped   int // not sent as their condition did not hold, they were filtered out or already succeeded
+	Retried   int // sent more than once, whatever their outcome, see RequestScope.MarkRetried
+}
+
+//Counts summarizes the results of the last Do of the bulk, sparing callers from going through its errors.
+//Every request of a bulk built with RetryFailed counts as retried.
+func (r *RoundTrip) Counts() Counts {
+	retryPass := r.retryPass
+
+	var counts Counts
+	for _, result := range r.Results() {
+		switch Code(result.Err) {
+		case "":
+			counts.Succeeded++
+		case CodeIgnored:
+			counts.Ignored++
+		case CodeSkipped:
+			counts.Skipped++
+		default:
+			counts.Failed++
+		}
+
+		if retryPass || (result.Scope != nil && result.Scope.Retried()) {
+			counts.Retried++
+		}
+	}
+
+	return counts
+}
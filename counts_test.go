@@ -0,0 +1,42 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestCountsSummarizeTheLastDo(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/retried", "/failed"), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/ok", nil).
+		AddSpec(RequestSpec{URL: "http://example.com/retried", Retries: 1}).
+		AddGet("http://example.com/failed", nil).
+		AddRequestIf(mustRequest(t, "http://example.com/skipped"), func() bool { return false })
+	assert.Equal(t, Counts{}, bulkRequest.Counts())
+
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, Counts{Succeeded: 2, Failed: 1, Skipped: 1, Retried: 1}, bulkRequest.Counts())
+
+	retry := bulkRequest.RetryFailed()
+	client.Do(retry)
+	defer retry.CloseAllResponses()
+
+	assert.Equal(t, Counts{Succeeded: 1, Retried: 1}, retry.Counts())
+}
+
+func TestCountsOfACancelledBulk(t *testing.T) {
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/a", nil).AddGet("http://example.com/b", nil)
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		bulkRequest.Cancel()
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}), NonFailingTimeoutValue)
+
+	client.Do(bulkRequest)
+
+	assert.Equal(t, Counts{Ignored: 2}, bulkRequest.Counts())
+}
@@ -0,0 +1,171 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//CrawlOptions bounds a crawl, see BulkClient.Crawl
+type CrawlOptions struct {
+	//Extract returns the URLs to follow from a fetched page, relative ones are resolved against the page URL.
+	//The response body is closed once it returns.
+	Extract  func(Result) []string
+	MaxDepth int // levels of links followed from the seeds, 0 only fetches the seeds
+	MaxPages int // pages fetched in total, defaults to 1000
+	Workers  int // workers firing and processing each level, defaults to 10
+
+	//Robots, when set, makes the crawl fetch the robots.txt of each host once, skip the pages it disallows
+	//and wait its crawl delay between pages of the host
+	Robots    *RobotsCache
+	UserAgent string // sent with every request and matched against robots.txt, defaults to meniscus
+}
+
+//CrawledPage is the outcome of fetching one page of a crawl
+type CrawledPage struct {
+	URL        string
+	Depth      int
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+}
+
+//Crawl fetches the seeds then the pages they link to, breadth first, one bulk per level.
+//Every URL is fetched at most once, and pages are returned in the order they were fetched.
+//Pages disallowed by robots.txt are returned with ErrDisallowedByRobots without being fetched.
+func (cl *BulkClient) Crawl(seeds []string, opts CrawlOptions) []CrawledPage {
+	opts = opts.withDefaults()
+
+	var pages []CrawledPage
+	seen := map[string]bool{}
+	level := unseen(seen, seeds, opts.MaxPages)
+
+	for depth := 0; len(level) != 0 && depth <= opts.MaxDepth; depth++ {
+		if opts.Robots != nil {
+			opts.Robots.fetch(cl, level, opts)
+		}
+
+		var next []string
+		cl.crawlLevel(level, opts, func(page string, result Result) {
+			crawled := CrawledPage{URL: page, Depth: depth, Latency: result.Latency, Err: result.Err}
+			if result.Response != nil {
+				crawled.StatusCode = result.Response.StatusCode
+				if depth < opts.MaxDepth && opts.Extract != nil {
+					next = append(next, resolveLinks(page, opts.Extract(result))...)
+				}
+			}
+			pages = append(pages, crawled)
+		})
+
+		level = unseen(seen, next, opts.MaxPages-len(pages))
+	}
+
+	return pages
+}
+
+// crawlLevel fetches the pages of a level, in rounds taking a single page of each host with a crawl delay,
+// and calls visit for each in order
+func (cl *BulkClient) crawlLevel(level []string, opts CrawlOptions, visit func(string, Result)) {
+	for pending := level; len(pending) != 0; {
+		var round, rest []string
+		var wait time.Duration
+		taken := map[string]bool{}
+
+		for _, page := range pending {
+			switch {
+			case opts.Robots == nil:
+				round = append(round, page)
+			case !opts.Robots.allowed(page):
+				visit(page, Result{Err: ErrDisallowedByRobots})
+			case opts.Robots.delay(page) == 0 || !taken[originOf(page)]:
+				taken[originOf(page)] = true
+				round = append(round, page)
+			default:
+				rest = append(rest, page)
+				if delay := opts.Robots.delay(page); delay > wait {
+					wait = delay
+				}
+			}
+		}
+
+		if len(round) != 0 {
+			bulkRequest := NewBulkRequest(nil, opts.Workers, opts.Workers)
+			for _, page := range round {
+				bulkRequest.AddGet(page, opts.headers())
+			}
+
+			cl.Do(bulkRequest)
+			for _, result := range bulkRequest.Results() {
+				visit(round[result.Index], result)
+			}
+			bulkRequest.CloseAllResponses()
+		}
+
+		if len(rest) != 0 {
+			sleep(context.Background(), cl.clock, wait)
+		}
+		pending = rest
+	}
+}
+
+func (opts CrawlOptions) withDefaults() CrawlOptions {
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = 1000
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = 10
+	}
+
+	return opts
+}
+
+func (opts CrawlOptions) userAgent() string {
+	if len(opts.UserAgent) == 0 {
+		return "meniscus"
+	}
+
+	return opts.UserAgent
+}
+
+func (opts CrawlOptions) headers() http.Header {
+	return http.Header{"User-Agent": {opts.userAgent()}}
+}
+
+func unseen(seen map[string]bool, urls []string, limit int) []string {
+	var fresh []string
+	for _, u := range urls {
+		if len(fresh) >= limit {
+			break
+		}
+
+		if !seen[u] {
+			seen[u] = true
+			fresh = append(fresh, u)
+		}
+	}
+
+	return fresh
+}
+
+func resolveLinks(base string, links []string) []string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+
+	var resolved []string
+	for _, link := range links {
+		linkURL, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+
+		absolute := baseURL.ResolveReference(linkURL)
+		absolute.Fragment = ""
+		resolved = append(resolved, absolute.String())
+	}
+
+	return resolved
+}
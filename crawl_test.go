@@ -0,0 +1,99 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var crawlSite = map[string]string{
+	"/":     "/a /b",
+	"/a":    "/a/1 /b #top",
+	"/b":    "/b/1 /",
+	"/a/1":  "/deep",
+	"/b/1":  "",
+	"/deep": "",
+}
+
+func crawlLinks(result Result) []string {
+	body, _ := ioutil.ReadAll(result.Response.Body)
+	return strings.Fields(string(body))
+}
+
+func TestCrawlFollowsLinksBreadthFirst(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(crawlSite[req.URL.Path]))
+	}))
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+
+	pages := client.Crawl([]string{server.URL + "/"}, CrawlOptions{Extract: crawlLinks, MaxDepth: 2})
+
+	var visited []string
+	for _, page := range pages {
+		assert.NoError(t, page.Err)
+		visited = append(visited, strings.TrimPrefix(page.URL, server.URL))
+	}
+	assert.Equal(t, []string{"/", "/a", "/b", "/a/1", "/b/1"}, visited)
+	assert.Equal(t, 2, pages[len(pages)-1].Depth)
+}
+
+func TestCrawlStopsAtMaxPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(crawlSite[req.URL.Path]))
+	}))
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+
+	pages := client.Crawl([]string{server.URL + "/"}, CrawlOptions{Extract: crawlLinks, MaxDepth: 10, MaxPages: 2})
+
+	assert.Len(t, pages, 2)
+}
+
+func TestCrawlHonoursRobots(t *testing.T) {
+	robotsFetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/robots.txt" {
+			robotsFetches++
+			w.Write([]byte("User-agent: other\nDisallow: /\n\nUser-agent: *\nDisallow: /b\nAllow: /b/1\nCrawl-delay: 0.01\n"))
+			return
+		}
+		w.Write([]byte(crawlSite[req.URL.Path]))
+	}))
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+	robots := NewRobotsCache()
+
+	pages := client.Crawl([]string{server.URL + "/"}, CrawlOptions{Extract: crawlLinks, MaxDepth: 1, Robots: robots})
+	client.Crawl([]string{server.URL + "/a"}, CrawlOptions{Robots: robots})
+
+	results := map[string]error{}
+	for _, page := range pages {
+		results[strings.TrimPrefix(page.URL, server.URL)] = page.Err
+	}
+	assert.Equal(t, map[string]error{"/": nil, "/a": nil, "/b": ErrDisallowedByRobots}, results)
+	assert.Equal(t, 1, robotsFetches)
+}
+
+func TestParseRobotsPicksTheMostSpecificGroupAndRule(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+User-agent: *
+Disallow: /
+
+User-agent: meniscus
+User-agent: other
+Disallow: /private # comment
+Allow: /private/public
+Crawl-delay: 2
+`), "meniscus/1.0")
+
+	assert.True(t, rules.allowed("/"))
+	assert.False(t, rules.allowed("/private/x"))
+	assert.True(t, rules.allowed("/private/public/x"))
+	assert.Equal(t, 2*time.Second, rules.delay)
+	assert.False(t, parseRobots(strings.NewReader("User-agent: *\nDisallow: /\n"), "bot").allowed("/x"))
+}
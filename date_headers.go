@@ -0,0 +1,126 @@
+package meniscus
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//DateHeaders interprets the date based headers of responses, Retry-After, Expires, Cache-Control max-age and Age,
+//against Clock. When the Date header of a response is more than Tolerance away from Clock, the server clock is taken
+//to have drifted and the dates it sent are shifted by the difference before being compared with Clock.
+//Smaller differences are put down to network latency and ignored.
+type DateHeaders struct {
+	Clock     Clock // defaults to the system clock
+	Tolerance time.Duration
+}
+
+//RetryAfter returns how long resp asks to wait before sending the request again, given either in seconds
+//or as a date, and false when it has no valid Retry-After header. Dates already past give 0.
+func (d DateHeaders) RetryAfter(resp *http.Response) (time.Duration, bool) {
+	return d.retryAfter(resp, d.now())
+}
+
+//Expiry returns when resp stops being fresh on Clock, from its Cache-Control max-age less its Age or else
+//from its Expires header, and false when it has neither. Responses marked no-store or no-cache expire right away.
+func (d DateHeaders) Expiry(resp *http.Response) (time.Time, bool) {
+	return d.expiry(resp, d.now())
+}
+
+func (d DateHeaders) retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	wait := date.Add(-d.skew(resp, now)).Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+
+	return wait, true
+}
+
+func (d DateHeaders) expiry(resp *http.Response, now time.Time) (time.Time, bool) {
+	directives := cacheDirectives(resp.Header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return now, true
+	}
+
+	if _, ok := directives["no-cache"]; ok {
+		return now, true
+	}
+
+	if maxAge, err := strconv.Atoi(directives["max-age"]); err == nil {
+		age, _ := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Age")))
+		return now.Add(time.Duration(maxAge-age) * time.Second), true
+	}
+
+	value := resp.Header.Get("Expires")
+	if len(value) == 0 {
+		return time.Time{}, false
+	}
+
+	expires, err := http.ParseTime(value)
+	if err != nil {
+		// an invalid date, e.g. 0, means already expired
+		return now, true
+	}
+
+	return expires.Add(-d.skew(resp, now)), true
+}
+
+// skew returns how far ahead of now the server clock of resp is, 0 within the tolerance or without a Date header
+func (d DateHeaders) skew(resp *http.Response, now time.Time) time.Duration {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0
+	}
+
+	skew := date.Sub(now)
+	if skew <= d.Tolerance && skew >= -d.Tolerance {
+		return 0
+	}
+
+	return skew
+}
+
+func (d DateHeaders) now() time.Time {
+	if d.Clock == nil {
+		return time.Now()
+	}
+
+	return d.Clock.Now()
+}
+
+// cacheDirectives splits a Cache-Control header into its lower cased directives and their unquoted values
+func cacheDirectives(header string) map[string]string {
+	directives := map[string]string{}
+	for _, directive := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(directive), "=", 2)
+		if len(parts[0]) == 0 {
+			continue
+		}
+
+		value := ""
+		if len(parts) == 2 {
+			value = strings.Trim(strings.TrimSpace(parts[1]), `"`)
+		}
+		directives[strings.ToLower(parts[0])] = value
+	}
+
+	return directives
+}
@@ -0,0 +1,113 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func responseWithHeaders(headers map[string]string) *http.Response {
+	resp := &http.Response{Header: http.Header{}}
+	for key, value := range headers {
+		resp.Header.Set(key, value)
+	}
+
+	return resp
+}
+
+func TestRetryAfterReadsDelaysAndDates(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	headers := DateHeaders{Clock: NewFakeClock(now)}
+
+	wait, ok := headers.RetryAfter(responseWithHeaders(map[string]string{"Retry-After": "120"}))
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, wait)
+
+	wait, ok = headers.RetryAfter(responseWithHeaders(map[string]string{"Retry-After": now.Add(30 * time.Second).Format(http.TimeFormat)}))
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, wait)
+
+	wait, ok = headers.RetryAfter(responseWithHeaders(map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)}))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), wait)
+
+	_, ok = headers.RetryAfter(responseWithHeaders(map[string]string{"Retry-After": "soon"}))
+	assert.False(t, ok)
+	_, ok = headers.RetryAfter(responseWithHeaders(nil))
+	assert.False(t, ok)
+}
+
+func TestRetryAfterDatesAreCorrectedForServerClockSkew(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	serverNow := now.Add(5 * time.Minute)
+	resp := responseWithHeaders(map[string]string{
+		"Date":        serverNow.Format(http.TimeFormat),
+		"Retry-After": serverNow.Add(30 * time.Second).Format(http.TimeFormat),
+	})
+
+	wait, _ := DateHeaders{Clock: NewFakeClock(now), Tolerance: time.Minute}.RetryAfter(resp)
+	assert.Equal(t, 30*time.Second, wait)
+
+	wait, _ = DateHeaders{Clock: NewFakeClock(now), Tolerance: 10 * time.Minute}.RetryAfter(resp)
+	assert.Equal(t, 5*time.Minute+30*time.Second, wait)
+}
+
+func TestExpiryPrefersMaxAgeOverExpires(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	headers := DateHeaders{Clock: NewFakeClock(now), Tolerance: time.Second}
+
+	expiry, ok := headers.Expiry(responseWithHeaders(map[string]string{
+		"Cache-Control": "public, max-age=60",
+		"Age":           "20",
+		"Expires":       now.Add(time.Hour).Format(http.TimeFormat),
+	}))
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(40*time.Second), expiry)
+
+	expiry, ok = headers.Expiry(responseWithHeaders(map[string]string{
+		"Date":    now.Add(-time.Hour).Format(http.TimeFormat),
+		"Expires": now.Add(-time.Hour + time.Minute).Format(http.TimeFormat),
+	}))
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Minute), expiry)
+
+	expiry, ok = headers.Expiry(responseWithHeaders(map[string]string{"Cache-Control": "no-store"}))
+	assert.True(t, ok)
+	assert.Equal(t, now, expiry)
+
+	_, ok = headers.Expiry(responseWithHeaders(nil))
+	assert.False(t, ok)
+}
+
+func TestResponseCacheHonoursHeaderExpiry(t *testing.T) {
+	calls := map[string]int{}
+	clock := NewFakeClock(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC))
+	server := HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		calls[req.URL.Path]++
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(req.URL.Path))
+		// the server clock runs an hour ahead
+		serverNow := clock.Now().Add(time.Hour)
+		resp.Header.Set("Date", serverNow.Format(http.TimeFormat))
+		switch req.URL.Path {
+		case "/expires":
+			resp.Header.Set("Expires", serverNow.Add(2*time.Minute).Format(http.TimeFormat))
+		case "/no-store":
+			resp.Header.Set("Cache-Control", "no-store")
+		}
+		return resp, nil
+	})
+	cache := NewResponseCache(0, 10*time.Minute).SetHeaderExpiry(DateHeaders{Tolerance: time.Second})
+	client := NewBulkHTTPClient(server, NonFailingTimeoutValue, WithClock(clock), WithResponseCache(cache))
+
+	fetch(client, "http://example.com/expires", "http://example.com/no-store", "http://example.com/ttl")
+	clock.Advance(time.Minute)
+	fetch(client, "http://example.com/expires", "http://example.com/no-store", "http://example.com/ttl")
+	clock.Advance(2 * time.Minute)
+	fetch(client, "http://example.com/expires", "http://example.com/no-store", "http://example.com/ttl")
+
+	assert.Equal(t, map[string]int{"/expires": 2, "/no-store": 3, "/ttl": 1}, calls)
+}
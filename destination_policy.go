@@ -0,0 +1,286 @@
+package meniscus
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var privateNetworks = mustParseCIDRs(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+	"169.254.0.0/16",
+	"127.0.0.0/8",
+	"0.0.0.0/8",
+	"::1/128",
+	"::/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+//DestinationPolicy restricts where the requests of a bulk may be sent, for services building URLs from user input.
+//Requests breaking the policy are not sent and fail with ErrDestinationNotAllowed.
+//When the client is an *http.Client, redirect targets and the address of every connection are checked too.
+type DestinationPolicy struct {
+	Schemes      []string     // allowed schemes, defaults to http and https
+	AllowedHosts []string     // when set, only these hosts are allowed, *.example.com matches any subdomain
+	DeniedHosts  []string     // hosts never allowed, same syntax as AllowedHosts
+	AllowedCIDRs []*net.IPNet // when set, every address the host resolves to must be in one of them
+	DeniedCIDRs  []*net.IPNet // addresses never allowed
+	DenyPrivate  bool         // deny loopback, private, link local and unspecified addresses
+	MaxRedirects int          // redirects followed before failing with ErrTooManyRedirects, defaults to 10
+	Resolver     *net.Resolver
+}
+
+// guard checks every redirect target against the policy so a 302 cannot bounce a request
+// to a destination it could not reach directly, and every address dialed so a host resolving to another
+// address at dial time than when it was checked cannot reach a denied one either, the check before sending
+// being only a fast path. Only an *http.Client follows redirects and dials itself,
+// it is copied rather than changed since it may be shared. Any CheckRedirect of its own still runs.
+func (p *DestinationPolicy) guard(client HTTPClient) HTTPClient {
+	httpClient, ok := client.(*http.Client)
+	if !ok {
+		return client
+	}
+
+	guarded := *httpClient
+	checkRedirect := httpClient.CheckRedirect
+	guarded.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= p.maxRedirects() {
+			return ErrTooManyRedirects
+		}
+
+		if err := p.check(req.Context(), req.URL); err != nil {
+			return err
+		}
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+
+		return nil
+	}
+
+	if transport, ok := p.guardedTransport(httpClient.Transport); ok {
+		guarded.Transport = transport
+	}
+
+	return &guarded
+}
+
+// guardedTransport returns a copy of roundTripper checking the address of every connection it opens.
+// A transport dialing by itself gets a dialer running the check before connecting, a custom dial function
+// has its connection checked once established, before anything is sent on it.
+// Behind a proxy, the address checked is that of the proxy.
+func (p *DestinationPolicy) guardedTransport(roundTripper http.RoundTripper) (*http.Transport, bool) {
+	if !p.checksAddresses() {
+		return nil, false
+	}
+
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+
+	base, ok := roundTripper.(*http.Transport)
+	if !ok {
+		return nil, false
+	}
+
+	transport := base.Clone()
+	if transport.DialContext == nil {
+		transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: p.control}).DialContext
+	} else {
+		transport.DialContext = p.checkedDial(transport.DialContext)
+	}
+
+	if transport.DialTLSContext != nil {
+		transport.DialTLSContext = p.checkedDial(transport.DialTLSContext)
+	}
+
+	return transport, true
+}
+
+func (p *DestinationPolicy) control(network, address string, _ syscall.RawConn) error {
+	return p.checkAddress(address)
+}
+
+func (p *DestinationPolicy) checkedDial(dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.checkAddress(conn.RemoteAddr().String()); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return conn, nil
+	}
+}
+
+// checkAddress checks the ip:port address of a connection
+func (p *DestinationPolicy) checkAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrDestinationNotAllowed
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !p.addressAllowed(ip) {
+		return ErrDestinationNotAllowed
+	}
+
+	return nil
+}
+
+func (p *DestinationPolicy) checksAddresses() bool {
+	return len(p.AllowedCIDRs) != 0 || len(p.DeniedCIDRs) != 0 || p.DenyPrivate
+}
+
+func (p *DestinationPolicy) maxRedirects() int {
+	if p.MaxRedirects <= 0 {
+		return 10
+	}
+
+	return p.MaxRedirects
+}
+
+func (p *DestinationPolicy) check(ctx context.Context, u *url.URL) error {
+	if u == nil || !p.schemeAllowed(strings.ToLower(u.Scheme)) {
+		return ErrDestinationNotAllowed
+	}
+
+	host := normalizeHost(u.Hostname())
+	if matchesAnyHost(p.DeniedHosts, host) || (len(p.AllowedHosts) != 0 && !matchesAnyHost(p.AllowedHosts, host)) {
+		return ErrDestinationNotAllowed
+	}
+
+	if !p.checksAddresses() {
+		return nil
+	}
+
+	ips, err := p.resolve(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range ips {
+		if !p.addressAllowed(ip) {
+			return ErrDestinationNotAllowed
+		}
+	}
+
+	return nil
+}
+
+func (p *DestinationPolicy) schemeAllowed(scheme string) bool {
+	if len(p.Schemes) == 0 {
+		return scheme == "http" || scheme == "https"
+	}
+
+	for _, allowed := range p.Schemes {
+		if strings.EqualFold(allowed, scheme) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *DestinationPolicy) addressAllowed(ip net.IP) bool {
+	if containsIP(p.DeniedCIDRs, ip) || (p.DenyPrivate && containsIP(privateNetworks, ip)) {
+		return false
+	}
+
+	return len(p.AllowedCIDRs) == 0 || containsIP(p.AllowedCIDRs, ip)
+}
+
+func (p *DestinationPolicy) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	resolver := p.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+
+	return ips, nil
+}
+
+func matchesAnyHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		pattern = normalizeHost(pattern)
+		if pattern == host || (strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeHost lower cases host and drops the trailing dot of a fully qualified name, which names the same host
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+
+	return networks
+}
+
+// policyViolation returns the destination or pinning policy error err stands for, if any, so it reaches the caller as is
+// rather than flattened into a client error, including when the http client wrapped it in a url.Error
+// or the dialer in a net.OpError
+func policyViolation(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+
+	// refused at dial time
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+
+	if err == ErrDestinationNotAllowed || err == ErrTooManyRedirects || err == ErrPinMismatch {
+		return err
+	}
+
+	return nil
+}
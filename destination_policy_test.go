@@ -0,0 +1,161 @@
+package meniscus
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDestinationPolicyChecksSchemesHostsAndAddresses(t *testing.T) {
+	_, office, _ := net.ParseCIDR("203.0.113.0/24")
+	policy := &DestinationPolicy{
+		DeniedHosts: []string{"*.internal.example.com"},
+		DeniedCIDRs: []*net.IPNet{office},
+		DenyPrivate: true,
+	}
+
+	for raw, allowed := range map[string]bool{
+		"http://93.184.216.34/":              true,
+		"ftp://93.184.216.34/":               false,
+		"http://10.1.2.3/":                   false,
+		"http://127.0.0.1:8080/":             false,
+		"http://[::1]/":                      false,
+		"http://169.254.169.254/latest/":     false,
+		"http://203.0.113.7/":                false,
+		"http://admin.internal.example.com/": false,
+	} {
+		u, _ := url.Parse(raw)
+		err := policy.check(context.Background(), u)
+		if allowed {
+			assert.NoError(t, err, raw)
+		} else {
+			assert.Equal(t, ErrDestinationNotAllowed, err, raw)
+		}
+	}
+}
+
+func TestDestinationPolicyAllowlists(t *testing.T) {
+	_, partners, _ := net.ParseCIDR("198.51.100.0/24")
+	hosts := &DestinationPolicy{AllowedHosts: []string{"api.example.com", "*.partner.example.com"}}
+	cidrs := &DestinationPolicy{AllowedCIDRs: []*net.IPNet{partners}}
+
+	check := func(policy *DestinationPolicy, raw string) error {
+		u, _ := url.Parse(raw)
+		return policy.check(context.Background(), u)
+	}
+
+	assert.NoError(t, check(hosts, "https://API.example.com/drivers"))
+	assert.NoError(t, check(hosts, "https://eu.partner.example.com/"))
+	assert.Equal(t, ErrDestinationNotAllowed, check(hosts, "https://example.com/"))
+	assert.NoError(t, check(cidrs, "http://198.51.100.20/"))
+	assert.Equal(t, ErrDestinationNotAllowed, check(cidrs, "http://198.51.101.20/"))
+}
+
+func TestDestinationPolicyMatchesFullyQualifiedHosts(t *testing.T) {
+	denied := &DestinationPolicy{DeniedHosts: []string{"internal.corp", "*.admin.corp."}}
+	allowed := &DestinationPolicy{AllowedHosts: []string{"api.example.com."}}
+
+	for raw, policy := range map[string]*DestinationPolicy{
+		"http://internal.corp./":        denied,
+		"http://INTERNAL.corp./":        denied,
+		"http://eu.admin.corp/":         denied,
+		"http://eu.admin.corp./":        denied,
+		"http://api.example.com.evil./": allowed,
+		"http://other.example.com./":    allowed,
+	} {
+		u, _ := url.Parse(raw)
+		assert.Equal(t, ErrDestinationNotAllowed, policy.check(context.Background(), u), raw)
+	}
+
+	u, _ := url.Parse("https://api.example.com/")
+	assert.NoError(t, allowed.check(context.Background(), u))
+	u, _ = url.Parse("https://api.example.com./")
+	assert.NoError(t, allowed.check(context.Background(), u))
+}
+
+func TestRequestsToDeniedDestinationsAreNotSent(t *testing.T) {
+	sent := 0
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithDestinationPolicy(DestinationPolicy{AllowedHosts: []string{"example.com"}}))
+
+	allowed, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	denied, _ := http.NewRequest(http.MethodGet, "http://metadata.google.internal/", nil)
+	bulkRequest := NewBulkRequest([]*http.Request{allowed, denied}, 1, 1)
+
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, ErrDestinationNotAllowed}, errs)
+	assert.Equal(t, 1, sent)
+}
+
+func TestRedirectsAreCheckedAgainstTheDestinationPolicy(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer internal.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/start":
+			http.Redirect(w, req, "/ok", http.StatusFound)
+		case "/bounce":
+			http.Redirect(w, req, strings.Replace(internal.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+		case "/loop":
+			http.Redirect(w, req, "/loop", http.StatusFound)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer public.Close()
+
+	publicURL, _ := url.Parse(public.URL)
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue,
+		WithDestinationPolicy(DestinationPolicy{AllowedHosts: []string{publicURL.Hostname()}, MaxRedirects: 3}))
+
+	bulkRequest := NewBulkRequest(nil, 1, 1)
+	for _, path := range []string{"/start", "/bounce", "/loop"} {
+		req, _ := http.NewRequest(http.MethodGet, public.URL+path, nil)
+		bulkRequest.AddRequest(req)
+	}
+
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.NoError(t, errs[0])
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, []error{ErrDestinationNotAllowed, ErrTooManyRedirects}, errs[1:])
+}
+
+func TestDestinationPolicyIsEnforcedWhenDialing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+
+	policy := &DestinationPolicy{DenyPrivate: true}
+	dialer := &net.Dialer{}
+	for _, transport := range []*http.Transport{{}, {DialContext: dialer.DialContext}} {
+		client := policy.guard(&http.Client{Transport: transport, Timeout: NonFailingTimeoutValue})
+
+		// sent past the check done before sending, as if the host resolved elsewhere by then
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		_, err := client.Do(req)
+
+		assert.Equal(t, ErrDestinationNotAllowed, policyViolation(err))
+	}
+
+	allowed := (&DestinationPolicy{DeniedHosts: []string{"example.com"}}).guard(&http.Client{Timeout: NonFailingTimeoutValue})
+	resp, err := allowed.Do(mustRequest(t, server.URL))
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
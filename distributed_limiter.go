@@ -0,0 +1,51 @@
+package meniscus
+
+import (
+	"context"
+	"time"
+)
+
+//Acquirer grants the requests of a global quota shared by horizontally scaled workers, e.g. one backed by Redis
+//or a sidecar. Acquire takes one request of the quota named key, or returns how long to wait before asking again.
+type Acquirer interface {
+	Acquire(ctx context.Context, key string) (retryAfter time.Duration, err error)
+}
+
+//AcquirerFunc is an adapter to allow the use of ordinary functions as an Acquirer
+type AcquirerFunc func(ctx context.Context, key string) (time.Duration, error)
+
+//Acquire calls f(ctx, key)
+func (f AcquirerFunc) Acquire(ctx context.Context, key string) (time.Duration, error) {
+	return f(ctx, key)
+}
+
+//DistributedLimiter is a Limiter taking its requests from the quota key of an Acquirer,
+//so that every worker running bulks with it collectively respects the quota
+type DistributedLimiter struct {
+	acquirer Acquirer
+	key      string
+	clock    Clock
+}
+
+//NewDistributedLimiter returns a Limiter for the quota key of acquirer, to be passed to WithLimiter
+func NewDistributedLimiter(acquirer Acquirer, key string) *DistributedLimiter {
+	return &DistributedLimiter{acquirer: acquirer, key: key, clock: RealClock()}
+}
+
+//Wait asks the Acquirer for a request of the quota until one is granted. It fails with the error of the Acquirer,
+//or with the context error once ctx is done while waiting to ask again.
+func (l *DistributedLimiter) Wait(ctx context.Context) error {
+	for {
+		retryAfter, err := l.acquirer.Acquire(ctx, l.key)
+		if err != nil {
+			return err
+		}
+		if retryAfter <= 0 {
+			return nil
+		}
+
+		if err := sleep(ctx, l.clock, retryAfter); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// windowQuota grants perWindow requests per key until the window is rolled over
+type windowQuota struct {
+	mu        sync.Mutex
+	perWindow int
+	used      map[string]int
+	asked     int
+}
+
+func (q *windowQuota) Acquire(ctx context.Context, key string) (time.Duration, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.asked++
+	if q.used[key] >= q.perWindow {
+		return time.Millisecond, nil
+	}
+	q.used[key]++
+	return 0, nil
+}
+
+func (q *windowQuota) roll() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.used = map[string]int{}
+}
+
+func TestDistributedLimiterWaitsForTheGlobalQuota(t *testing.T) {
+	quota := &windowQuota{perWindow: 2, used: map[string]int{}}
+	workers := []*DistributedLimiter{NewDistributedLimiter(quota, "orders"), NewDistributedLimiter(quota, "orders")}
+	ctx := context.Background()
+
+	assert.NoError(t, workers[0].Wait(ctx))
+	assert.NoError(t, workers[1].Wait(ctx))
+
+	granted := make(chan error)
+	go func() { granted <- workers[0].Wait(ctx) }()
+	assert.Eventually(t, func() bool {
+		quota.mu.Lock()
+		defer quota.mu.Unlock()
+		return quota.asked > 3
+	}, time.Second, time.Millisecond)
+
+	quota.roll()
+	assert.NoError(t, <-granted)
+	assert.NoError(t, NewDistributedLimiter(quota, "payments").Wait(ctx))
+}
+
+func TestDistributedLimiterFailsWithTheAcquirer(t *testing.T) {
+	unreachable := NewDistributedLimiter(AcquirerFunc(func(context.Context, string) (time.Duration, error) {
+		return 0, errors.New("redis unreachable")
+	}), "orders")
+	assert.EqualError(t, unreachable.Wait(context.Background()), "redis unreachable")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	exhausted := NewDistributedLimiter(AcquirerFunc(func(context.Context, string) (time.Duration, error) {
+		return time.Hour, nil
+	}), "orders")
+	assert.Equal(t, context.DeadlineExceeded, exhausted.Wait(ctx))
+}
@@ -0,0 +1,115 @@
+// Package doh resolves many names at once over DNS-over-HTTPS, using the application/dns-json API
+package doh
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"net/http"
+	"net/url"
+)
+
+//DefaultServer is the DoH endpoint used when Resolver.Server is empty
+const DefaultServer = "https://cloudflare-dns.com/dns-query"
+
+//Resolver sends one DoH query per name through a BulkClient, zero values get the defaults
+type Resolver struct {
+	Client  *meniscus.BulkClient
+	Server  string // defaults to DefaultServer
+	Workers int    // defaults to one per name, up to 50
+}
+
+//Answer is a resource record of a resolution
+type Answer struct {
+	Name string `json:"name"`
+	Type int    `json:"type"`
+	TTL  int    `json:"TTL"`
+	Data string `json:"data"`
+}
+
+//Resolution is the outcome of resolving one name
+type Resolution struct {
+	Name    string
+	Type    string
+	Rcode   int // the DNS response code, 0 when the name resolved
+	Answers []Answer
+	Err     error
+}
+
+//RcodeError is returned for a name the server answered with an error response code, e.g. NXDOMAIN
+type RcodeError int
+
+var rcodeNames = map[RcodeError]string{1: "FORMERR", 2: "SERVFAIL", 3: "NXDOMAIN", 4: "NOTIMP", 5: "REFUSED"}
+
+func (e RcodeError) Error() string {
+	if name, ok := rcodeNames[e]; ok {
+		return "doh: " + name
+	}
+
+	return fmt.Sprintf("doh: rcode %d", int(e))
+}
+
+//Resolve queries every name for records of type qtype, e.g. A, AAAA or MX, in the same order as names
+func (r *Resolver) Resolve(names []string, qtype string) []Resolution {
+	resolutions := make([]Resolution, len(names))
+	if len(names) == 0 {
+		return resolutions
+	}
+
+	server := r.Server
+	if len(server) == 0 {
+		server = DefaultServer
+	}
+
+	workers := r.Workers
+	if workers <= 0 {
+		workers = len(names)
+		if workers > 50 {
+			workers = 50
+		}
+	}
+
+	bulkRequest := meniscus.NewBulkRequest(nil, workers, workers)
+	for _, name := range names {
+		query := url.Values{}
+		query.Set("name", name)
+		query.Set("type", qtype)
+
+		headers := http.Header{}
+		headers.Set("Accept", "application/dns-json")
+		bulkRequest.AddGet(server+"?"+query.Encode(), headers)
+	}
+
+	r.Client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	for _, result := range bulkRequest.Results() {
+		resolution := Resolution{Name: names[result.Index], Type: qtype, Err: result.Err}
+		if result.Response != nil {
+			resolution.Rcode, resolution.Answers, resolution.Err = decode(result.Response)
+		}
+		resolutions[result.Index] = resolution
+	}
+
+	return resolutions
+}
+
+func decode(res *http.Response) (int, []Answer, error) {
+	if res.StatusCode != http.StatusOK {
+		return 0, nil, fmt.Errorf("doh: unexpected status %d", res.StatusCode)
+	}
+
+	var message struct {
+		Status int      `json:"Status"`
+		Answer []Answer `json:"Answer"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&message); err != nil {
+		return 0, nil, fmt.Errorf("error while decoding dns-json response: %s", err)
+	}
+
+	if message.Status != 0 {
+		return message.Status, message.Answer, RcodeError(message.Status)
+	}
+
+	return message.Status, message.Answer, nil
+}
@@ -0,0 +1,43 @@
+package doh
+
+import (
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResolveReturnsTheAnswersOfEveryName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "application/dns-json", req.Header.Get("Accept"))
+		assert.Equal(t, "A", req.URL.Query().Get("type"))
+
+		switch name := req.URL.Query().Get("name"); name {
+		case "example.com":
+			fmt.Fprintf(w, `{"Status":0,"Answer":[{"name":"example.com.","type":1,"TTL":300,"data":"93.184.216.34"}]}`)
+		case "missing.example":
+			fmt.Fprintf(w, `{"Status":3,"Answer":[]}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	resolver := &Resolver{
+		Client: meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second),
+		Server: server.URL + "/dns-query",
+	}
+	resolutions := resolver.Resolve([]string{"example.com", "missing.example", "bad name"}, "A")
+
+	require.Len(t, resolutions, 3)
+	assert.Equal(t, Resolution{Name: "example.com", Type: "A",
+		Answers: []Answer{{Name: "example.com.", Type: 1, TTL: 300, Data: "93.184.216.34"}}}, resolutions[0])
+	assert.Equal(t, 3, resolutions[1].Rcode)
+	assert.Equal(t, RcodeError(3), resolutions[1].Err)
+	assert.Equal(t, "doh: NXDOMAIN", resolutions[1].Err.Error())
+	assert.EqualError(t, resolutions[2].Err, "doh: unexpected status 400")
+}
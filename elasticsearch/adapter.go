@@ -0,0 +1,279 @@
+// Package elasticsearch sends many logical search and index operations to Elasticsearch
+// as a few _msearch and _bulk requests fired through a meniscus.BulkClient
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//ErrItemCountMismatch is returned for the operations of a batch whose response does not hold one item per operation
+var ErrItemCountMismatch = errors.New("elasticsearch: response items do not match the operations sent")
+
+//Adapter batches operations into NDJSON payloads, zero values get the defaults
+type Adapter struct {
+	Client    *meniscus.BulkClient
+	URL       string // of the cluster, e.g. http://localhost:9200
+	BatchSize int    // operations per request, defaults to 500
+	Workers   int    // defaults to one per batch, up to 10
+}
+
+//Search is a single search of an _msearch request
+type Search struct {
+	Index string      // may be empty to search the index of the URL or every index
+	Query interface{} // the search body, e.g. map[string]interface{}{"query": ...}
+}
+
+//Operation is a single action of a _bulk request
+type Operation struct {
+	Action   string // index, create, update or delete, defaults to index
+	Index    string
+	ID       string
+	Document interface{} // the source, or the update body for update, ignored for delete
+}
+
+//ItemError is the error Elasticsearch reported for an operation, or for a whole batch it rejected
+type ItemError struct {
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("elasticsearch: %s: %s (status %d)", e.Type, e.Reason, e.Status)
+}
+
+//MultiSearch runs searches as _msearch requests and returns one result per search, in the same order.
+//The Value of a successful result is the json.RawMessage of its search response, a failed search fails with an *ItemError.
+func (a *Adapter) MultiSearch(searches []Search) []meniscus.Result {
+	payloads := make([][]byte, len(searches))
+	errs := make([]error, len(searches))
+	for i, search := range searches {
+		header := map[string]string{}
+		if len(search.Index) > 0 {
+			header["index"] = search.Index
+		}
+		payloads[i], errs[i] = ndjson(header, search.Query)
+	}
+
+	return a.send("/_msearch", payloads, errs, splitSearches)
+}
+
+//Bulk runs ops as _bulk requests and returns one result per operation, in the same order.
+//The Value of a successful result is the json.RawMessage of its item, e.g. {"_index":...,"_id":...,"status":201},
+//a rejected operation fails with an *ItemError.
+func (a *Adapter) Bulk(ops []Operation) []meniscus.Result {
+	payloads := make([][]byte, len(ops))
+	errs := make([]error, len(ops))
+	for i, op := range ops {
+		action := op.Action
+		if len(action) == 0 {
+			action = "index"
+		}
+
+		meta := map[string]string{}
+		if len(op.Index) > 0 {
+			meta["_index"] = op.Index
+		}
+		if len(op.ID) > 0 {
+			meta["_id"] = op.ID
+		}
+
+		header := map[string]map[string]string{action: meta}
+		if action == "delete" {
+			payloads[i], errs[i] = ndjson(header)
+			continue
+		}
+		payloads[i], errs[i] = ndjson(header, op.Document)
+	}
+
+	return a.send("/_bulk", payloads, errs, splitBulkItems)
+}
+
+// batch is one NDJSON request and the operations it carries
+type batch struct {
+	body       bytes.Buffer
+	operations []int
+}
+
+func (a *Adapter) send(path string, payloads [][]byte, errs []error, split func([]byte) ([]json.RawMessage, error)) []meniscus.Result {
+	results := make([]meniscus.Result, len(payloads))
+	batches := a.batch(payloads, errs, results)
+	if len(batches) == 0 {
+		return results
+	}
+
+	workers := a.Workers
+	if workers <= 0 {
+		workers = len(batches)
+		if workers > 10 {
+			workers = 10
+		}
+	}
+
+	bulkRequest := meniscus.NewBulkRequest(nil, workers, workers)
+	for _, b := range batches {
+		req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(a.URL, "/")+path, bytes.NewReader(b.body.Bytes()))
+		if err != nil {
+			return failAll(batches, results, err)
+		}
+
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		bulkRequest.AddRequest(req)
+	}
+
+	a.Client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	for _, result := range bulkRequest.Results() {
+		items, err := readItems(result, split)
+		operations := batches[result.Index].operations
+		if err == nil && len(items) != len(operations) {
+			err = ErrItemCountMismatch
+		}
+
+		for i, index := range operations {
+			opResult := meniscus.Result{Index: index, Request: result.Request, Latency: result.Latency, Err: err}
+			if err == nil {
+				opResult.Value = items[i]
+				opResult.Err = itemError(items[i])
+			}
+			results[index] = opResult
+		}
+	}
+
+	return results
+}
+
+// batch groups the encoded operations into requests of up to BatchSize operations,
+// failing in results the ones that could not be encoded
+func (a *Adapter) batch(payloads [][]byte, errs []error, results []meniscus.Result) []*batch {
+	size := a.BatchSize
+	if size <= 0 {
+		size = 500
+	}
+
+	var batches []*batch
+	for index, payload := range payloads {
+		if errs[index] != nil {
+			results[index] = meniscus.Result{Index: index, Err: errs[index]}
+			continue
+		}
+
+		if len(batches) == 0 || len(batches[len(batches)-1].operations) == size {
+			batches = append(batches, &batch{})
+		}
+
+		current := batches[len(batches)-1]
+		current.body.Write(payload)
+		current.operations = append(current.operations, index)
+	}
+
+	return batches
+}
+
+func failAll(batches []*batch, results []meniscus.Result, err error) []meniscus.Result {
+	for _, b := range batches {
+		for _, index := range b.operations {
+			results[index] = meniscus.Result{Index: index, Err: err}
+		}
+	}
+
+	return results
+}
+
+func readItems(result meniscus.Result, split func([]byte) ([]json.RawMessage, error)) ([]json.RawMessage, error) {
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	body, err := ioutil.ReadAll(result.Response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading response body: %s", err)
+	}
+
+	if result.Response.StatusCode < 200 || result.Response.StatusCode > 299 {
+		if err := itemError(body); err != nil {
+			return nil, err
+		}
+		return nil, &ItemError{Status: result.Response.StatusCode, Reason: strings.TrimSpace(string(body))}
+	}
+
+	return split(body)
+}
+
+func splitSearches(body []byte) ([]json.RawMessage, error) {
+	var response struct {
+		Responses []json.RawMessage `json:"responses"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error while decoding _msearch response: %s", err)
+	}
+
+	return response.Responses, nil
+}
+
+// splitBulkItems returns the items of a _bulk response without the action they are keyed by
+func splitBulkItems(body []byte) ([]json.RawMessage, error) {
+	var response struct {
+		Items []map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error while decoding _bulk response: %s", err)
+	}
+
+	items := make([]json.RawMessage, len(response.Items))
+	for i, item := range response.Items {
+		for _, value := range item {
+			items[i] = value
+		}
+	}
+
+	return items, nil
+}
+
+// itemError returns the error reported in item, whose error is an object on recent versions and a string on older ones
+func itemError(item json.RawMessage) error {
+	var reported struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(item, &reported); err != nil || len(reported.Error) == 0 || string(reported.Error) == "null" {
+		return nil
+	}
+
+	itemErr := &ItemError{Status: reported.Status}
+	var cause struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(reported.Error, &cause); err == nil {
+		itemErr.Type, itemErr.Reason = cause.Type, cause.Reason
+	} else {
+		json.Unmarshal(reported.Error, &itemErr.Reason)
+	}
+
+	return itemErr
+}
+
+// ndjson encodes every line as JSON followed by a newline
+func ndjson(lines ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		encoded, err := json.Marshal(line)
+		if err != nil {
+			return nil, fmt.Errorf("error while encoding operation: %s", err)
+		}
+
+		buf.Write(encoded)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
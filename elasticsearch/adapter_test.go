@@ -0,0 +1,122 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startCluster answers _msearch with one hit per search naming its query term
+// and _bulk with a version conflict for the document with id "taken"
+func startCluster(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(requests, 1)
+		assert.Equal(t, "application/x-ndjson", req.Header.Get("Content-Type"))
+
+		var lines []map[string]interface{}
+		scanner := bufio.NewScanner(req.Body)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line), "no errors")
+			lines = append(lines, line)
+		}
+
+		var items []string
+		switch req.URL.Path {
+		case "/_msearch":
+			for i := 0; i < len(lines); i += 2 {
+				if lines[i]["index"] == "missing" {
+					items = append(items, `{"error":{"type":"index_not_found_exception","reason":"no such index [missing]"},"status":404}`)
+					continue
+				}
+				items = append(items, fmt.Sprintf(`{"hits":{"hits":[{"_id":"%s"}]},"status":200}`, lines[i+1]["q"]))
+			}
+			fmt.Fprintf(w, `{"took":1,"responses":[%s]}`, strings.Join(items, ","))
+		case "/_bulk":
+			for i := 0; i < len(lines); i++ {
+				for action, meta := range lines[i] {
+					id := meta.(map[string]interface{})["_id"]
+					if action != "delete" {
+						i++
+					}
+					if id == "taken" {
+						items = append(items, fmt.Sprintf(`{"%s":{"_id":"taken","status":409,"error":{"type":"version_conflict_engine_exception","reason":"document already exists"}}}`, action))
+						continue
+					}
+					items = append(items, fmt.Sprintf(`{"%s":{"_id":"%s","status":201}}`, action, id))
+				}
+			}
+			fmt.Fprintf(w, `{"took":1,"errors":true,"items":[%s]}`, strings.Join(items, ","))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"type":"illegal_argument_exception","reason":"unknown endpoint"},"status":400}`))
+		}
+	}))
+}
+
+func newAdapter(url string) *Adapter {
+	client := meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second)
+	return &Adapter{Client: client, URL: url, BatchSize: 2}
+}
+
+func TestMultiSearchSplitsResponsesBackToEverySearch(t *testing.T) {
+	var requests int32
+	server := startCluster(t, &requests)
+	defer server.Close()
+
+	results := newAdapter(server.URL).MultiSearch([]Search{
+		{Index: "drivers", Query: map[string]string{"q": "first"}},
+		{Index: "missing", Query: map[string]string{"q": "second"}},
+		{Query: map[string]string{"q": "third"}},
+	})
+
+	require.Len(t, results, 3)
+	assert.Equal(t, int32(2), requests)
+	assert.NoError(t, results[0].Err)
+	assert.JSONEq(t, `{"hits":{"hits":[{"_id":"first"}]},"status":200}`, string(results[0].Value.(json.RawMessage)))
+	assert.Equal(t, &ItemError{Status: 404, Type: "index_not_found_exception", Reason: "no such index [missing]"}, results[1].Err)
+	assert.NoError(t, results[2].Err)
+	assert.JSONEq(t, `{"hits":{"hits":[{"_id":"third"}]},"status":200}`, string(results[2].Value.(json.RawMessage)))
+	assert.Equal(t, []int{0, 1, 2}, []int{results[0].Index, results[1].Index, results[2].Index})
+}
+
+func TestBulkReportsEveryOperationOnItsOwn(t *testing.T) {
+	var requests int32
+	server := startCluster(t, &requests)
+	defer server.Close()
+
+	results := newAdapter(server.URL).Bulk([]Operation{
+		{Index: "drivers", ID: "1", Document: map[string]string{"name": "first"}},
+		{Action: "create", Index: "drivers", ID: "taken", Document: map[string]string{"name": "second"}},
+		{Action: "delete", Index: "drivers", ID: "3"},
+		{Index: "drivers", ID: "4", Document: make(chan int)},
+	})
+
+	require.Len(t, results, 4)
+	assert.Equal(t, int32(2), requests)
+	assert.NoError(t, results[0].Err)
+	assert.JSONEq(t, `{"_id":"1","status":201}`, string(results[0].Value.(json.RawMessage)))
+	assert.Equal(t, &ItemError{Status: 409, Type: "version_conflict_engine_exception", Reason: "document already exists"}, results[1].Err)
+	assert.NoError(t, results[2].Err)
+	assert.JSONEq(t, `{"_id":"3","status":201}`, string(results[2].Value.(json.RawMessage)))
+	assert.Contains(t, results[3].Err.Error(), "error while encoding operation")
+}
+
+func TestRejectedBatchFailsAllItsOperations(t *testing.T) {
+	var requests int32
+	server := startCluster(t, &requests)
+	defer server.Close()
+
+	results := newAdapter(server.URL + "/unknown").Bulk([]Operation{{Index: "drivers", ID: "1", Document: map[string]string{}}})
+
+	assert.Equal(t, &ItemError{Status: 400, Type: "illegal_argument_exception", Reason: "unknown endpoint"}, results[0].Err)
+}
@@ -0,0 +1,128 @@
+package meniscus
+
+import "errors"
+
+//ErrorCode is a stable identifier of the kind of an error returned by meniscus, see Code.
+//Codes do not change with the wording of errors, alerting and retry decisions should rely on them.
+type ErrorCode string
+
+const (
+	//CodeTimeout is a request that ran into one of its StagedTimeouts or the timeout of the http.Client
+	CodeTimeout ErrorCode = "MENISCUS_TIMEOUT"
+	//CodeIgnored is a request left unanswered when its bulk timed out or was cancelled, or cancelled through its RequestHandle
+	CodeIgnored ErrorCode = "MENISCUS_IGNORED"
+	//CodeClientErr is a request that could not be built or sent, or got no response from the http client
+	CodeClientErr ErrorCode = "MENISCUS_CLIENT_ERR"
+	//CodeBodyReadErr is a request whose response body could not be read
+	CodeBodyReadErr ErrorCode = "MENISCUS_BODY_READ_ERR"
+	//CodeRejected is a request or a response refused by a policy of the client, e.g. its DestinationPolicy or certificate pins
+	CodeRejected ErrorCode = "MENISCUS_REJECTED"
+	//CodeSkipped is a request not sent as its condition did not hold, it was filtered out, already succeeded
+	//or its downstream was found unhealthy
+	CodeSkipped ErrorCode = "MENISCUS_SKIPPED"
+	//CodeBulkErr is a bulk that failed as a whole without firing any request
+	CodeBulkErr ErrorCode = "MENISCUS_BULK_ERR"
+	//CodeUnknown is an error that did not come from meniscus, e.g. one returned by a ResponseHandler
+	CodeUnknown ErrorCode = "MENISCUS_UNKNOWN"
+)
+
+// sentinelCodes are the codes of the errors meniscus returns as is or wrapped, in the order they are checked in,
+// so that an error wrapping several of them always gets the same code
+var sentinelCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrDestinationNotAllowed, CodeRejected},
+	{ErrTooManyRedirects, CodeRejected},
+	{ErrPinMismatch, CodeRejected},
+	{ErrInvalidSignature, CodeRejected},
+	{ErrDisallowedByRobots, CodeRejected},
+
+	{ErrBulkClosed, CodeBodyReadErr},
+
+	{ErrInjectedFault, CodeClientErr},
+	{ErrInteractionNotFound, CodeClientErr},
+	{ErrTenantQueueFull, CodeClientErr},
+	{ErrUnresolvedVariable, CodeClientErr},
+	{ErrBodyNotReplayable, CodeClientErr},
+	{ErrConnectToUnsupported, CodeClientErr},
+
+	{ErrRequestSkipped, CodeSkipped},
+	{ErrRequestFiltered, CodeSkipped},
+	{ErrAlreadySucceeded, CodeSkipped},
+	{ErrDownstreamUnhealthy, CodeSkipped},
+
+	{ErrNoRequests, CodeBulkErr},
+	{ErrAlreadyExecuting, CodeBulkErr},
+	{ErrConcurrencyBudgetExceeded, CodeBulkErr},
+	{ErrInvalidShard, CodeBulkErr},
+}
+
+// timeoutErrors are the errors of the staged timeouts
+var timeoutErrors = []error{ErrDialTimeout, ErrTLSHandshakeTimeout, ErrResponseHeaderTimeout, ErrRequestTimeout, ErrStalled}
+
+//Code returns the code of err, an empty code for a nil error.
+//Requests ignored at the deadline keep CodeIgnored when attributed a TimeoutError, see WithTimeoutAttribution.
+func Code(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, ErrRequestIgnored) || errors.Is(err, ErrRequestCancelled) {
+		return CodeIgnored
+	}
+
+	if timedOut(err) {
+		return CodeTimeout
+	}
+
+	for _, sentinel := range sentinelCodes {
+		if errors.Is(err, sentinel.err) {
+			return sentinel.code
+		}
+	}
+
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+
+	var misconfigured *ClientTimeoutError
+	if errors.As(err, &misconfigured) {
+		return CodeBulkErr
+	}
+
+	return CodeUnknown
+}
+
+// timedOut tells whether err comes from a staged timeout, or from a timeout of the http.Client or the network
+func timedOut(err error) bool {
+	for _, timeout := range timeoutErrors {
+		if errors.Is(err, timeout) {
+			return true
+		}
+	}
+
+	var attributed *TimeoutError
+	if errors.As(err, &attributed) {
+		return true
+	}
+
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// codedError is an error formatted by the client, carrying its code and the error it was caused by
+type codedError struct {
+	code  ErrorCode
+	msg   string
+	cause error
+}
+
+func (e *codedError) Error() string {
+	return e.msg
+}
+
+func (e *codedError) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,97 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCodeTellsTheErrorsOfABulkApart(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/reset":
+			return nil, errors.New("connection reset")
+		case "/slow":
+			return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: ErrResponseHeaderTimeout}
+		case "/denied":
+			return nil, ErrDestinationNotAllowed
+		}
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithName("pricing-fanout"))
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/ok", nil).
+		AddGet("http://example.com/reset", nil).
+		AddGet("http://example.com/slow", nil).
+		AddGet("http://example.com/denied", nil).
+		AddRequestIf(mustRequest(t, "http://example.com/skipped"), func() bool { return false })
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	var codes []ErrorCode
+	for _, err := range errs {
+		codes = append(codes, Code(err))
+	}
+	assert.Equal(t, []ErrorCode{"", CodeClientErr, CodeTimeout, CodeRejected, CodeSkipped}, codes)
+	assert.EqualError(t, errs[1], "pricing-fanout: http client error: connection reset")
+	assert.True(t, errors.Is(errs[2], ErrResponseHeaderTimeout))
+}
+
+func TestCodeOfResponseBodiesThatCannotBeRead(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(errReader{})
+		return resp, nil
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/truncated", nil)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, CodeBodyReadErr, Code(errs[0]))
+	assert.EqualError(t, errs[0], "error while reading response body: unexpected EOF")
+}
+
+// errReader fails like a connection dropped in the middle of a response
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("unexpected EOF")
+}
+
+func TestCodeOfErrorsReturnedAsIs(t *testing.T) {
+	assert.Equal(t, ErrorCode(""), Code(nil))
+	assert.Equal(t, CodeIgnored, Code(ErrRequestIgnored))
+	assert.Equal(t, CodeIgnored, Code(ErrRequestCancelled))
+	assert.Equal(t, CodeIgnored, Code(&TimeoutError{Source: BulkDeadline, Err: ErrRequestIgnored}))
+	assert.Equal(t, CodeTimeout, Code(&TimeoutError{Source: ClientTimeout, Err: errors.New("Client.Timeout exceeded")}))
+	assert.Equal(t, CodeTimeout, Code(&url.Error{Op: "Get", URL: "http://example.com", Err: context.DeadlineExceeded}))
+	assert.Equal(t, CodeBulkErr, Code(ErrNoRequests))
+	assert.Equal(t, CodeBulkErr, Code(ErrAlreadyExecuting))
+	assert.Equal(t, CodeBulkErr, Code(&ClientTimeoutError{}))
+	assert.Equal(t, CodeRejected, Code(ErrInvalidSignature))
+	assert.Equal(t, CodeSkipped, Code(ErrRequestFiltered))
+	assert.Equal(t, CodeUnknown, Code(errors.New("handler failed")))
+}
+
+// bothErrors wraps two errors at once, as a middleware failing while cleaning up after another error might
+type bothErrors [2]error
+
+func (e bothErrors) Error() string {
+	return e[0].Error() + ": " + e[1].Error()
+}
+
+func (e bothErrors) Is(target error) bool {
+	return e[0] == target || e[1] == target
+}
+
+func TestCodeOfErrorsWrappingSeveralSentinelsFollowsAFixedPriority(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, CodeRejected, Code(bothErrors{ErrTenantQueueFull, ErrPinMismatch}))
+		assert.Equal(t, CodeClientErr, Code(bothErrors{ErrAlreadyExecuting, ErrTenantQueueFull}))
+	}
+}
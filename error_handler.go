@@ -0,0 +1,23 @@
+package meniscus
+
+//ErrorHandler is the fallback policy for the requests of a bulk that failed, e.g. logging them,
+//counting them or pushing them to a dead letter queue
+type ErrorHandler func(Result)
+
+//WithErrorHandler hands every request that failed in a bulk to handle, in the order of the requests,
+//before Do returns. Requests skipped by their condition, filtered out or already succeeded did not fail and are not handed to it,
+//nor are bulks failing as a whole, e.g. with ErrNoRequests, whose error is only returned by Do.
+func WithErrorHandler(handle ErrorHandler) ClientOption {
+	return func(cl *BulkClient) {
+		cl.errorHandler = handle
+	}
+}
+
+// handleErrors hands the failed requests of the last Do of bulkRequest to the error handler
+func (cl *BulkClient) handleErrors(bulkRequest *RoundTrip) {
+	for _, result := range bulkRequest.Results() {
+		if result.Err != nil && Code(result.Err) != CodeSkipped {
+			cl.errorHandler(result)
+		}
+	}
+}
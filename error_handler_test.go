@@ -0,0 +1,49 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestErrorHandlerReceivesEveryFailedRequest(t *testing.T) {
+	var failed []Result
+	client := NewBulkHTTPClient(flakyClient("/a", "/c"), NonFailingTimeoutValue,
+		WithErrorHandler(func(result Result) { failed = append(failed, result) }))
+
+	bulkRequest := NewBulkRequest(nil).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/b", nil).
+		AddTaggedRequest(mustRequest(t, "http://example.com/c"), Tags{"queue": "dlq"}).
+		AddRequestIf(mustRequest(t, "http://example.com/d"), func() bool { return false }).
+		AddGet("://invalid", nil)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, ErrRequestSkipped, errs[3])
+	if assert.Len(t, failed, 3) {
+		assert.Equal(t, []int{0, 2, 4}, []int{failed[0].Index, failed[1].Index, failed[2].Index})
+		assert.EqualError(t, failed[0].Err, "http client error: connection reset")
+		assert.Equal(t, Tags{"queue": "dlq"}, failed[1].Tags)
+		assert.Equal(t, errs[4], failed[2].Err)
+	}
+
+	failed = nil
+	client.Do(NewBulkRequest(nil))
+	assert.Empty(t, failed, "bulks failing as a whole are not handed over")
+}
+
+func TestErrorHandlerReceivesTheFailuresOfEveryPhase(t *testing.T) {
+	var failed []int
+	client := NewBulkHTTPClient(flakyClient("/first", "/second"), NonFailingTimeoutValue,
+		WithErrorHandler(func(result Result) { failed = append(failed, result.Index) }))
+
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/first", nil).
+		Then(func([]Result) []*http.Request {
+			return []*http.Request{mustRequest(t, "http://example.com/ok"), mustRequest(t, "http://example.com/second")}
+		})
+	client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []int{0, 2}, failed)
+}
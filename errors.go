@@ -7,3 +7,78 @@ var ErrNoRequests = errors.New("no requests provided")
 
 //ErrRequestIgnored ...
 var ErrRequestIgnored = errors.New("request ignored")
+
+//ErrInjectedFault is the default error returned by ChaosMiddleware
+var ErrInjectedFault = errors.New("injected fault")
+
+//ErrInteractionNotFound is returned by ReplayClient for requests missing from the cassette
+var ErrInteractionNotFound = errors.New("no recorded interaction found for request")
+
+//ErrDialTimeout is returned when establishing the connection exceeds StagedTimeouts.Dial
+var ErrDialTimeout = errors.New("dial timeout exceeded")
+
+//ErrTLSHandshakeTimeout is returned when the TLS handshake exceeds StagedTimeouts.TLSHandshake
+var ErrTLSHandshakeTimeout = errors.New("tls handshake timeout exceeded")
+
+//ErrResponseHeaderTimeout is returned when waiting for the response headers exceeds StagedTimeouts.ResponseHeader
+var ErrResponseHeaderTimeout = errors.New("response header timeout exceeded")
+
+//ErrRequestTimeout is returned when a request, including reading its body, exceeds StagedTimeouts.Total
+var ErrRequestTimeout = errors.New("request timeout exceeded")
+
+//ErrConcurrencyBudgetExceeded is returned when running a bulk would exceed the client goroutine budget
+var ErrConcurrencyBudgetExceeded = errors.New("goroutine budget exceeded")
+
+//ErrTenantQueueFull is returned when a tenant already has its maximum number of requests waiting for a SharedPool slot
+var ErrTenantQueueFull = errors.New("tenant queue is full")
+
+//ErrRequestCancelled is returned for a request aborted through its RequestHandle
+var ErrRequestCancelled = errors.New("request cancelled")
+
+//ErrDestinationNotAllowed is returned for a request whose destination breaks the client DestinationPolicy
+var ErrDestinationNotAllowed = errors.New("destination not allowed")
+
+//ErrTooManyRedirects is returned for a request redirected more times than the DestinationPolicy allows
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+//ErrPinMismatch is returned for a request to a pinned host whose certificate matches none of its pins
+var ErrPinMismatch = errors.New("certificate does not match any pin")
+
+//ErrInvalidSignature is returned by response verifiers for a response whose signature does not match its body
+var ErrInvalidSignature = errors.New("invalid response signature")
+
+//ErrDisallowedByRobots is returned for a crawled page its host robots.txt does not allow fetching
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+//ErrRequestSkipped is returned for a request added with AddRequestIf whose condition did not hold
+var ErrRequestSkipped = errors.New("request skipped")
+
+//ErrStalled is returned when no byte of a response body is received for longer than StagedTimeouts.Stall
+var ErrStalled = errors.New("response stalled")
+
+//ErrUnresolvedVariable is returned for a request using a variable that was not set when it was dispatched
+var ErrUnresolvedVariable = errors.New("unresolved variable")
+
+//ErrBodyNotReplayable is returned for a request retried by RoundTrip.RetryFailed whose body cannot be rebuilt
+var ErrBodyNotReplayable = errors.New("request body cannot be replayed")
+
+//ErrRequestFiltered is returned for a request dropped from its bulk by RoundTrip.Filter
+var ErrRequestFiltered = errors.New("request filtered")
+
+//ErrConnectToUnsupported is returned for a request to be connected to another address by a client that cannot be routed
+var ErrConnectToUnsupported = errors.New("connecting to another address is not supported by the http client")
+
+//ErrAlreadyExecuting is returned by Do for a bulk that is already being run by another call to Do
+var ErrAlreadyExecuting = errors.New("bulk already executing")
+
+//ErrBulkClosed is returned reading an unbuffered response body once its bulk was cancelled, timed out or had its responses closed
+var ErrBulkClosed = errors.New("bulk closed")
+
+//ErrAlreadySucceeded is returned for a request left out by RoundTrip.SkipSucceeded as it succeeded in a prior run
+var ErrAlreadySucceeded = errors.New("request already succeeded")
+
+//ErrDownstreamUnhealthy is returned for a request of a chunk not sent by a Chunker as its HealthProbe kept failing
+var ErrDownstreamUnhealthy = errors.New("downstream unhealthy")
+
+//ErrInvalidShard is returned by RoundTrip.Shard for a shard out of range or a shard count that is not positive
+var ErrInvalidShard = errors.New("invalid shard")
@@ -0,0 +1,63 @@
+package meniscus
+
+import (
+	"net/http"
+	"time"
+)
+
+// expectContinue sends the headers of requests with bodies larger than threshold alone first,
+// waiting up to wait for the server to accept them before sending the body
+type expectContinue struct {
+	threshold int64
+	wait      time.Duration
+}
+
+func (e expectContinue) enabled() bool {
+	return e.wait > 0
+}
+
+// prepare returns a copy of req expecting 100-continue when its body is large or of unknown length
+func (e expectContinue) prepare(req *http.Request) *http.Request {
+	if req.Body == nil || req.Body == http.NoBody || (req.ContentLength > 0 && req.ContentLength <= e.threshold) {
+		return req
+	}
+
+	expecting := *req
+	expecting.Header = cloneHeader(req.Header)
+	expecting.Header.Set("Expect", "100-continue")
+	return &expecting
+}
+
+// client returns a copy of client waiting for 100-continue. Only an *http.Client using an *http.Transport
+// can be configured, other clients are returned as they are and handle the Expect header themselves.
+func (e expectContinue) client(client HTTPClient) HTTPClient {
+	httpClient, ok := client.(*http.Client)
+	if !ok {
+		return client
+	}
+
+	roundTripper := httpClient.Transport
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+
+	transport, ok := roundTripper.(*http.Transport)
+	if !ok {
+		return client
+	}
+
+	waiting := *httpClient
+	waitingTransport := transport.Clone()
+	waitingTransport.ExpectContinueTimeout = e.wait
+	waiting.Transport = waitingTransport
+	return &waiting
+}
+
+func cloneHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for key, values := range header {
+		clone[key] = append([]string(nil), values...)
+	}
+
+	return clone
+}
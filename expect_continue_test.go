@@ -0,0 +1,50 @@
+package meniscus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingReader struct {
+	reader *bytes.Reader
+	read   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	atomic.AddInt64(&r.read, int64(n))
+	return n, err
+}
+
+func TestLargeBodiesAreNotSentToServersRejectingTheHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(req.Header.Get("Expect")))
+	}))
+	defer server.Close()
+
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue,
+		WithExpectContinue(1024, time.Second))
+
+	large := &countingReader{reader: bytes.NewReader(make([]byte, 1<<20))}
+	rejected, _ := http.NewRequest(http.MethodPost, server.URL, large)
+	small, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte("small")))
+	small.Header.Set("Authorization", "token")
+	bulkRequest := NewBulkRequest([]*http.Request{rejected, small}, 2, 2)
+
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, http.StatusUnauthorized, responses[0].StatusCode)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&large.read))
+	assert.Equal(t, "", rejected.Header.Get("Expect"))
+}
@@ -0,0 +1,19 @@
+package meniscus
+
+import (
+	"github.com/gojektech/meniscus/internal/fakeclock"
+	"time"
+)
+
+// FakeClock is the fake clock of meniscustest, which the tests of this package cannot import
+type FakeClock struct {
+	*fakeclock.Clock
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{Clock: fakeclock.New(now)}
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return c.Clock.NewTimer(d)
+}
@@ -0,0 +1,76 @@
+package meniscus
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+)
+
+// forceCloseMiddleware closes the connection of any request still in flight when its context is done,
+// so that a connection left half read by a cancelled request is never handed back to the idle pool.
+// With HTTP/2 this closes every stream multiplexed on the same connection.
+func forceCloseMiddleware() Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			killSwitch := &connKillSwitch{finished: make(chan struct{})}
+			ctx := httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+				GotConn: func(info httptrace.GotConnInfo) {
+					killSwitch.track(info.Conn)
+				},
+			})
+
+			go func() {
+				select {
+				case <-ctx.Done():
+					killSwitch.kill()
+				case <-killSwitch.finished:
+				}
+			}()
+
+			resp, err := next.Do(req.WithContext(ctx))
+			if err != nil {
+				if ctx.Err() != nil {
+					killSwitch.kill()
+				}
+				killSwitch.finish()
+				return nil, err
+			}
+
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: killSwitch.finish}
+			return resp, nil
+		})
+	}
+}
+
+type connKillSwitch struct {
+	mu       sync.Mutex
+	conn     net.Conn
+	done     bool
+	finished chan struct{}
+}
+
+func (k *connKillSwitch) track(conn net.Conn) {
+	k.mu.Lock()
+	k.conn = conn
+	k.mu.Unlock()
+}
+
+func (k *connKillSwitch) kill() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !k.done && k.conn != nil {
+		k.conn.Close()
+	}
+}
+
+func (k *connKillSwitch) finish() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !k.done {
+		k.done = true
+		close(k.finished)
+	}
+}
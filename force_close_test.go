@@ -0,0 +1,55 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForceCloseOnTimeoutClosesConnectionsOfStalledRequests(t *testing.T) {
+	release := make(chan struct{})
+	var closed int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-req.Context().Done():
+			atomic.AddInt32(&closed, 1)
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, FailingTimeoutValue, WithForceCloseOnTimeout())
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, ErrRequestIgnored, errs[0])
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&closed) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestForceCloseOnTimeoutKeepsConnectionsOfCompletedRequests(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithForceCloseOnTimeout())
+
+	for i := 0; i < 2; i++ {
+		bulkRequest := newBulkClientWithNRequests(1, server.URL)
+		_, errs := client.Do(bulkRequest)
+		bulkRequest.CloseAllResponses()
+		assert.Equal(t, []error{nil}, errs)
+	}
+}
@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"strings"
+)
+
+//ErrDetailNotFound is returned by Status.Detail when the status carries no detail of the requested type
+var ErrDetailNotFound = errors.New("gateway: status has no detail of the requested type")
+
+//Status is the error body of a gRPC-gateway response, a JSON encoded google.rpc.Status
+type Status struct {
+	HTTPStatus int               `json:"-"`
+	Code       int32             `json:"code"`
+	Message    string            `json:"message"`
+	Details    []json.RawMessage `json:"details,omitempty"` // google.protobuf.Any encoded as JSON
+}
+
+func (s *Status) Error() string {
+	return fmt.Sprintf("gateway: http status %d, rpc code %d: %s", s.HTTPStatus, s.Code, s.Message)
+}
+
+//Detail decodes into msg the first detail of the status with the type of msg, e.g. an errdetails.BadRequest
+func (s *Status) Detail(msg proto.Message) error {
+	name := string(msg.ProtoReflect().Descriptor().FullName())
+	for _, raw := range s.Details {
+		var header struct {
+			Type string `json:"@type"`
+		}
+		if err := json.Unmarshal(raw, &header); err != nil || !strings.HasSuffix(header.Type, "/"+name) {
+			continue
+		}
+
+		detail := &anypb.Any{}
+		if err := unmarshaler.Unmarshal(raw, detail); err != nil {
+			return fmt.Errorf("error while decoding status detail: %s", err)
+		}
+
+		return detail.UnmarshalTo(msg)
+	}
+
+	return ErrDetailNotFound
+}
+
+// parseStatus builds the Status of an error response, falling back to the raw body when it is not a gateway error
+func parseStatus(httpStatus int, body []byte) *Status {
+	status := &Status{}
+	if err := json.Unmarshal(body, status); err != nil || (status.Code == 0 && status.Message == "") {
+		status = &Status{Message: strings.TrimSpace(string(body))}
+	}
+
+	status.HTTPStatus = httpStatus
+	return status
+}
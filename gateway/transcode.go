@@ -0,0 +1,83 @@
+// Package gateway helps bulk-calling gRPC-gateway style endpoints, which transcode protobuf messages to and from JSON
+package gateway
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"io/ioutil"
+	"net/http"
+)
+
+// marshaler emits proto field names, which every gateway accepts whatever its json_name settings
+var marshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// unmarshaler tolerates fields added to the server's messages after the client was built
+var unmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+//NewRequest builds a request for url with msg encoded as protobuf JSON, msg may be nil for requests without a body
+func NewRequest(method, url string, msg proto.Message) (*http.Request, error) {
+	var body []byte
+	if msg != nil {
+		encoded, err := marshaler.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("error while encoding request message: %s", err)
+		}
+		body = encoded
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if msg != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+//Decoder returns a post processor decoding every successful response into a message built by newMessage,
+//which becomes the Value of its result. Responses with an error status fail with the *Status carried by their body.
+func Decoder(newMessage func() proto.Message) meniscus.PostProcessor {
+	return func(result meniscus.Result) meniscus.Result {
+		if result.Err != nil || result.Response == nil {
+			return result
+		}
+
+		msg := newMessage()
+		if err := Decode(result.Response, msg); err != nil {
+			result.Err = err
+			return result
+		}
+
+		result.Value = msg
+		return result
+	}
+}
+
+//Decode reads the body of res into msg, or returns the *Status it carries when res has an error status
+func Decode(res *http.Response, msg proto.Message) error {
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("error while reading response body: %s", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return parseStatus(res.StatusCode, body)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	if err := unmarshaler.Unmarshal(body, msg); err != nil {
+		return fmt.Errorf("error while decoding response message: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewRequestEncodesTheMessageAsProtobufJSON(t *testing.T) {
+	req, err := NewRequest(http.MethodPost, "http://example.com/v1/drivers", wrapperspb.String("driver-1"))
+	require.NoError(t, err, "no errors")
+
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, `"driver-1"`, string(body))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "application/json", req.Header.Get("Accept"))
+}
+
+func TestDecoderDecodesResponsesAndExtractsStatusDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/drivers/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":5,"message":"driver not found","details":[{"@type":"type.googleapis.com/google.protobuf.StringValue","value":"missing"}]}`))
+			return
+		}
+
+		w.Write([]byte(`{"name":"driver-1","rating":4.5,"added_later":true}`))
+	}))
+	defer server.Close()
+
+	client := meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second,
+		meniscus.WithPostProcessor(Decoder(func() proto.Message { return &structpb.Struct{} }), 2))
+	found, err := NewRequest(http.MethodGet, server.URL+"/v1/drivers/1", nil)
+	require.NoError(t, err, "no errors")
+	missing, err := NewRequest(http.MethodGet, server.URL+"/v1/drivers/missing", nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := meniscus.NewBulkRequest([]*http.Request{found, missing}, 2, 2)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	require.NoError(t, errs[0], "no errors")
+	driver := bulkRequest.Values()[0].(*structpb.Struct)
+	assert.Equal(t, "driver-1", driver.Fields["name"].GetStringValue())
+	assert.Equal(t, 4.5, driver.Fields["rating"].GetNumberValue())
+
+	status, ok := errs[1].(*Status)
+	require.True(t, ok, "error is a gateway status")
+	assert.Equal(t, http.StatusNotFound, status.HTTPStatus)
+	assert.Equal(t, int32(5), status.Code)
+	assert.Equal(t, "driver not found", status.Message)
+
+	detail := &wrapperspb.StringValue{}
+	require.NoError(t, status.Detail(detail), "no errors")
+	assert.Equal(t, "missing", detail.Value)
+	assert.Equal(t, ErrDetailNotFound, status.Detail(&wrapperspb.Int64Value{}))
+}
+
+func TestParseStatusFallsBackToTheRawBody(t *testing.T) {
+	status := parseStatus(http.StatusBadGateway, []byte("upstream unavailable\n"))
+
+	assert.Equal(t, &Status{HTTPStatus: http.StatusBadGateway, Message: "upstream unavailable"}, status)
+}
@@ -0,0 +1,45 @@
+package meniscus
+
+import "sync/atomic"
+
+// goroutineBudget counts the pipeline goroutines of all bulks running on a client, optionally capping them
+type goroutineBudget struct {
+	inUse int64
+	limit int64
+}
+
+func (b *goroutineBudget) acquire(n int) bool {
+	for {
+		inUse := atomic.LoadInt64(&b.inUse)
+		if b.limit > 0 && inUse+int64(n) > b.limit {
+			return false
+		}
+
+		if atomic.CompareAndSwapInt64(&b.inUse, inUse, inUse+int64(n)) {
+			return true
+		}
+	}
+}
+
+func (b *goroutineBudget) release(n int) {
+	atomic.AddInt64(&b.inUse, -int64(n))
+}
+
+func (b *goroutineBudget) current() int {
+	return int(atomic.LoadInt64(&b.inUse))
+}
+
+// workerGoroutines is the number of goroutines started by workerManager for bulkRequest, including itself
+func (cl *BulkClient) workerGoroutines(bulkRequest *RoundTrip) int {
+	n := 2 + bulkRequest.fireWorkers() + bulkRequest.processWorkers()
+	if cl.postProcessor != nil {
+		n += cl.postProcessWorkers
+	}
+
+	return n
+}
+
+//GoroutinesInUse returns the number of pipeline goroutines currently running for bulks on this client
+func (cl *BulkClient) GoroutinesInUse() int {
+	return cl.goroutines.current()
+}
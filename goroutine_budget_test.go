@@ -0,0 +1,41 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGoroutineBudgetRejectsBulksThatDoNotFit(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithGoroutineBudget(10))
+
+	tooWide := newBulkClientWithNRequests(2, server.URL)
+	responses, errs := client.Do(tooWide)
+	assert.Nil(t, responses)
+	assert.Equal(t, []error{ErrConcurrencyBudgetExceeded}, errs)
+
+	narrow := NewBulkRequest(newBulkClientWithNRequests(2, server.URL).requests, 2, 2)
+	_, errs = client.Do(narrow)
+	defer narrow.CloseAllResponses()
+	assert.Equal(t, []error{nil, nil}, errs)
+}
+
+func TestGoroutinesInUseIsReleasedAfterDo(t *testing.T) {
+	inFlight := make(chan int, 1)
+	var client *BulkClient
+	client = NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		inFlight <- client.GoroutinesInUse()
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(newBulkClientWithNRequests(1, "http://example.com").requests, 1, 1)
+	client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, 5, <-inFlight)
+	assert.Eventually(t, func() bool { return client.GoroutinesInUse() == 0 }, time.Second, time.Millisecond)
+}
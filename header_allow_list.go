@@ -0,0 +1,43 @@
+package meniscus
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxDrain is how much of a dropped body is read so its connection can be reused,
+// larger bodies are left unread and their connection closed
+const maxDrain = 4 << 10
+
+// headerAllowList drops response bodies and keeps only the listed headers
+type headerAllowList struct {
+	enabled bool
+	headers []string
+}
+
+// strip returns a copy of res without its body and with only the allowed headers, draining the original body
+func (l headerAllowList) strip(res *http.Response, req *http.Request) *http.Response {
+	drain(res.Body)
+
+	header := http.Header{}
+	for _, key := range l.headers {
+		if values, ok := res.Header[key]; ok {
+			header[key] = append([]string(nil), values...)
+		}
+	}
+
+	return &http.Response{
+		Body:       memoryBody{bytes.NewReader(nil)},
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     header,
+		Request:    req,
+	}
+}
+
+// drain reads a few bytes of body so that a short response frees its connection for reuse once closed
+func drain(body io.Reader) {
+	io.CopyN(ioutil.Discard, body, maxDrain)
+}
@@ -0,0 +1,37 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHeaderAllowListDropsBodiesAndOtherHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Request-Id", "42")
+		w.Header().Set("X-Internal", "secret")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(strings.Repeat("a", 2*maxDrain)))
+	}))
+	defer server.Close()
+
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue,
+		WithUnbufferedResponses(), WithHeaderAllowList("x-request-id"))
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	require.NoError(t, errs[0], "no errors")
+	assert.Equal(t, http.StatusAccepted, responses[0].StatusCode)
+	assert.Equal(t, http.Header{"X-Request-Id": {"42"}}, responses[0].Header)
+	body, err := ioutil.ReadAll(responses[0].Body)
+	assert.NoError(t, err)
+	assert.Empty(t, body)
+}
@@ -0,0 +1,78 @@
+package meniscus
+
+import (
+	"net/http"
+	"time"
+)
+
+//HealthCheckOptions configures HealthCheckBatch, zero values get the defaults
+type HealthCheckOptions struct {
+	Client  HTTPClient     // defaults to an http.Client with Timeout as its timeout
+	Method  string         // defaults to HEAD
+	Timeout time.Duration  // for the whole batch, defaults to 2 seconds
+	Workers int            // defaults to one per endpoint, up to 20
+	Healthy func(int) bool // tells whether a status code is up, defaults to 2xx and 3xx
+	Options []ClientOption // applied to the BulkClient running the probes
+}
+
+//EndpointHealth is the outcome of probing one endpoint
+type EndpointHealth struct {
+	URL        string
+	Up         bool
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+}
+
+//HealthCheckBatch probes every url at once and reports which are up, in the same order as urls
+func HealthCheckBatch(urls []string, opts HealthCheckOptions) []EndpointHealth {
+	opts = opts.withDefaults(len(urls))
+	client := NewBulkHTTPClient(opts.Client, opts.Timeout, opts.Options...)
+
+	bulkRequest := NewBulkRequest(nil, opts.Workers, opts.Workers)
+	for _, url := range urls {
+		bulkRequest.addBuilt(opts.Method, url, nil, nil)
+	}
+
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	report := make([]EndpointHealth, len(urls))
+	for _, result := range bulkRequest.Results() {
+		health := EndpointHealth{URL: urls[result.Index], Latency: result.Latency, Err: result.Err}
+		if result.Response != nil {
+			health.StatusCode = result.Response.StatusCode
+			health.Up = opts.Healthy(health.StatusCode)
+		}
+		report[result.Index] = health
+	}
+
+	return report
+}
+
+func (opts HealthCheckOptions) withDefaults(endpoints int) HealthCheckOptions {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	if len(opts.Method) == 0 {
+		opts.Method = http.MethodHead
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = endpoints
+		if opts.Workers > 20 {
+			opts.Workers = 20
+		}
+	}
+
+	if opts.Healthy == nil {
+		opts.Healthy = func(status int) bool { return status >= 200 && status < 400 }
+	}
+
+	return opts
+}
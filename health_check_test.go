@@ -0,0 +1,38 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckBatchReportsEachEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/up":
+			w.WriteHeader(http.StatusNoContent)
+		case "/slow":
+			time.Sleep(MockServerSlowResponseSleep * 2)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	report := HealthCheckBatch([]string{server.URL + "/up", server.URL + "/down", server.URL + "/slow"},
+		HealthCheckOptions{Timeout: MockServerSlowResponseSleep})
+
+	assert.Equal(t, server.URL+"/up", report[0].URL)
+	assert.True(t, report[0].Up)
+	assert.Equal(t, http.StatusNoContent, report[0].StatusCode)
+	assert.True(t, report[0].Latency > 0)
+
+	assert.False(t, report[1].Up)
+	assert.Equal(t, http.StatusServiceUnavailable, report[1].StatusCode)
+	assert.NoError(t, report[1].Err)
+
+	assert.False(t, report[2].Up)
+	assert.Error(t, report[2].Err)
+}
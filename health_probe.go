@@ -0,0 +1,62 @@
+package meniscus
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+//HealthProbe tells whether a downstream is healthy enough to be sent the next chunk of a Chunker, returning an error if not
+type HealthProbe func(ctx context.Context) error
+
+//HTTPProbe is a HealthProbe sending a GET to url through client, the downstream is healthy if it answers with a 2xx
+func HTTPProbe(client HTTPClient, url string) HealthProbe {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("health probe to %s got status %d", url, resp.StatusCode)
+		}
+
+		return nil
+	}
+}
+
+//WithHealthProbe has the Chunker run probe before each chunk. A failed probe pauses the Chunker for pause before
+//probing again, up to attempts probes in all, after which the chunks left are not sent and their requests fail
+//with ErrDownstreamUnhealthy, protecting a struggling downstream during long backfills.
+func (c *Chunker) WithHealthProbe(probe HealthProbe, pause time.Duration, attempts int) *Chunker {
+	c.probe = probe
+	c.probePause = pause
+	c.probeAttempts = attempts
+	return c
+}
+
+// healthy probes the downstream until it is found healthy or the attempts are spent
+func (c *Chunker) healthy(ctx context.Context) bool {
+	if c.probe == nil {
+		return true
+	}
+
+	for attempt := 1; ; attempt++ {
+		if c.probe(ctx) == nil {
+			return true
+		}
+		if attempt >= c.probeAttempts || sleep(ctx, c.client.clock, c.probePause) != nil {
+			return false
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestChunkerAbortsTheChunksLeftOnceTheProbeKeepsFailing(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+	probes := 0
+	chunker := NewChunker(client, 2, 2, nil).WithHealthProbe(func(context.Context) error {
+		probes++
+		if probes > 1 {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}, time.Millisecond, 3)
+
+	results := chunker.Do(context.Background(), requestsTo(t, 5))
+
+	assert.Equal(t, 4, probes)
+	assert.Len(t, calls, 2)
+	for _, result := range results[:2] {
+		assert.NoError(t, result.Err)
+		result.Response.Body.Close()
+	}
+	for _, result := range results[2:] {
+		assert.Equal(t, ErrDownstreamUnhealthy, result.Err)
+	}
+}
+
+func TestChunkerPausesUntilTheDownstreamRecovers(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+	probes := 0
+	chunker := NewChunker(client, 2, 2, nil).WithHealthProbe(func(context.Context) error {
+		probes++
+		if probes == 2 {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}, 10*time.Millisecond, 2)
+
+	start := time.Now()
+	results := chunker.Do(context.Background(), requestsTo(t, 4))
+
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	assert.Equal(t, 3, probes)
+	assert.Len(t, calls, 4)
+	for _, result := range results {
+		assert.NoError(t, result.Err)
+		result.Response.Body.Close()
+	}
+}
+
+func TestHTTPProbeRequiresA2xx(t *testing.T) {
+	statuses := map[string]int{"/healthy": http.StatusOK, "/unhealthy": http.StatusServiceUnavailable}
+	client := HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, statuses[req.URL.Path]), nil
+	})
+
+	assert.NoError(t, HTTPProbe(client, "http://example.com/healthy")(context.Background()))
+	assert.EqualError(t, HTTPProbe(client, "http://example.com/unhealthy")(context.Background()),
+		"health probe to http://example.com/unhealthy got status 503")
+	assert.Equal(t, CodeSkipped, Code(ErrDownstreamUnhealthy))
+}
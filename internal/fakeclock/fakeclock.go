@@ -0,0 +1,114 @@
+// Package fakeclock implements the fake clock shared by the tests of meniscus and by meniscustest
+package fakeclock
+
+import (
+	"sync"
+	"time"
+)
+
+//Clock is a clock whose time only moves when Advance is called
+type Clock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	pending []*Timer
+}
+
+//New returns a Clock set to now
+func New(now time.Time) *Clock {
+	clock := &Clock{now: now}
+	clock.cond = sync.NewCond(&clock.mu)
+	return clock
+}
+
+//Now returns the current fake time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+//NewTimer returns a timer that fires once the fake time has been advanced by d
+func (c *Clock) NewTimer(d time.Duration) *Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &Timer{clock: c, deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+		return timer
+	}
+
+	c.pending = append(c.pending, timer)
+	c.cond.Broadcast()
+	return timer
+}
+
+//Advance moves the fake time forward by d and fires every timer that became due
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	var pending []*Timer
+	for _, timer := range c.pending {
+		if timer.deadline.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+
+		timer.c <- c.now
+	}
+
+	c.pending = pending
+	c.cond.Broadcast()
+}
+
+//BlockUntil waits until at least n timers are waiting on the clock
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.pending) < n {
+		c.cond.Wait()
+	}
+}
+
+//Pending returns the number of timers waiting on the clock
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+func (c *Clock) stop(timer *Timer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, t := range c.pending {
+		if t == timer {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			c.cond.Broadcast()
+			return true
+		}
+	}
+
+	return false
+}
+
+//Timer is a timer of a Clock
+type Timer struct {
+	clock    *Clock
+	deadline time.Time
+	c        chan time.Time
+}
+
+//C returns the channel the fake time is sent on once the timer fires
+func (t *Timer) C() <-chan time.Time {
+	return t.c
+}
+
+//Stop prevents the timer from firing, it returns false when the timer already fired or was stopped
+func (t *Timer) Stop() bool {
+	return t.clock.stop(t)
+}
@@ -0,0 +1,70 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+)
+
+//Interruption summarizes a bulk whose last Do was cut short, by its context being cancelled, Cancel or its timeout,
+//so that callers can tell the requests that are safe to send again from those that may have reached the server.
+//The requests are given by their index, as in Results.
+type Interruption struct {
+	Cause      error // context.Canceled or context.DeadlineExceeded
+	Completed  []int // answered, with a response or an error, before the bulk was cut short
+	InFlight   []int // picked up by a fire worker but left unanswered, they may have reached the server
+	NotStarted []int // never picked up by a fire worker
+}
+
+//DoWithContext runs bulkRequest like Do, as a child of ctx, see RoundTrip.SetContext.
+//When ctx is cancelled before the bulk completes, RoundTrip.Interruption tells which requests were sent.
+func (cl *BulkClient) DoWithContext(ctx context.Context, bulkRequest *RoundTrip) ([]*http.Response, []error) {
+	return cl.Do(bulkRequest.SetContext(ctx))
+}
+
+//Interruption returns the summary of the last Do of the bulk when it was cut short, nil when it ran to completion
+func (r *RoundTrip) Interruption() *Interruption {
+	return r.interruption
+}
+
+// interrupt summarizes the requests left unanswered by a bulk cut short with cause, given the states they reached
+func (r *RoundTrip) interrupt(cause error, states []ExecutionState) {
+	interruption := &Interruption{Cause: cause}
+	for index, err := range r.errors {
+		switch {
+		case err != ErrRequestIgnored:
+			interruption.Completed = append(interruption.Completed, r.origin(index))
+		case states[index] < StateFiring:
+			interruption.NotStarted = append(interruption.NotStarted, r.origin(index))
+		default:
+			interruption.InFlight = append(interruption.InFlight, r.origin(index))
+		}
+	}
+
+	if len(interruption.InFlight) != 0 || len(interruption.NotStarted) != 0 {
+		r.interruption = interruption
+	}
+}
+
+// appendInterruption makes the interruption of a phase following requests that all completed that of r
+func (r *RoundTrip) appendInterruption(phase *RoundTrip, offset int) {
+	if phase.interruption == nil {
+		return
+	}
+
+	interruption := &Interruption{Cause: phase.interruption.Cause}
+	for index := 0; index < offset; index++ {
+		interruption.Completed = append(interruption.Completed, r.origin(index))
+	}
+
+	shift := func(indices []int) []int {
+		var shifted []int
+		for _, index := range indices {
+			shifted = append(shifted, index+offset)
+		}
+		return shifted
+	}
+	interruption.Completed = append(interruption.Completed, shift(phase.interruption.Completed)...)
+	interruption.InFlight = shift(phase.interruption.InFlight)
+	interruption.NotStarted = shift(phase.interruption.NotStarted)
+	r.interruption = interruption
+}
@@ -0,0 +1,71 @@
+package meniscus
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// waitForResults waits until count results of bulkRequest were collected, or a second elapsed
+func waitForResults(bulkRequest *RoundTrip, count int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && len(bulkRequest.Results()) < count {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestInterruptionTellsTheRequestsInFlightFromThoseNeverStarted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var bulkRequest *RoundTrip
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/slow" && ctx.Err() == nil {
+			waitForResults(bulkRequest, 1)
+			cancel()
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return syntheticResponse(req, http.StatusOK), nil
+	}), time.Minute)
+
+	bulkRequest = NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/fast", nil).
+		AddGet("http://example.com/slow", nil).
+		AddGet("http://example.com/never", nil)
+	_, errs := client.DoWithContext(ctx, bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, ErrRequestIgnored, ErrRequestIgnored}, errs)
+	assert.Equal(t, &Interruption{Cause: context.Canceled, Completed: []int{0}, InFlight: []int{1}, NotStarted: []int{2}},
+		bulkRequest.Interruption())
+
+	_, errs = client.DoWithContext(context.Background(), bulkRequest)
+	assert.Equal(t, []error{nil, nil, nil}, errs)
+	assert.Nil(t, bulkRequest.Interruption(), "bulks running to completion are not interrupted")
+}
+
+func TestInterruptionOfALaterPhaseCountsTheEarlierRequestsAsCompleted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var bulkRequest *RoundTrip
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/slow" {
+			waitForResults(bulkRequest, 1)
+			cancel()
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return syntheticResponse(req, http.StatusOK), nil
+	}), time.Minute)
+
+	bulkRequest = NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/token", nil).
+		Then(func([]Result) []*http.Request {
+			return []*http.Request{mustRequest(t, "http://example.com/slow"), mustRequest(t, "http://example.com/never")}
+		})
+	client.DoWithContext(ctx, bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, &Interruption{Cause: context.Canceled, Completed: []int{0}, InFlight: []int{1}, NotStarted: []int{2}},
+		bulkRequest.Interruption())
+}
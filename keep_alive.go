@@ -0,0 +1,120 @@
+package meniscus
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//TunedTransport returns a copy of http.DefaultTransport that keeps enough idle connections per host
+//for bulks fired with the given worker counts, instead of the 2 kept by default, so that the connections
+//of one bulk are reused by the next rather than closed and dialed again.
+//Idle connections are closed after 90s, long enough to span bulks fired a few seconds apart.
+func TunedTransport(fireRequestsWorkers, processResponseWorkers int) *http.Transport {
+	// a connection goes back to the pool once its response body is read, so a bulk can hold one per worker of either kind
+	connections := fireRequestsWorkers + processResponseWorkers
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = connections
+	if transport.MaxIdleConns != 0 && transport.MaxIdleConns < connections {
+		transport.MaxIdleConns = connections
+	}
+	transport.IdleConnTimeout = 90 * time.Second
+	return transport
+}
+
+//TransportWarning reports a setting of the transport of a client that bottlenecks the fire workers of a bulk
+type TransportWarning struct {
+	BulkID  string
+	Setting string // the field of http.Transport, e.g. MaxIdleConnsPerHost
+	Value   int
+	Workers int
+}
+
+func (w TransportWarning) String() string {
+	return fmt.Sprintf("%s of %d bottlenecks %d fire workers", w.Setting, w.Value, w.Workers)
+}
+
+//CheckTransport returns the settings of the transport of client that bottleneck fireRequestsWorkers workers
+//sending requests to the same host. Only an *http.Client using an *http.Transport can be checked.
+func CheckTransport(client HTTPClient, fireRequestsWorkers int) []TransportWarning {
+	transport := transportOf(client)
+	if transport == nil {
+		return nil
+	}
+
+	var warnings []TransportWarning
+	warn := func(setting string, value int) {
+		warnings = append(warnings, TransportWarning{Setting: setting, Value: value, Workers: fireRequestsWorkers})
+	}
+
+	if transport.DisableKeepAlives {
+		warn("DisableKeepAlives", 1)
+		return warnings
+	}
+
+	if transport.MaxConnsPerHost > 0 && transport.MaxConnsPerHost < fireRequestsWorkers {
+		warn("MaxConnsPerHost", transport.MaxConnsPerHost)
+	}
+
+	idlePerHost := transport.MaxIdleConnsPerHost
+	if idlePerHost == 0 {
+		idlePerHost = http.DefaultMaxIdleConnsPerHost
+	}
+	if idlePerHost < fireRequestsWorkers {
+		warn("MaxIdleConnsPerHost", idlePerHost)
+	}
+
+	if transport.MaxIdleConns > 0 && transport.MaxIdleConns < fireRequestsWorkers {
+		warn("MaxIdleConns", transport.MaxIdleConns)
+	}
+
+	return warnings
+}
+
+//WithTransportCheck calls callback when the transport of the client bottlenecks the fire workers of a bulk,
+//see CheckTransport. It is called before the bulk is fired, once per setting and number of fire workers.
+func WithTransportCheck(callback func(TransportWarning)) ClientOption {
+	return func(cl *BulkClient) {
+		cl.transportCheck = &transportCheck{callback: callback, warned: map[TransportWarning]bool{}}
+	}
+}
+
+// transportCheck remembers the warnings already given for the transport of one client
+type transportCheck struct {
+	client   HTTPClient
+	callback func(TransportWarning)
+
+	mu     sync.Mutex
+	warned map[TransportWarning]bool // without their bulk ID
+}
+
+func (c *transportCheck) check(bulkRequest *RoundTrip) {
+	for _, warning := range CheckTransport(c.client, bulkRequest.fireWorkers()) {
+		c.mu.Lock()
+		warned := c.warned[warning]
+		c.warned[warning] = true
+		c.mu.Unlock()
+
+		if !warned {
+			warning.BulkID = bulkRequest.id
+			c.callback(warning)
+		}
+	}
+}
+
+// transportOf returns the transport client sends its requests with, nil when it is not an *http.Transport
+func transportOf(client HTTPClient) *http.Transport {
+	httpClient, ok := client.(*http.Client)
+	if !ok {
+		return nil
+	}
+
+	if httpClient.Transport == nil {
+		return http.DefaultTransport.(*http.Transport)
+	}
+
+	transport, _ := httpClient.Transport.(*http.Transport)
+	return transport
+}
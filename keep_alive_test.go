@@ -0,0 +1,52 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTunedTransportKeepsAConnectionPerWorker(t *testing.T) {
+	transport := TunedTransport(50, 20)
+
+	assert.Equal(t, 70, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+	assert.Empty(t, CheckTransport(&http.Client{Transport: transport}, 50))
+	assert.Equal(t, 160, TunedTransport(100, 60).MaxIdleConns)
+}
+
+func TestCheckTransportReportsBottlenecks(t *testing.T) {
+	assert.Equal(t, []TransportWarning{{Setting: "MaxIdleConnsPerHost", Value: 2, Workers: 10}},
+		CheckTransport(&http.Client{}, 10))
+	assert.Equal(t, []TransportWarning{
+		{Setting: "MaxConnsPerHost", Value: 4, Workers: 10},
+		{Setting: "MaxIdleConns", Value: 5, Workers: 10},
+	}, CheckTransport(&http.Client{Transport: &http.Transport{MaxConnsPerHost: 4, MaxIdleConnsPerHost: 10, MaxIdleConns: 5}}, 10))
+	assert.Equal(t, []TransportWarning{{Setting: "DisableKeepAlives", Value: 1, Workers: 10}},
+		CheckTransport(&http.Client{Transport: &http.Transport{DisableKeepAlives: true}}, 10))
+	assert.Empty(t, CheckTransport(&http.Client{}, 2))
+	assert.Empty(t, CheckTransport(countingClient(map[string]int{}), 10))
+}
+
+func TestTransportCheckWarnsOncePerBulkShape(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	var warnings []TransportWarning
+	client := NewBulkHTTPClient(&http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 4}}, NonFailingTimeoutValue,
+		WithTransportCheck(func(warning TransportWarning) { warnings = append(warnings, warning) }))
+
+	for _, workers := range []int{8, 8, 2, 16} {
+		_, errs := client.Do(NewBulkRequest(nil, workers, 1).SetID("bulk").AddGet(server.URL, nil))
+		assert.Equal(t, []error{nil}, errs)
+	}
+
+	assert.Equal(t, []TransportWarning{
+		{BulkID: "bulk", Setting: "MaxIdleConnsPerHost", Value: 4, Workers: 8},
+		{BulkID: "bulk", Setting: "MaxIdleConnsPerHost", Value: 4, Workers: 16},
+	}, warnings)
+	assert.Equal(t, "MaxIdleConnsPerHost of 4 bottlenecks 8 fire workers", warnings[0].String())
+}
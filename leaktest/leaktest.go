@@ -0,0 +1,113 @@
+// Package leaktest checks that code using meniscus gives back the goroutines, file descriptors and heap it takes,
+// e.g. in soak tests firing many bulks in a row:
+//
+//	assert.NoError(t, leaktest.Soak(100, leaktest.Limits{Goroutines: 10}, func(int) error { ... }))
+package leaktest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// defaultSettle is how long goroutines and connections get to wind down before a check fails
+const defaultSettle = time.Second
+
+//Snapshot is the resource usage of the process at one point in time
+type Snapshot struct {
+	Goroutines int
+	FDs        int    // open file descriptors, -1 where they cannot be counted
+	HeapAlloc  uint64 // bytes of live heap objects, after a garbage collection
+}
+
+//Take returns the current resource usage of the process
+func Take() Snapshot {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	return Snapshot{Goroutines: runtime.NumGoroutine(), FDs: openFDs(), HeapAlloc: stats.HeapAlloc}
+}
+
+// openFDs counts the file descriptors of the process, -1 on platforms without /proc
+func openFDs() int {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+
+	return len(fds)
+}
+
+//Limits is how much the resource usage may grow over a check, idle keep-alive connections
+//and the goroutines serving them usually account for some of it
+type Limits struct {
+	Goroutines int
+	FDs        int
+	HeapGrowth uint64        // in bytes, not checked when zero as the heap is rarely stable between snapshots
+	Settle     time.Duration // how long usage may take to come back within limits, defaults to one second
+}
+
+//Check fails if the resource usage grew past limits since before, once it had time to settle
+func Check(before Snapshot, limits Limits) error {
+	settle := limits.Settle
+	if settle <= 0 {
+		settle = defaultSettle
+	}
+
+	deadline := time.Now().Add(settle)
+	for {
+		after := Take()
+		leaks := compare(before, after, limits)
+		if len(leaks) == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("leaked %s", strings.Join(leaks, ", "))
+		}
+
+		time.Sleep(settle / 20)
+	}
+}
+
+//Soak runs iteration iterations times and fails with its first error, or if the resource usage grew past limits.
+//A first warm up run is left out of the check, so that pools and caches filled once do not count as leaks.
+func Soak(iterations int, limits Limits, iteration func(i int) error) error {
+	if err := iteration(0); err != nil {
+		return fmt.Errorf("warm up: %v", err)
+	}
+
+	before := Take()
+	for i := 1; i <= iterations; i++ {
+		if err := iteration(i); err != nil {
+			return fmt.Errorf("iteration %d: %v", i, err)
+		}
+	}
+
+	return Check(before, limits)
+}
+
+// compare describes the resources that grew past limits between before and after
+func compare(before, after Snapshot, limits Limits) []string {
+	var leaks []string
+	if grown := after.Goroutines - before.Goroutines; grown > limits.Goroutines {
+		leaks = append(leaks, fmt.Sprintf("%d goroutines (%d to %d)", grown, before.Goroutines, after.Goroutines))
+	}
+
+	if before.FDs >= 0 && after.FDs >= 0 {
+		if grown := after.FDs - before.FDs; grown > limits.FDs {
+			leaks = append(leaks, fmt.Sprintf("%d file descriptors (%d to %d)", grown, before.FDs, after.FDs))
+		}
+	}
+
+	if limits.HeapGrowth > 0 && after.HeapAlloc > before.HeapAlloc {
+		if grown := after.HeapAlloc - before.HeapAlloc; grown > limits.HeapGrowth {
+			leaks = append(leaks, fmt.Sprintf("%d bytes of heap (%d to %d)", grown, before.HeapAlloc, after.HeapAlloc))
+		}
+	}
+
+	return leaks
+}
@@ -0,0 +1,110 @@
+package leaktest
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckPassesOnceGoroutinesWindDown(t *testing.T) {
+	before := Take()
+	stop := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		go func() { <-stop }()
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(stop)
+	}()
+
+	assert.NoError(t, Check(before, Limits{}))
+}
+
+func TestCheckFailsOnLeakedGoroutines(t *testing.T) {
+	before := Take()
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < 5; i++ {
+		go func() { <-stop }()
+	}
+
+	err := Check(before, Limits{Goroutines: 2, Settle: 50 * time.Millisecond})
+
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "leaked 5 goroutines"), err.Error())
+	assert.NoError(t, Check(before, Limits{Goroutines: 5, Settle: 50 * time.Millisecond}))
+}
+
+func TestCheckFailsOnLeakedFileDescriptors(t *testing.T) {
+	before := Take()
+	if before.FDs < 0 {
+		t.Skip("file descriptors cannot be counted on this platform")
+	}
+
+	file, err := ioutil.TempFile("", "leaktest")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	err = Check(before, Limits{Settle: 50 * time.Millisecond})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "1 file descriptors")
+
+	file.Close()
+	assert.NoError(t, Check(before, Limits{}))
+}
+
+// retained keeps the allocations of a test alive, out of the reach of escape analysis
+var retained []byte
+
+func TestCheckFailsOnHeapGrowth(t *testing.T) {
+	before := Take()
+	retained = make([]byte, 8<<20)
+	defer func() { retained = nil }()
+
+	err := Check(before, Limits{Goroutines: 100, FDs: 100, HeapGrowth: 1 << 20, Settle: 50 * time.Millisecond})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bytes of heap")
+}
+
+func TestSoakLeavesTheWarmUpRunOut(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var iterations []int
+	err := Soak(3, Limits{Settle: 50 * time.Millisecond}, func(i int) error {
+		iterations = append(iterations, i)
+		if i == 0 {
+			go func() { <-stop }()
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, iterations)
+}
+
+func TestSoakFailsOnLeaksAndIterationErrors(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	err := Soak(3, Limits{Goroutines: 1, Settle: 50 * time.Millisecond}, func(i int) error {
+		go func() { <-stop }()
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "leaked 3 goroutines")
+
+	err = Soak(3, Limits{}, func(i int) error {
+		if i == 2 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "iteration 2: boom")
+}
@@ -0,0 +1,226 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+//DefaultSignificance is the p-value under which a latency change counts when CompareConfig.Significance is zero
+const DefaultSignificance = 0.05
+
+//CompareConfig describes the workload Compare runs against two client configurations,
+//e.g. the current one and one with new options or worker counts
+type CompareConfig struct {
+	Baseline  *meniscus.BulkClient
+	Candidate *meniscus.BulkClient
+	NewBulk   func() *meniscus.RoundTrip // builds the bulk fired on every tick, for both clients
+	Rate      int                        // bulks per second
+	Duration  time.Duration              // how long each client is run for in every round
+	Rounds    int                        // rounds alternating the clients, evening out drifts of the downstream, defaults to 1
+
+	MaxLatencyRegression    float64 // relative growth of the p50 or p99 request latency of the candidate failing the comparison, 0.1 for 10%
+	MaxThroughputRegression float64 // relative drop of the throughput of the candidate failing the comparison
+	MaxGoodputRegression    float64 // relative drop of the goodput of the candidate failing the comparison
+	MaxErrorRateRegression  float64 // growth of the error rate of the candidate failing the comparison, in points of ratio
+	Significance            float64 // p-value under which a latency change is not noise, defaults to DefaultSignificance
+}
+
+//Comparison is the outcome of Compare, deltas are those of the candidate against the baseline.
+//Latencies are those of the requests of the bulks, see Report.RequestLatency.
+type Comparison struct {
+	Baseline  Report
+	Candidate Report
+
+	P50Delta         time.Duration
+	P99Delta         time.Duration
+	P50Change        float64 // relative, 0.1 when the candidate is 10% slower
+	P99Change        float64
+	LatencyPValue    float64 // of the Mann-Whitney U test of both latency distributions being the same, 1 without samples
+	ThroughputChange float64 // relative, -0.1 when the candidate completes 10% fewer requests per second
+	GoodputChange    float64 // relative, -0.1 when the candidate succeeds 10% fewer requests per second
+	ErrorRateDelta   float64
+
+	Regressions []string // the thresholds of the config the candidate exceeded, none when it passes
+}
+
+//Regressed tells whether the candidate exceeded a threshold, e.g. to fail a CI job
+func (c Comparison) Regressed() bool {
+	return len(c.Regressions) != 0
+}
+
+//String renders the comparison as a small table followed by the regressions
+func (c Comparison) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-11s %12s %12s %12s\n", "", "baseline", "candidate", "change")
+	fmt.Fprintf(&b, "%-11s %12s %12s %+11.1f%%\n", "p50", c.Baseline.RequestLatency.P50, c.Candidate.RequestLatency.P50, c.P50Change*100)
+	fmt.Fprintf(&b, "%-11s %12s %12s %+11.1f%%\n", "p99", c.Baseline.RequestLatency.P99, c.Candidate.RequestLatency.P99, c.P99Change*100)
+	fmt.Fprintf(&b, "%-11s %12s %12s %12s\n", "stddev", c.Baseline.RequestLatency.StdDev, c.Candidate.RequestLatency.StdDev, "")
+	fmt.Fprintf(&b, "%-11s %12s %12s %12.4f\n", "p-value", "", "", c.LatencyPValue)
+	fmt.Fprintf(&b, "%-11s %11.2f/s %11.2f/s %+11.1f%%\n", "throughput", c.Baseline.Throughput, c.Candidate.Throughput, c.ThroughputChange*100)
+	fmt.Fprintf(&b, "%-11s %11.2f/s %11.2f/s %+11.1f%%\n", "goodput", c.Baseline.Goodput, c.Candidate.Goodput, c.GoodputChange*100)
+	fmt.Fprintf(&b, "%-11s %12.4f %12.4f %+12.4f\n", "error rate", c.Baseline.ErrorRate(), c.Candidate.ErrorRate(), c.ErrorRateDelta)
+	for _, regression := range c.Regressions {
+		fmt.Fprintf(&b, "regression: %s\n", regression)
+	}
+
+	return b.String()
+}
+
+//Compare runs the same workload through the baseline and the candidate client, one after the other in every round,
+//and compares their reports, the reports of every round of a client being merged. Comparing two versions of
+//the library itself is done by running Compare in each build against a common baseline configuration.
+//Clients are compared on the latency distribution of their requests, a latency change only counting as a regression
+//when a Mann-Whitney U test finds it significant, and on throughput, goodput and error rate.
+//Both clients being fired at the same rate, their throughput only drops when one falls behind.
+//A comparison cut short by ctx returns its error.
+func Compare(ctx context.Context, config CompareConfig) (Comparison, error) {
+	if config.Baseline == nil || config.Candidate == nil {
+		return Comparison{}, ErrInvalidConfig
+	}
+
+	runners := make([]*Runner, 2)
+	for i, client := range []*meniscus.BulkClient{config.Baseline, config.Candidate} {
+		runner, err := NewRunner(Config{Client: client, NewBulk: config.NewBulk, Rate: config.Rate, Duration: config.Duration})
+		if err != nil {
+			return Comparison{}, err
+		}
+		runners[i] = runner
+	}
+
+	rounds := config.Rounds
+	if rounds <= 0 {
+		rounds = 1
+	}
+
+	collectors := []*collector{newCollector(nil), newCollector(nil)}
+	elapsed := make([]time.Duration, 2)
+	for round := 0; round < rounds && ctx.Err() == nil; round++ {
+		for i, runner := range runners {
+			ran, err := runner.run(ctx, collectors[i])
+			if err != nil {
+				return Comparison{}, err
+			}
+			elapsed[i] += ran
+		}
+	}
+
+	if ctx.Err() != nil {
+		return Comparison{}, ctx.Err()
+	}
+
+	pValue := mannWhitney(collectors[0].requests, collectors[1].requests)
+	return compare(collectors[0].report(elapsed[0]), collectors[1].report(elapsed[1]), pValue, config), nil
+}
+
+func compare(baseline, candidate Report, pValue float64, config CompareConfig) Comparison {
+	comparison := Comparison{
+		Baseline:         baseline,
+		Candidate:        candidate,
+		P50Delta:         candidate.RequestLatency.P50 - baseline.RequestLatency.P50,
+		P99Delta:         candidate.RequestLatency.P99 - baseline.RequestLatency.P99,
+		P50Change:        change(float64(baseline.RequestLatency.P50), float64(candidate.RequestLatency.P50)),
+		P99Change:        change(float64(baseline.RequestLatency.P99), float64(candidate.RequestLatency.P99)),
+		LatencyPValue:    pValue,
+		ThroughputChange: change(baseline.Throughput, candidate.Throughput),
+		GoodputChange:    change(baseline.Goodput, candidate.Goodput),
+		ErrorRateDelta:   candidate.ErrorRate() - baseline.ErrorRate(),
+	}
+
+	significance := config.Significance
+	if significance <= 0 {
+		significance = DefaultSignificance
+	}
+
+	if config.MaxLatencyRegression > 0 && pValue < significance {
+		if comparison.P50Change > config.MaxLatencyRegression {
+			comparison.Regressions = append(comparison.Regressions,
+				fmt.Sprintf("p50 latency grew by %.1f%%, over %.1f%%", comparison.P50Change*100, config.MaxLatencyRegression*100))
+		}
+		if comparison.P99Change > config.MaxLatencyRegression {
+			comparison.Regressions = append(comparison.Regressions,
+				fmt.Sprintf("p99 latency grew by %.1f%%, over %.1f%%", comparison.P99Change*100, config.MaxLatencyRegression*100))
+		}
+	}
+
+	if config.MaxThroughputRegression > 0 && -comparison.ThroughputChange > config.MaxThroughputRegression {
+		comparison.Regressions = append(comparison.Regressions,
+			fmt.Sprintf("throughput dropped by %.1f%%, over %.1f%%", -comparison.ThroughputChange*100, config.MaxThroughputRegression*100))
+	}
+
+	if config.MaxGoodputRegression > 0 && -comparison.GoodputChange > config.MaxGoodputRegression {
+		comparison.Regressions = append(comparison.Regressions,
+			fmt.Sprintf("goodput dropped by %.1f%%, over %.1f%%", -comparison.GoodputChange*100, config.MaxGoodputRegression*100))
+	}
+
+	if config.MaxErrorRateRegression > 0 && comparison.ErrorRateDelta > config.MaxErrorRateRegression {
+		comparison.Regressions = append(comparison.Regressions,
+			fmt.Sprintf("error rate grew by %.4f, over %.4f", comparison.ErrorRateDelta, config.MaxErrorRateRegression))
+	}
+
+	return comparison
+}
+
+// change is the relative change from baseline to candidate, zero when there is no baseline to compare with
+func change(baseline, candidate float64) float64 {
+	if baseline == 0 {
+		return 0
+	}
+
+	return (candidate - baseline) / baseline
+}
+
+// mannWhitney returns the two sided p-value of the Mann-Whitney U test of a and b being drawn from the same
+// distribution, with the normal approximation corrected for ties, 1 when either has no sample
+func mannWhitney(a, b []time.Duration) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 1
+	}
+
+	type sample struct {
+		latency  time.Duration
+		baseline bool
+	}
+	samples := make([]sample, 0, len(a)+len(b))
+	for _, latency := range a {
+		samples = append(samples, sample{latency: latency, baseline: true})
+	}
+	for _, latency := range b {
+		samples = append(samples, sample{latency: latency})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].latency < samples[j].latency })
+
+	// ranks start at 1, tied samples sharing the mean of their ranks
+	var rankSum, ties float64
+	for i := 0; i < len(samples); {
+		j := i
+		for j < len(samples) && samples[j].latency == samples[i].latency {
+			j++
+		}
+
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if samples[k].baseline {
+				rankSum += rank
+			}
+		}
+
+		tied := float64(j - i)
+		ties += tied*tied*tied - tied
+		i = j
+	}
+
+	n1, n2, n := float64(len(a)), float64(len(b)), float64(len(samples))
+	u := rankSum - n1*(n1+1)/2
+	variance := n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+
+	z := (u - n1*n2/2) / math.Sqrt(variance)
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
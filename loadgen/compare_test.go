@@ -0,0 +1,115 @@
+package loadgen
+
+import (
+	"context"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCompareRunsBothClientsOnTheSameWorkload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Slow") == "true" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	slow := meniscus.Middleware(func(next meniscus.HTTPClient) meniscus.HTTPClient {
+		return meniscus.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Slow", "true")
+			return next.Do(req)
+		})
+	})
+	comparison, err := Compare(context.Background(), CompareConfig{
+		Baseline:  meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second),
+		Candidate: meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second, meniscus.WithMiddleware(slow)),
+		NewBulk: func() *meniscus.RoundTrip {
+			return meniscus.NewBulkRequest(nil).AddGet(server.URL, nil)
+		},
+		Rate:                 50,
+		Duration:             100 * time.Millisecond,
+		Rounds:               2,
+		MaxLatencyRegression: 0.5,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, comparison.Baseline.Bulks > 0)
+	assert.True(t, comparison.Candidate.Bulks > 0)
+	assert.True(t, comparison.P50Delta >= 15*time.Millisecond)
+	assert.True(t, comparison.Regressed())
+	assert.Contains(t, comparison.String(), "regression: p50 latency grew by")
+}
+
+func TestCompareFlagsTheRegressionsOverTheThresholds(t *testing.T) {
+	baseline := Report{Requests: 100, Succeeded: 100, Throughput: 100, Goodput: 100,
+		RequestLatency: LatencySummary{P50: 10 * time.Millisecond, P99: 40 * time.Millisecond}}
+	candidate := Report{Requests: 100, Succeeded: 80, Failed: 20, Throughput: 90, Goodput: 80,
+		RequestLatency: LatencySummary{P50: 10 * time.Millisecond, P99: 50 * time.Millisecond}}
+
+	comparison := compare(baseline, candidate, 0.01, CompareConfig{MaxLatencyRegression: 0.2, MaxThroughputRegression: 0.05,
+		MaxGoodputRegression: 0.1, MaxErrorRateRegression: 0.1})
+
+	assert.Equal(t, 10*time.Millisecond, comparison.P99Delta)
+	assert.InDelta(t, 0.25, comparison.P99Change, 1e-9)
+	assert.InDelta(t, -0.1, comparison.ThroughputChange, 1e-9)
+	assert.InDelta(t, -0.2, comparison.GoodputChange, 1e-9)
+	assert.InDelta(t, 0.2, comparison.ErrorRateDelta, 1e-9)
+	assert.Equal(t, []string{
+		"p99 latency grew by 25.0%, over 20.0%",
+		"throughput dropped by 10.0%, over 5.0%",
+		"goodput dropped by 20.0%, over 10.0%",
+		"error rate grew by 0.2000, over 0.1000",
+	}, comparison.Regressions)
+
+	assert.False(t, compare(baseline, baseline, 1, CompareConfig{MaxLatencyRegression: 0.2}).Regressed())
+}
+
+func TestCompareIgnoresLatencyChangesThatAreNotSignificant(t *testing.T) {
+	baseline := Report{RequestLatency: LatencySummary{P50: 10 * time.Millisecond, P99: 40 * time.Millisecond}}
+	candidate := Report{RequestLatency: LatencySummary{P50: 10 * time.Millisecond, P99: 80 * time.Millisecond}}
+
+	assert.False(t, compare(baseline, candidate, 0.3, CompareConfig{MaxLatencyRegression: 0.2}).Regressed())
+	assert.True(t, compare(baseline, candidate, 0.3, CompareConfig{MaxLatencyRegression: 0.2, Significance: 0.5}).Regressed())
+}
+
+func TestMannWhitneyTellsShiftedDistributionsFromNoise(t *testing.T) {
+	var fast, alsoFast, slow []time.Duration
+	for i := 0; i < 50; i++ {
+		jitter := time.Duration(i%10) * time.Millisecond
+		fast = append(fast, 10*time.Millisecond+jitter)
+		alsoFast = append(alsoFast, 10*time.Millisecond+time.Duration((i+3)%10)*time.Millisecond)
+		slow = append(slow, 15*time.Millisecond+jitter)
+	}
+
+	assert.True(t, mannWhitney(fast, slow) < 0.001)
+	assert.True(t, mannWhitney(fast, alsoFast) > 0.5)
+	assert.Equal(t, 1.0, mannWhitney(fast, nil))
+	assert.Equal(t, 1.0, mannWhitney([]time.Duration{time.Millisecond}, []time.Duration{time.Millisecond}))
+}
+
+func TestCompareRequiresBothClients(t *testing.T) {
+	_, err := Compare(context.Background(), CompareConfig{Baseline: meniscus.NewBulkHTTPClient(nil, time.Second)})
+	assert.Equal(t, ErrInvalidConfig, err)
+}
+
+func TestCompareReturnsTheErrorOfARunCutShort(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Compare(ctx, CompareConfig{
+		Baseline:  meniscus.NewBulkHTTPClient(nil, time.Second),
+		Candidate: meniscus.NewBulkHTTPClient(nil, time.Second),
+		NewBulk: func() *meniscus.RoundTrip {
+			return meniscus.NewBulkRequest(nil)
+		},
+		Rate:     10,
+		Duration: time.Second,
+	})
+	assert.Equal(t, context.Canceled, err)
+}
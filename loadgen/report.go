@@ -0,0 +1,130 @@
+package loadgen
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+//Report aggregates the outcome of all bulks fired by a Runner
+type Report struct {
+	Bulks     int
+	Requests  int
+	Succeeded int
+	Failed    int
+	Ignored   int
+
+	StatusCodes map[int]int
+	Errors      map[string]int
+	Tags        map[string]TagReport // keyed by key=value for every tag set on the requests
+	Routes      map[string]TagReport // keyed by route, only filled when Config.Routes is set
+
+	Elapsed    time.Duration
+	Throughput float64 // requests completed per second
+	Goodput    float64 // requests succeeded per second
+	Latency    LatencySummary
+	QueueWait  LatencySummary // of the requests waiting for a fire worker, a high one calls for more workers
+
+	RequestLatency LatencySummary // of the requests sent, from sending each to receiving its response headers
+
+	SnapshotErr error // the first error writing a Snapshot, after which the run went on without snapshots
+}
+
+//ErrorRate is the fraction of requests that failed or were ignored
+func (r Report) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+
+	return float64(r.Failed+r.Ignored) / float64(r.Requests)
+}
+
+//String renders a one line human readable summary of the report
+func (r Report) String() string {
+	return fmt.Sprintf("bulks=%d requests=%d succeeded=%d failed=%d ignored=%d throughput=%.2f/s goodput=%.2f/s latency[%s]",
+		r.Bulks, r.Requests, r.Succeeded, r.Failed, r.Ignored, r.Throughput, r.Goodput, r.Latency)
+}
+
+//TagReport counts the outcome of the requests sharing a tag or a route
+type TagReport struct {
+	Requests  int
+	Succeeded int
+	Failed    int
+	Ignored   int
+}
+
+//ErrorRate is the fraction of requests with the tag that failed or were ignored
+func (r TagReport) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+
+	return float64(r.Failed+r.Ignored) / float64(r.Requests)
+}
+
+func (r TagReport) add(other TagReport) TagReport {
+	return TagReport{
+		Requests:  r.Requests + other.Requests,
+		Succeeded: r.Succeeded + other.Succeeded,
+		Failed:    r.Failed + other.Failed,
+		Ignored:   r.Ignored + other.Ignored,
+	}
+}
+
+//LatencySummary describes the distribution of latencies, e.g. of bulk round trips
+type LatencySummary struct {
+	Min    time.Duration
+	Mean   time.Duration
+	StdDev time.Duration // spread of the latencies around their mean
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+//String renders the summary as space separated percentiles
+func (s LatencySummary) String() string {
+	return fmt.Sprintf("min=%s mean=%s stddev=%s p50=%s p90=%s p99=%s max=%s", s.Min, s.Mean, s.StdDev, s.P50, s.P90, s.P99, s.Max)
+}
+
+func summarize(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+	mean := total / time.Duration(len(sorted))
+
+	var squares float64
+	for _, latency := range sorted {
+		deviation := float64(latency - mean)
+		squares += deviation * deviation
+	}
+
+	return LatencySummary{
+		Min:    sorted[0],
+		Mean:   mean,
+		StdDev: time.Duration(math.Sqrt(squares / float64(len(sorted)))),
+		P50:    percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		P99:    percentile(sorted, 99),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+func percentile(sorted []time.Duration, p int) time.Duration {
+	index := (len(sorted)*p+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+
+	return sorted[index]
+}
@@ -0,0 +1,225 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"github.com/gojektech/meniscus"
+	"sync"
+	"time"
+)
+
+//ErrInvalidConfig is returned when a Runner is built from an incomplete Config
+var ErrInvalidConfig = errors.New("loadgen: client, bulk builder, rate and duration are required")
+
+//Config describes the load fired by a Runner
+type Config struct {
+	Client   *meniscus.BulkClient
+	NewBulk  func() *meniscus.RoundTrip // builds the bulk fired on every tick
+	Rate     int                        // bulks fired per second
+	Duration time.Duration              // total time bulks are fired for
+	Routes   meniscus.RouteNormalizer   // optional, breaks the report down by route
+
+	SnapshotInterval time.Duration // optional, how often a Snapshot is written to Snapshots
+	Snapshots        SnapshotSink
+}
+
+//Runner fires bulks through a BulkClient at a fixed rate and reports on the outcome
+type Runner struct {
+	config Config
+}
+
+//NewRunner validates config and returns a Runner for it
+func NewRunner(config Config) (*Runner, error) {
+	if config.Client == nil || config.NewBulk == nil || config.Rate <= 0 || config.Duration <= 0 {
+		return nil, ErrInvalidConfig
+	}
+
+	return &Runner{config: config}, nil
+}
+
+//Run fires bulks until the configured duration elapses or ctx is done,
+//waits for the bulks in flight and returns the aggregated report
+func (r *Runner) Run(ctx context.Context) Report {
+	collector := newCollector(r.config.Routes)
+	elapsed, snapshotErr := r.run(ctx, collector)
+
+	report := collector.report(elapsed)
+	report.SnapshotErr = snapshotErr
+	return report
+}
+
+// run fires bulks into collector and returns how long it did so, along with the error of the snapshots
+func (r *Runner) run(ctx context.Context, collector *collector) (time.Duration, error) {
+	ticker := time.NewTicker(time.Second / time.Duration(r.config.Rate))
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(r.config.Duration)
+	defer deadline.Stop()
+
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	var snapshots *snapshotter
+	var snapshotTicks <-chan time.Time
+	if r.config.Snapshots != nil && r.config.SnapshotInterval > 0 {
+		snapshots = newSnapshotter(r.config.Snapshots, r.config.Routes, start)
+		snapshotTicker := time.NewTicker(r.config.SnapshotInterval)
+		defer snapshotTicker.Stop()
+		snapshotTicks = snapshotTicker.C
+	}
+
+LOOP:
+	for {
+		select {
+		case <-ticker.C:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.fire(collector, snapshots)
+			}()
+		case now := <-snapshotTicks:
+			snapshots.flush(now)
+		case <-deadline.C:
+			break LOOP
+		case <-ctx.Done():
+			break LOOP
+		}
+	}
+
+	wg.Wait()
+	elapsed := time.Since(start)
+	if snapshots != nil {
+		return elapsed, snapshots.flush(time.Now())
+	}
+
+	return elapsed, nil
+}
+
+func (r *Runner) fire(collector *collector, snapshots *snapshotter) {
+	bulk := r.config.NewBulk()
+	defer bulk.CloseAllResponses()
+
+	start := time.Now()
+	r.config.Client.Do(bulk)
+	latency, results := time.Since(start), bulk.Results()
+	collector.add(latency, results)
+	if snapshots != nil {
+		snapshots.add(latency, results)
+	}
+}
+
+// snapshotter collects the bulks completed since the last snapshot
+type snapshotter struct {
+	mu     sync.Mutex
+	sink   SnapshotSink
+	routes meniscus.RouteNormalizer
+	window *collector
+	since  time.Time
+	err    error // the first error of the sink, after which no more snapshots are written
+}
+
+func newSnapshotter(sink SnapshotSink, routes meniscus.RouteNormalizer, start time.Time) *snapshotter {
+	return &snapshotter{sink: sink, routes: routes, window: newCollector(routes), since: start}
+}
+
+func (s *snapshotter) add(latency time.Duration, results []meniscus.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.window.add(latency, results)
+}
+
+// flush writes the snapshot of the window ending at now and starts the next one
+func (s *snapshotter) flush(now time.Time) error {
+	s.mu.Lock()
+	window, since := s.window, s.since
+	s.window, s.since = newCollector(s.routes), now
+	s.mu.Unlock()
+
+	if s.err == nil {
+		elapsed := now.Sub(since)
+		s.err = s.sink.WriteSnapshot(Snapshot{Time: now, Elapsed: elapsed, Report: window.report(elapsed)})
+	}
+
+	return s.err
+}
+
+type collector struct {
+	mu         sync.Mutex
+	routes     meniscus.RouteNormalizer
+	latencies  []time.Duration
+	requests   []time.Duration // latencies of the requests sent
+	queueWaits []time.Duration
+	totals     Report
+}
+
+func newCollector(routes meniscus.RouteNormalizer) *collector {
+	return &collector{
+		routes: routes,
+		totals: Report{StatusCodes: map[int]int{}, Errors: map[string]int{}, Tags: map[string]TagReport{}, Routes: map[string]TagReport{}},
+	}
+}
+
+func (c *collector) add(latency time.Duration, results []meniscus.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.latencies = append(c.latencies, latency)
+	c.totals.Bulks++
+	for _, result := range results {
+		c.totals.Requests++
+		if result.QueueWait > 0 {
+			c.queueWaits = append(c.queueWaits, result.QueueWait)
+		}
+		if result.Latency > 0 {
+			c.requests = append(c.requests, result.Latency)
+		}
+
+		outcome := c.outcome(result)
+		for key, value := range result.Tags {
+			tag := key + "=" + value
+			c.totals.Tags[tag] = c.totals.Tags[tag].add(outcome)
+		}
+
+		if c.routes != nil && result.Request != nil {
+			route := c.routes(result.Request.URL)
+			c.totals.Routes[route] = c.totals.Routes[route].add(outcome)
+		}
+	}
+}
+
+// outcome counts result in the totals and returns it as a single request report
+func (c *collector) outcome(result meniscus.Result) TagReport {
+	switch {
+	case result.Err == nil && result.Response != nil:
+		c.totals.Succeeded++
+		c.totals.StatusCodes[result.Response.StatusCode]++
+		return TagReport{Requests: 1, Succeeded: 1}
+	case meniscus.Code(result.Err) == meniscus.CodeIgnored:
+		c.totals.Ignored++
+		return TagReport{Requests: 1, Ignored: 1}
+	default:
+		c.totals.Failed++
+		if result.Err != nil {
+			c.totals.Errors[result.Err.Error()]++
+		}
+		return TagReport{Requests: 1, Failed: 1}
+	}
+}
+
+func (c *collector) report(elapsed time.Duration) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := c.totals
+	report.Elapsed = elapsed
+	report.Latency = summarize(c.latencies)
+	report.QueueWait = summarize(c.queueWaits)
+	report.RequestLatency = summarize(c.requests)
+	if elapsed > 0 {
+		report.Throughput = float64(report.Requests) / elapsed.Seconds()
+		report.Goodput = float64(report.Succeeded) / elapsed.Seconds()
+	}
+
+	return report
+}
@@ -0,0 +1,119 @@
+package loadgen
+
+import (
+	"context"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunnerFiresBulksAtTheConfiguredRateAndReports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second)
+	runner, err := NewRunner(Config{
+		Client: client,
+		NewBulk: func() *meniscus.RoundTrip {
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			return meniscus.NewBulkRequest([]*http.Request{req, req.Clone(req.Context())}, 2, 2)
+		},
+		Rate:     50,
+		Duration: 200 * time.Millisecond,
+	})
+	require.NoError(t, err, "no errors")
+
+	report := runner.Run(context.Background())
+
+	assert.True(t, report.Bulks > 0)
+	assert.Equal(t, report.Bulks*2, report.Requests)
+	assert.Equal(t, report.Requests, report.Succeeded)
+	assert.Equal(t, report.Requests, report.StatusCodes[http.StatusOK])
+	assert.Equal(t, float64(0), report.ErrorRate())
+	assert.True(t, report.Latency.Max >= report.Latency.P50)
+	assert.True(t, report.Throughput > 0)
+}
+
+func TestReportBreaksDownRequestsByTag(t *testing.T) {
+	collector := newCollector(nil)
+	ok := &http.Response{StatusCode: http.StatusOK}
+	collector.add(time.Millisecond, []meniscus.Result{
+		{Response: ok, Tags: meniscus.Tags{"endpoint": "get-driver", "tier": "gold"}},
+		{Err: meniscus.ErrRequestIgnored, Tags: meniscus.Tags{"endpoint": "get-driver"}},
+		{Response: ok},
+	})
+
+	report := collector.report(time.Second)
+
+	assert.Equal(t, TagReport{Requests: 2, Succeeded: 1, Ignored: 1}, report.Tags["endpoint=get-driver"])
+	assert.Equal(t, TagReport{Requests: 1, Succeeded: 1}, report.Tags["tier=gold"])
+	assert.Equal(t, 0.5, report.Tags["endpoint=get-driver"].ErrorRate())
+	assert.Equal(t, 3, report.Requests)
+}
+
+func TestReportBreaksDownRequestsByRoute(t *testing.T) {
+	collector := newCollector(meniscus.NormalizeIDs)
+	ok := &http.Response{StatusCode: http.StatusOK}
+	first, _ := http.NewRequest(http.MethodGet, "http://example.com/drivers/1", nil)
+	second, _ := http.NewRequest(http.MethodGet, "http://example.com/drivers/2", nil)
+	collector.add(time.Millisecond, []meniscus.Result{{Request: first, Response: ok}, {Request: second, Err: meniscus.ErrRequestIgnored}})
+
+	report := collector.report(time.Second)
+
+	assert.Equal(t, map[string]TagReport{"/drivers/:id": {Requests: 2, Succeeded: 1, Ignored: 1}}, report.Routes)
+}
+
+func TestReportSummarizesQueueWaits(t *testing.T) {
+	collector := newCollector(nil)
+	ok := &http.Response{StatusCode: http.StatusOK}
+	collector.add(time.Millisecond, []meniscus.Result{
+		{Response: ok, QueueWait: time.Millisecond},
+		{Response: ok, QueueWait: 3 * time.Millisecond},
+		{Err: meniscus.ErrRequestIgnored},
+	})
+
+	report := collector.report(time.Second)
+
+	assert.Equal(t, time.Millisecond, report.QueueWait.Min)
+	assert.Equal(t, 3*time.Millisecond, report.QueueWait.Max)
+	assert.Equal(t, 2*time.Millisecond, report.QueueWait.Mean)
+}
+
+func TestNewRunnerRejectsIncompleteConfig(t *testing.T) {
+	_, err := NewRunner(Config{Rate: 1})
+	assert.Equal(t, ErrInvalidConfig, err)
+}
+
+func TestSummarizeComputesPercentiles(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	summary := summarize(latencies)
+
+	assert.Equal(t, time.Millisecond, summary.Min)
+	assert.Equal(t, 50*time.Millisecond, summary.P50)
+	assert.Equal(t, 90*time.Millisecond, summary.P90)
+	assert.Equal(t, 99*time.Millisecond, summary.P99)
+	assert.Equal(t, 100*time.Millisecond, summary.Max)
+}
+
+func TestReportCountsAttributedTimeoutsAsIgnored(t *testing.T) {
+	collector := newCollector(nil)
+	collector.add(time.Millisecond, []meniscus.Result{
+		{Err: &meniscus.TimeoutError{Source: meniscus.BulkDeadline, Phase: meniscus.PhaseAwaitingHeaders, Err: meniscus.ErrRequestIgnored}},
+		{Err: meniscus.ErrRequestCancelled},
+	})
+
+	report := collector.report(time.Second)
+
+	assert.Equal(t, 2, report.Ignored)
+	assert.Equal(t, 0, report.Failed)
+}
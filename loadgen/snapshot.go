@@ -0,0 +1,105 @@
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Snapshot is the report of the bulks completed during one Config.SnapshotInterval of a run
+type Snapshot struct {
+	Time    time.Time // end of the interval
+	Elapsed time.Duration
+	Report  Report
+}
+
+//SnapshotSink persists the snapshots of a run, e.g. so that perf runs in CI leave comparable artifacts
+type SnapshotSink interface {
+	WriteSnapshot(snapshot Snapshot) error
+}
+
+//SnapshotSinkFunc is an adapter to allow the use of ordinary functions as a SnapshotSink
+type SnapshotSinkFunc func(Snapshot) error
+
+//WriteSnapshot calls f(snapshot)
+func (f SnapshotSinkFunc) WriteSnapshot(snapshot Snapshot) error {
+	return f(snapshot)
+}
+
+// snapshotRecord is the JSON line written by JSONLinesSink
+type snapshotRecord struct {
+	Time       time.Time `json:"time"`
+	ElapsedMS  float64   `json:"elapsed_ms"`
+	Bulks      int       `json:"bulks"`
+	Requests   int       `json:"requests"`
+	Succeeded  int       `json:"succeeded"`
+	Failed     int       `json:"failed"`
+	Ignored    int       `json:"ignored"`
+	Throughput float64   `json:"throughput"`
+	P50MS      float64   `json:"latency_p50_ms"`
+	P90MS      float64   `json:"latency_p90_ms"`
+	P99MS      float64   `json:"latency_p99_ms"`
+	MaxMS      float64   `json:"latency_max_ms"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+//JSONLinesSink writes every snapshot to w as one JSON line of counts, throughput and latency percentiles
+func JSONLinesSink(w io.Writer) SnapshotSink {
+	encoder := json.NewEncoder(w)
+	return SnapshotSinkFunc(func(snapshot Snapshot) error {
+		report := snapshot.Report
+		return encoder.Encode(snapshotRecord{
+			Time:       snapshot.Time,
+			ElapsedMS:  milliseconds(snapshot.Elapsed),
+			Bulks:      report.Bulks,
+			Requests:   report.Requests,
+			Succeeded:  report.Succeeded,
+			Failed:     report.Failed,
+			Ignored:    report.Ignored,
+			Throughput: report.Throughput,
+			P50MS:      milliseconds(report.Latency.P50),
+			P90MS:      milliseconds(report.Latency.P90),
+			P99MS:      milliseconds(report.Latency.P99),
+			MaxMS:      milliseconds(report.Latency.Max),
+		})
+	})
+}
+
+//InfluxSink writes every snapshot to w as a point of measurement in InfluxDB line protocol, with tags,
+//ready to be loaded with `influx write` or posted to the /write endpoint of InfluxDB
+func InfluxSink(w io.Writer, measurement string, tags map[string]string) SnapshotSink {
+	series := escapeInflux(measurement, ", ")
+	var keys []string
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series += "," + escapeInflux(key, ",= ") + "=" + escapeInflux(tags[key], ",= ")
+	}
+
+	return SnapshotSinkFunc(func(snapshot Snapshot) error {
+		report := snapshot.Report
+		_, err := fmt.Fprintf(w, "%s bulks=%di,requests=%di,succeeded=%di,failed=%di,ignored=%di,throughput=%g,"+
+			"latency_p50_ms=%g,latency_p90_ms=%g,latency_p99_ms=%g,latency_max_ms=%g %d\n",
+			series, report.Bulks, report.Requests, report.Succeeded, report.Failed, report.Ignored, report.Throughput,
+			milliseconds(report.Latency.P50), milliseconds(report.Latency.P90), milliseconds(report.Latency.P99),
+			milliseconds(report.Latency.Max), snapshot.Time.UnixNano())
+		return err
+	})
+}
+
+// escapeInflux escapes the characters of special in a measurement, tag key or tag value
+func escapeInflux(s, special string) string {
+	for _, c := range special {
+		s = strings.Replace(s, string(c), `\`+string(c), -1)
+	}
+
+	return s
+}
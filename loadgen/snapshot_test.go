@@ -0,0 +1,106 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunnerWritesPeriodicSnapshots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var snapshots []Snapshot
+	runner, err := NewRunner(Config{
+		Client: meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second),
+		NewBulk: func() *meniscus.RoundTrip {
+			return meniscus.NewBulkRequest(nil).AddGet(server.URL, nil)
+		},
+		Rate:             100,
+		Duration:         250 * time.Millisecond,
+		SnapshotInterval: 100 * time.Millisecond,
+		Snapshots: SnapshotSinkFunc(func(snapshot Snapshot) error {
+			snapshots = append(snapshots, snapshot)
+			return nil
+		}),
+	})
+	require.NoError(t, err)
+
+	report := runner.Run(context.Background())
+
+	assert.NoError(t, report.SnapshotErr)
+	require.Len(t, snapshots, 3)
+	requests := 0
+	for _, snapshot := range snapshots {
+		requests += snapshot.Report.Requests
+		assert.True(t, snapshot.Elapsed > 0)
+	}
+	assert.Equal(t, report.Requests, requests)
+	assert.True(t, snapshots[0].Report.Throughput > 0)
+}
+
+func TestRunnerStopsSnapshotsAtTheFirstSinkError(t *testing.T) {
+	writes := 0
+	runner, _ := NewRunner(Config{
+		Client:           meniscus.NewBulkHTTPClient(nil, time.Second),
+		NewBulk:          func() *meniscus.RoundTrip { return meniscus.NewBulkRequest(nil) },
+		Rate:             100,
+		Duration:         150 * time.Millisecond,
+		SnapshotInterval: 50 * time.Millisecond,
+		Snapshots: SnapshotSinkFunc(func(Snapshot) error {
+			writes++
+			return errors.New("disk full")
+		}),
+	})
+
+	report := runner.Run(context.Background())
+
+	assert.EqualError(t, report.SnapshotErr, "disk full")
+	assert.Equal(t, 1, writes)
+}
+
+func snapshotOf() Snapshot {
+	return Snapshot{
+		Time:    time.Unix(1500000000, 0),
+		Elapsed: time.Second,
+		Report: Report{Bulks: 2, Requests: 4, Succeeded: 3, Failed: 1, Throughput: 4,
+			Latency: LatencySummary{P50: 10 * time.Millisecond, P90: 20 * time.Millisecond, P99: 25 * time.Millisecond, Max: 30 * time.Millisecond}},
+	}
+}
+
+func TestInfluxSinkWritesLineProtocol(t *testing.T) {
+	var out bytes.Buffer
+	sink := InfluxSink(&out, "meniscus loadgen", map[string]string{"run": "ci 42", "branch": "main"})
+
+	require.NoError(t, sink.WriteSnapshot(snapshotOf()))
+
+	assert.Equal(t, `meniscus\ loadgen,branch=main,run=ci\ 42 bulks=2i,requests=4i,succeeded=3i,failed=1i,ignored=0i,throughput=4,`+
+		"latency_p50_ms=10,latency_p90_ms=20,latency_p99_ms=25,latency_max_ms=30 1500000000000000000\n", out.String())
+}
+
+func TestJSONLinesSinkWritesOneRecordPerSnapshot(t *testing.T) {
+	var out bytes.Buffer
+	sink := JSONLinesSink(&out)
+
+	require.NoError(t, sink.WriteSnapshot(snapshotOf()))
+	require.NoError(t, sink.WriteSnapshot(snapshotOf()))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, float64(4), record["requests"])
+	assert.Equal(t, float64(25), record["latency_p99_ms"])
+	assert.Equal(t, float64(1000), record["elapsed_ms"])
+}
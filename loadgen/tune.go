@@ -0,0 +1,144 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"github.com/gojektech/meniscus"
+	"net/http"
+	"time"
+)
+
+//ErrNoCandidates is returned by Tune when there is nothing to compare
+var ErrNoCandidates = errors.New("loadgen: no candidate configurations to tune")
+
+//Candidate is one worker and rate configuration evaluated by Tune
+type Candidate struct {
+	FireWorkers    int
+	ProcessWorkers int
+	Rate           int // bulks per second, defaults to TuneConfig.Rate
+}
+
+//DefaultCandidates returns configurations with equal fire and process workers doubling from 1 to 64
+func DefaultCandidates() []Candidate {
+	var candidates []Candidate
+	for workers := 1; workers <= 64; workers *= 2 {
+		candidates = append(candidates, Candidate{FireWorkers: workers, ProcessWorkers: workers})
+	}
+
+	return candidates
+}
+
+//TuneConfig describes the sample workload and the configurations Tune compares
+type TuneConfig struct {
+	Client       *meniscus.BulkClient
+	Requests     func() []*http.Request // builds a sample of the real request mix for one bulk
+	Candidates   []Candidate            // defaults to DefaultCandidates
+	Rate         int                    // bulks per second
+	Duration     time.Duration          // how long each candidate is run for
+	MaxErrorRate float64                // candidates above this error rate are not recommended
+	MaxLatency   time.Duration          // optional, candidates with a p99 latency above it are not recommended
+}
+
+//TuneResult is the report gathered for a single candidate
+type TuneResult struct {
+	Candidate Candidate
+	Report    Report
+}
+
+//Recommendation holds the best candidate along with the results of every candidate run
+type Recommendation struct {
+	Best    Candidate
+	Results []TuneResult
+}
+
+//Tune runs the sample workload once per candidate and recommends the configuration
+//with the highest goodput among those within the error rate and latency budgets.
+//As bulks are fired at a fixed rate, candidates within 5% of the same goodput are told apart by their p99 latency.
+//When no candidate stays within budget, the one with the lowest error rate is recommended.
+func Tune(ctx context.Context, config TuneConfig) (Recommendation, error) {
+	candidates := config.Candidates
+	if len(candidates) == 0 {
+		candidates = DefaultCandidates()
+	}
+
+	if config.Requests == nil {
+		return Recommendation{}, ErrInvalidConfig
+	}
+
+	var recommendation Recommendation
+	for _, candidate := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if candidate.Rate == 0 {
+			candidate.Rate = config.Rate
+		}
+
+		candidate := candidate
+		runner, err := NewRunner(Config{
+			Client: config.Client,
+			NewBulk: func() *meniscus.RoundTrip {
+				return meniscus.NewBulkRequest(config.Requests(), candidate.FireWorkers, candidate.ProcessWorkers)
+			},
+			Rate:     candidate.Rate,
+			Duration: config.Duration,
+		})
+		if err != nil {
+			return Recommendation{}, err
+		}
+
+		recommendation.Results = append(recommendation.Results, TuneResult{Candidate: candidate, Report: runner.Run(ctx)})
+	}
+
+	if len(recommendation.Results) == 0 {
+		return Recommendation{}, ErrNoCandidates
+	}
+
+	recommendation.Best = best(recommendation.Results, config.MaxErrorRate, config.MaxLatency).Candidate
+	return recommendation, nil
+}
+
+// goodputTolerance is the relative goodput difference below which two candidates are ranked by latency,
+// every candidate keeping up with a fixed rate completing about as many requests
+const goodputTolerance = 0.05
+
+func best(results []TuneResult, maxErrorRate float64, maxLatency time.Duration) TuneResult {
+	var chosen *TuneResult
+	for i := range results {
+		result := &results[i]
+		if result.Report.ErrorRate() > maxErrorRate || (maxLatency > 0 && result.Report.Latency.P99 > maxLatency) {
+			continue
+		}
+
+		if chosen == nil || better(result.Report, chosen.Report) {
+			chosen = result
+		}
+	}
+
+	if chosen != nil {
+		return *chosen
+	}
+
+	chosen = &results[0]
+	for i := range results {
+		if results[i].Report.ErrorRate() < chosen.Report.ErrorRate() {
+			chosen = &results[i]
+		}
+	}
+
+	return *chosen
+}
+
+// better tells whether report ranks above current, on goodput first and p99 latency second
+func better(report, current Report) bool {
+	if report.Goodput > current.Goodput*(1+goodputTolerance) {
+		return true
+	}
+
+	if report.Goodput < current.Goodput*(1-goodputTolerance) {
+		return false
+	}
+
+	return report.Latency.P99 < current.Latency.P99
+}
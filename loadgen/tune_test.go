@@ -0,0 +1,102 @@
+package loadgen
+
+import (
+	"context"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTuneRunsEveryCandidateAndRecommendsOne(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	candidates := []Candidate{{FireWorkers: 1, ProcessWorkers: 1, Rate: 20}, {FireWorkers: 4, ProcessWorkers: 4, Rate: 20}}
+	recommendation, err := Tune(context.Background(), TuneConfig{
+		Client: meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second),
+		Requests: func() []*http.Request {
+			var requests []*http.Request
+			for i := 0; i < 4; i++ {
+				req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+				requests = append(requests, req)
+			}
+			return requests
+		},
+		Candidates: candidates,
+		Duration:   100 * time.Millisecond,
+	})
+	require.NoError(t, err, "no errors")
+
+	assert.Len(t, recommendation.Results, 2)
+	assert.Contains(t, candidates, recommendation.Best)
+}
+
+func TestTuneRecommendsTheCandidateKeepingUpWithTheLoad(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	recommendation, err := Tune(context.Background(), TuneConfig{
+		Client: meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, 100*time.Millisecond),
+		Requests: func() []*http.Request {
+			var requests []*http.Request
+			for i := 0; i < 8; i++ {
+				req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+				requests = append(requests, req)
+			}
+			return requests
+		},
+		Candidates:   []Candidate{{FireWorkers: 1, ProcessWorkers: 1}, {FireWorkers: 8, ProcessWorkers: 8}},
+		Rate:         10,
+		Duration:     300 * time.Millisecond,
+		MaxErrorRate: 0.1,
+	})
+	require.NoError(t, err, "no errors")
+
+	assert.Equal(t, Candidate{FireWorkers: 8, ProcessWorkers: 8, Rate: 10}, recommendation.Best)
+	assert.True(t, recommendation.Results[0].Report.ErrorRate() > 0.1)
+	assert.Equal(t, 0.0, recommendation.Results[1].Report.ErrorRate())
+}
+
+func TestBestPrefersGoodputWithinErrorBudget(t *testing.T) {
+	results := []TuneResult{
+		{Candidate: Candidate{FireWorkers: 1}, Report: Report{Requests: 10, Succeeded: 10, Throughput: 10, Goodput: 10}},
+		{Candidate: Candidate{FireWorkers: 8}, Report: Report{Requests: 10, Failed: 5, Succeeded: 5, Throughput: 100, Goodput: 50}},
+		{Candidate: Candidate{FireWorkers: 4}, Report: Report{Requests: 10, Succeeded: 10, Throughput: 30, Goodput: 30}},
+	}
+
+	assert.Equal(t, 4, best(results, 0.1, 0).Candidate.FireWorkers)
+	assert.Equal(t, 8, best(results, 0.5, 0).Candidate.FireWorkers)
+}
+
+func TestBestRanksCandidatesKeepingUpWithTheRateByLatency(t *testing.T) {
+	results := []TuneResult{
+		{Candidate: Candidate{FireWorkers: 1}, Report: Report{Requests: 100, Succeeded: 100, Throughput: 101, Goodput: 101,
+			Latency: LatencySummary{P99: 300 * time.Millisecond}}},
+		{Candidate: Candidate{FireWorkers: 4}, Report: Report{Requests: 100, Succeeded: 100, Throughput: 99, Goodput: 99,
+			Latency: LatencySummary{P99: 40 * time.Millisecond}}},
+		{Candidate: Candidate{FireWorkers: 8}, Report: Report{Requests: 100, Succeeded: 100, Throughput: 100, Goodput: 100,
+			Latency: LatencySummary{P99: 60 * time.Millisecond}}},
+	}
+
+	assert.Equal(t, 4, best(results, 0, 0).Candidate.FireWorkers)
+	assert.Equal(t, 4, best(results, 0, 50*time.Millisecond).Candidate.FireWorkers)
+	assert.Equal(t, 1, best(results[:1], 0, 50*time.Millisecond).Candidate.FireWorkers)
+}
+
+func TestBestFallsBackToLowestErrorRate(t *testing.T) {
+	results := []TuneResult{
+		{Candidate: Candidate{FireWorkers: 1}, Report: Report{Requests: 10, Failed: 8, Throughput: 10, Goodput: 2}},
+		{Candidate: Candidate{FireWorkers: 2}, Report: Report{Requests: 10, Failed: 3, Throughput: 5, Goodput: 3.5}},
+	}
+
+	assert.Equal(t, 2, best(results, 0, 0).Candidate.FireWorkers)
+}
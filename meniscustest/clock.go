@@ -0,0 +1,24 @@
+// Package meniscustest provides test doubles for code using meniscus
+package meniscustest
+
+import (
+	"github.com/gojektech/meniscus"
+	"github.com/gojektech/meniscus/internal/fakeclock"
+	"time"
+)
+
+//FakeClock is a meniscus.Clock whose time only moves when Advance is called, to be given to meniscus.WithClock
+//so that tests control timeouts and delays. BlockUntil waits for the client to start waiting on its timers.
+type FakeClock struct {
+	*fakeclock.Clock
+}
+
+//NewFakeClock returns a FakeClock set to now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{Clock: fakeclock.New(now)}
+}
+
+//NewTimer returns a timer that fires once the fake time has been advanced by d
+func (c *FakeClock) NewTimer(d time.Duration) meniscus.Timer {
+	return c.Clock.NewTimer(d)
+}
@@ -0,0 +1,29 @@
+package meniscustest
+
+import (
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFakeClockDrivesTheTimeoutOfABulk(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	client := meniscus.NewBulkHTTPClient(meniscus.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}), time.Minute, meniscus.WithClock(clock))
+
+	bulkRequest := meniscus.NewBulkRequest(nil).AddGet("http://example.com", nil)
+	done := make(chan []error)
+	go func() {
+		_, errs := client.Do(bulkRequest)
+		done <- errs
+	}()
+
+	clock.BlockUntil(1)
+	assert.Equal(t, 1, clock.Pending())
+	clock.Advance(time.Minute)
+	assert.Equal(t, []error{meniscus.ErrRequestIgnored}, <-done)
+}
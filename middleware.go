@@ -0,0 +1,23 @@
+package meniscus
+
+import "net/http"
+
+//HTTPClientFunc is an adapter to allow the use of ordinary functions as an HTTPClient
+type HTTPClientFunc func(*http.Request) (*http.Response, error)
+
+//Do calls f(req)
+func (f HTTPClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+//Middleware wraps an HTTPClient to decorate every request fired by the bulk client
+type Middleware func(HTTPClient) HTTPClient
+
+//Chain wraps client with the given middlewares, the first middleware being the outermost
+func Chain(client HTTPClient, middlewares ...Middleware) HTTPClient {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		client = middlewares[i](client)
+	}
+
+	return client
+}
@@ -0,0 +1,258 @@
+package meniscus
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+)
+
+//ClientOption configures optional behaviour of a BulkClient
+type ClientOption func(*BulkClient)
+
+//WithMiddleware wraps the http client used by the BulkClient with the given middlewares
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(cl *BulkClient) {
+		cl.middlewares = append(cl.middlewares, middlewares...)
+	}
+}
+
+//WithClock makes the BulkClient measure its timeout on clock instead of the system clock, e.g. a meniscustest.FakeClock
+func WithClock(clock Clock) ClientOption {
+	return func(cl *BulkClient) {
+		cl.clock = clock
+	}
+}
+
+//WithStagedTimeouts bounds the dial, TLS handshake, response header and total time of every request
+func WithStagedTimeouts(timeouts StagedTimeouts) ClientOption {
+	return func(cl *BulkClient) {
+		cl.stagedTimeouts = timeouts
+	}
+}
+
+//WithForceCloseOnTimeout closes the underlying connection of requests cut short by a deadline
+//instead of only cancelling their context, keeping half read connections out of the idle pool
+func WithForceCloseOnTimeout() ClientOption {
+	return func(cl *BulkClient) {
+		cl.forceClose = true
+	}
+}
+
+//WithConnectionDiagnostics records whether each request reused a connection, see RoundTrip.Connections
+func WithConnectionDiagnostics() ClientOption {
+	return func(cl *BulkClient) {
+		cl.connDiagnostics = true
+	}
+}
+
+//WithUnbufferedResponses hands out the original response bodies instead of copying them into memory.
+//The bodies stay readable until the bulk timeout elapses, it is cancelled or CloseAllResponses is called,
+//so callers must be done reading them before the deadline. Reads past that fail with ErrBulkClosed, see RoundTrip.OpenBodies.
+func WithUnbufferedResponses() ClientOption {
+	return func(cl *BulkClient) {
+		cl.unbuffered = true
+	}
+}
+
+//WithBufferPool recycles the buffers holding copied response bodies once the responses are closed.
+//Bodies can no longer be read after CloseAllResponses.
+func WithBufferPool() ClientOption {
+	return func(cl *BulkClient) {
+		cl.bufferPool = newBufferPool()
+	}
+}
+
+//WithSpillToDisk streams response bodies larger than threshold bytes to temporary files in dir
+//(the default temp directory when empty) instead of holding them in memory.
+//The files are removed when the responses are closed, see CloseAllResponses.
+func WithSpillToDisk(threshold int64, dir string) ClientOption {
+	return func(cl *BulkClient) {
+		cl.spill = spillConfig{threshold: threshold, dir: dir}
+	}
+}
+
+//WithPostProcessor runs processor on the result of every request using its own pool of workers,
+//defaulting to one worker per CPU when workers is not positive
+func WithPostProcessor(processor PostProcessor, workers int) ClientOption {
+	return func(cl *BulkClient) {
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+
+		cl.postProcessor = processor
+		cl.postProcessWorkers = workers
+	}
+}
+
+//WithPostProcessQueue buffers up to size received responses waiting for a post processor,
+//so slow post processing does not hold up the workers reading responses off the network
+func WithPostProcessQueue(size int) ClientOption {
+	return func(cl *BulkClient) {
+		cl.postProcessQueue = size
+	}
+}
+
+//WithProfilerLabels labels the worker goroutines with the bulk ID and destination host of the request
+//they are handling, see runtime/pprof
+func WithProfilerLabels() ClientOption {
+	return func(cl *BulkClient) {
+		cl.profilerLabels = true
+	}
+}
+
+//WithGoroutineBudget caps the pipeline goroutines the client runs across all concurrent bulks.
+//Bulks that would exceed the budget fail with ErrConcurrencyBudgetExceeded without firing any request.
+func WithGoroutineBudget(limit int) ClientOption {
+	return func(cl *BulkClient) {
+		cl.goroutines.limit = int64(limit)
+	}
+}
+
+//WithRouteNormalizer adds the route of each request, as given by normalize, to the profiler labels
+//and to its RequestScope, from which the telemetry middlewares read it
+func WithRouteNormalizer(normalize RouteNormalizer) ClientOption {
+	return func(cl *BulkClient) {
+		cl.routes = normalize
+	}
+}
+
+//WithDestinationPolicy checks the destination of every request against policy before it is sent
+func WithDestinationPolicy(policy DestinationPolicy) ClientOption {
+	return func(cl *BulkClient) {
+		cl.destinations = &policy
+	}
+}
+
+//WithCertificatePins fails requests to pinned hosts with ErrPinMismatch unless they present a pinned key
+func WithCertificatePins(pins CertificatePins) ClientOption {
+	return func(cl *BulkClient) {
+		cl.pins = pins
+	}
+}
+
+//WithResponseVerifier fails requests whose response is rejected by verify.
+//Verified responses are always buffered, even with WithUnbufferedResponses.
+func WithResponseVerifier(verify ResponseVerifier) ClientOption {
+	return func(cl *BulkClient) {
+		cl.verifier = verify
+	}
+}
+
+//WithBodyCipher encrypts request bodies and decrypts response bodies with cipher
+func WithBodyCipher(cipher BodyCipher) ClientOption {
+	return func(cl *BulkClient) {
+		cl.cipher = cipher
+	}
+}
+
+//WithExpectContinue sends requests with bodies over threshold bytes, or of unknown length, with Expect: 100-continue,
+//so their body is only uploaded once the server accepted the headers, or after wait without an answer
+func WithExpectContinue(threshold int64, wait time.Duration) ClientOption {
+	return func(cl *BulkClient) {
+		cl.expectContinue = expectContinue{threshold: threshold, wait: wait}
+	}
+}
+
+//WithUploadPolicy sets how request bodies are framed, as a Content-Length or chunked
+func WithUploadPolicy(policy UploadPolicy) ClientOption {
+	return func(cl *BulkClient) {
+		cl.uploadPolicy = policy
+	}
+}
+
+//WithReducer folds the results of every bulk with reduce as they arrive, see Reducer
+func WithReducer(reduce Reducer) ClientOption {
+	return func(cl *BulkClient) {
+		cl.reducer = reduce
+	}
+}
+
+//WithResponseCache serves GET requests from cache, which keeps their successful responses across Do calls
+func WithResponseCache(cache *ResponseCache) ClientOption {
+	return func(cl *BulkClient) {
+		cl.cache = cache
+	}
+}
+
+//WithHeaderAllowList drops response bodies and keeps only the given headers on the responses,
+//for huge bulks where only status codes and a few headers matter.
+//Bodies are never read so response verifiers and body ciphers are not applied.
+func WithHeaderAllowList(headers ...string) ClientOption {
+	return func(cl *BulkClient) {
+		cl.allowList = headerAllowList{enabled: true}
+		for _, header := range headers {
+			cl.allowList.headers = append(cl.allowList.headers, http.CanonicalHeaderKey(header))
+		}
+	}
+}
+
+//WithSaturationCallback calls callback when the fire workers of a bulk cannot keep up with its requests,
+//see WithSaturationThresholds, so that services can scale out or shed load before requests start timing out.
+//It is called at most once per kind of event and per bulk, from the fire workers, so it must not block.
+func WithSaturationCallback(callback func(SaturationEvent)) ClientOption {
+	return func(cl *BulkClient) {
+		cl.saturationCallback = callback
+	}
+}
+
+//WithSaturationThresholds sets when the callback given WithSaturationCallback is called
+func WithSaturationThresholds(thresholds SaturationThresholds) ClientOption {
+	return func(cl *BulkClient) {
+		cl.saturationThresholds = thresholds
+	}
+}
+
+//WithTimeoutAttribution returns a *TimeoutError for requests failing at a timeout, telling whether the bulk deadline,
+//a staged timeout or the http.Client timeout fired, in which phase and after how long.
+//It wraps the error returned without attribution, e.g. ErrRequestIgnored, which can no longer be compared with ==.
+func WithTimeoutAttribution() ClientOption {
+	return func(cl *BulkClient) {
+		cl.timeoutAttribution = true
+	}
+}
+
+//WithRequestCloner makes CloneForAttempt copy the requests of the client with cloner,
+//for requests carrying state that http.Request.Clone does not copy, e.g. in a custom body type
+func WithRequestCloner(cloner RequestCloner) ClientOption {
+	return func(cl *BulkClient) {
+		cl.cloner = cloner
+	}
+}
+
+//WithSharedPool makes every request of the client hold a slot of pool while in flight
+func WithSharedPool(pool *SharedPool) ClientOption {
+	return func(cl *BulkClient) {
+		cl.sharedPool = pool
+	}
+}
+
+// builtinMiddlewares returns the middlewares enabled through options, they run inside the user supplied middlewares
+func (cl *BulkClient) builtinMiddlewares() []Middleware {
+	var middlewares []Middleware
+	if cl.cache != nil {
+		if cl.cache.clock == nil {
+			cl.cache.clock = cl.clock
+		}
+		middlewares = append(middlewares, cl.cache.middleware(cl.bufferPool, cl.spill))
+	}
+
+	middlewares = append(middlewares, retryMiddleware(cl.retry, cl.clock))
+
+	if cl.limiter != nil {
+		middlewares = append(middlewares, limiterMiddleware(cl.limiter))
+	}
+
+	if cl.sharedPool != nil {
+		middlewares = append(middlewares, cl.sharedPool.Middleware())
+	}
+
+	if cl.stagedTimeouts.enabled() {
+		middlewares = append(middlewares, stagedTimeoutMiddleware(cl.stagedTimeouts, cl.clock))
+	}
+
+	if cl.forceClose {
+		middlewares = append(middlewares, forceCloseMiddleware())
+	}
+
+	return middlewares
+}
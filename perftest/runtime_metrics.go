@@ -1,19 +1,22 @@
+//go:build perftest
+// +build perftest
+
 package perftest
 
 import (
-	"strconv"
-	"time"
-	"os"
-	"sync/atomic"
-	"net/http/httptest"
-	"testing"
-	"sync"
-	"net/http"
-	"meniscus"
+	"context"
 	"fmt"
-	"net/url"
+	"github.com/gojektech/meniscus"
+	"github.com/gojektech/meniscus/loadgen"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	_ "net/http/pprof"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+	"time"
 )
 
 var (
@@ -31,52 +34,60 @@ func fireRequestsAtFrequency(t *testing.T, quit <-chan struct{}) {
 	server := StartSleepingMockServer(1 * time.Nanosecond)
 	defer server.Close()
 
-	ticker := time.NewTicker(timePeriod)
-	var wg sync.WaitGroup
-	var bulkRequestsDone uint64
-	var responses []*http.Response
-	var errs []error
-	var iterationsCompleted int
+	timeout := 100 * time.Millisecond
+	httpclient := &http.Client{Timeout: timeout}
 
-	for {
-		select {
-		case <-ticker.C:
-			if iterationsCompleted < iterations {
-				work(requests, requestSize, timePeriod, bulkRequestsDone, responses, errs, server.URL, &wg)
-				iterationsCompleted++
-				wg.Wait()
+	// at least one bulk per second, a lower rate would not be a valid config
+	rate := int(float64(requests) / timePeriod.Seconds())
+	if rate < 1 {
+		rate = 1
+	}
 
-				assertions(t, requests, requestSize, bulkRequestsDone, responses, errs)
-			} else {
-				logger.Println("Specified iterations are over.")
-			}
+	config := loadgen.Config{
+		Client: meniscus.NewBulkHTTPClient(httpclient, timeout),
+		NewBulk: func() *meniscus.RoundTrip {
+			return newBulkClientWithNRequests(requestSize, server.URL)
+		},
+		Rate:     rate,
+		Duration: time.Duration(iterations) * timePeriod,
+	}
 
+	if path := os.Getenv("SNAPSHOT_FILE"); len(path) != 0 {
+		snapshots, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer snapshots.Close()
+
+		logger.Printf("Writing a snapshot every %f seconds to %s\n", timePeriod.Seconds(), path)
+		config.SnapshotInterval = timePeriod
+		config.Snapshots = loadgen.InfluxSink(snapshots, "meniscus_perftest",
+			map[string]string{"requests": strconv.Itoa(requests), "request_size": strconv.Itoa(requestSize)})
+	}
 
+	runner, err := loadgen.NewRunner(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
 		case <-quit:
-			ticker.Stop()
-			return
+			cancel()
+		case <-ctx.Done():
 		}
-	}
-}
+	}()
 
-func work(requests int, requestSize int, timePeriod time.Duration, bulkRequestsDone uint64, responses []*http.Response, errs []error, url string, wg *sync.WaitGroup) {
 	logger.Printf("Firing %d requests of size %d with an interval of %f seconds.\n", requests, requestSize, timePeriod.Seconds())
-	timeout := 100 * time.Millisecond
-	httpclient := &http.Client{Timeout: timeout}
-
-	for noOfBulkRequests := 0; noOfBulkRequests < requests; noOfBulkRequests++ {
-		wg.Add(1)
-		go func() {
-			client := meniscus.NewBulkHTTPClient(httpclient, timeout)
-			bulkRequest := newBulkClientWithNRequests(requestSize, url)
-			res, err := client.Do(bulkRequest, 10, 10)
-			responses = append(responses, res...)
-			errs = append(errs, err...)
-			atomic.AddUint64(&bulkRequestsDone, 1)
-			bulkRequest.CloseAllResponses()
-			wg.Done()
-		}()
+	report := runner.Run(ctx)
+	logger.Println(report)
+	if report.SnapshotErr != nil {
+		t.Error(report.SnapshotErr)
 	}
+
+	assertions(t, requestSize, report)
 }
 
 func getIntFromEnvOrPanic(envVarName string) int {
@@ -109,7 +120,7 @@ func newSleepingHandler(sleepDuration time.Duration) func(w http.ResponseWriter,
 }
 
 func newBulkClientWithNRequests(n int, serverURL string) *meniscus.RoundTrip {
-	bulkRequest := meniscus.NewBulkRequest()
+	bulkRequest := meniscus.NewBulkRequest(nil, 10, 10)
 	for i := 0; i < n; i++ {
 		query := url.Values{}
 		req, _ := http.NewRequest(http.MethodGet, encodeURL(serverURL, "", query), nil)
@@ -1,7 +1,10 @@
+// +build perftest
+
 package perftest
 
 import (
 	"fmt"
+	"github.com/gojektech/meniscus/loadgen"
 	"github.com/stretchr/testify/assert"
 	metrics "github.com/tevjef/go-runtime-metrics"
 	_ "github.com/tevjef/go-runtime-metrics/expvar"
@@ -39,8 +42,8 @@ func TestBulkClientRuntimeMetrics(t *testing.T) {
 	fireRequestsAtFrequency(t, quit)
 }
 
-func assertions(t *testing.T, requests int, requestSize int, bulkRequestsDone uint64, responses []*http.Response, errs []error) {
-	assert.Equal(t, requests, int(bulkRequestsDone))
-	assert.Equal(t, requests * requestSize, len(responses))
-	assert.Equal(t, requests * requestSize, len(errs))
+func assertions(t *testing.T, requestSize int, report loadgen.Report) {
+	assert.True(t, report.Bulks > 0)
+	assert.Equal(t, report.Bulks*requestSize, report.Requests)
+	assert.Equal(t, report.Requests, report.Succeeded+report.Failed+report.Ignored)
 }
@@ -0,0 +1,100 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+)
+
+//Then adds a second phase to the bulk. Once every request of the bulk completed, build is called with their results,
+//e.g. to use the tokens they fetched, and the requests it returns are fired by the same client and workers,
+//within what is left of the bulk timeout. Their responses and errors follow those of the first phase.
+func (r *RoundTrip) Then(build func([]Result) []*http.Request) *RoundTrip {
+	r.nextPhase = build
+	return r
+}
+
+func (cl *BulkClient) doPhases(bulkRequest *RoundTrip) ([]*http.Response, []error) {
+	ctx, cancel := withClockTimeout(bulkRequest.parentContext(), cl.clock, cl.timeout)
+
+	responses, errs := cl.do(ctx, bulkRequest)
+	if bulkRequest.handOver(cancel) || ctx.Err() != nil || responses == nil {
+		cl.releasePhases(bulkRequest, cancel)
+		return responses, errs
+	}
+
+	requests := bulkRequest.nextPhase(bulkRequest.Results())
+	if len(requests) == 0 {
+		cl.releasePhases(bulkRequest, cancel)
+		return responses, errs
+	}
+
+	phase := NewBulkRequest(requests, bulkRequest.fireRequestsWorkers, bulkRequest.processResponseWorkers).
+		SetID(bulkRequest.id).
+		SetTenant(bulkRequest.tenant)
+	phase.aggregate = bulkRequest.aggregate
+	phase.variables = bulkRequest.variables
+	bulkRequest.follow(phase)
+	cl.do(ctx, phase)
+
+	bulkRequest.appendPhase(phase)
+	cl.releasePhases(bulkRequest, cancel)
+	return bulkRequest.responses, bulkRequest.errors
+}
+
+// releasePhases cancels the context shared by the phases, once the responses are closed when they are unbuffered
+func (cl *BulkClient) releasePhases(bulkRequest *RoundTrip, cancel context.CancelFunc) {
+	if !cl.unbuffered {
+		cancel()
+		return
+	}
+
+	release := bulkRequest.release
+	bulkRequest.release = func() {
+		if release != nil {
+			release()
+		}
+		cancel()
+	}
+}
+
+// handOver makes Cancel abort the phases still to come, and tells whether the bulk was already cancelled
+func (r *RoundTrip) handOver(cancel context.CancelFunc) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cancel = cancel
+	return r.cancelled
+}
+
+// appendPhase adds the requests and outcomes of phase after those of r
+func (r *RoundTrip) appendPhase(phase *RoundTrip) {
+	offset := len(r.requests)
+	r.requests = append(r.requests, phase.requests...)
+	r.responses = append(r.responses, phase.responses...)
+	r.errors = append(r.errors, phase.errors...)
+	r.connections = append(r.connections, phase.connections...)
+	r.values = append(r.values, phase.values...)
+	r.latencies = append(r.latencies, phase.latencies...)
+	r.queueWaits = append(r.queueWaits, phase.queueWaits...)
+	r.aggregate = phase.aggregate
+	r.appendInterruption(phase, offset)
+
+	release := r.release
+	r.release = func() {
+		if release != nil {
+			release()
+		}
+		if phase.release != nil {
+			phase.release()
+		}
+	}
+
+	results := phase.Results()
+	for i := range results {
+		results[i].Index += offset
+	}
+
+	r.mu.Lock()
+	r.progress = append(r.progress, results...)
+	r.mu.Unlock()
+}
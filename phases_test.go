@@ -0,0 +1,70 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSecondPhaseIsBuiltFromTheFirstPhaseResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			w.Write([]byte("secret"))
+			return
+		}
+		w.Write([]byte(req.URL.Path + " " + req.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 2, 2).
+		AddGet(server.URL+"/token", nil).
+		Then(func(results []Result) []*http.Request {
+			token, _ := ioutil.ReadAll(results[0].Response.Body)
+			var requests []*http.Request
+			for _, path := range []string{"/drivers", "/orders"} {
+				req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+				req.Header.Set("Authorization", string(token))
+				requests = append(requests, req)
+			}
+			return requests
+		})
+
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil, nil}, errs)
+	var bodies []string
+	for _, response := range responses[1:] {
+		body, _ := ioutil.ReadAll(response.Body)
+		bodies = append(bodies, string(body))
+	}
+	assert.Equal(t, []string{"/drivers secret", "/orders secret"}, bodies)
+	assert.Equal(t, 2, bulkRequest.Results()[2].Index)
+}
+
+func TestPhasesShareTheBulkTimeout(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, MockServerSlowResponseSleep+30*time.Millisecond)
+
+	querySlow := url.Values{}
+	querySlow.Set("kind", "slow")
+	slowURL := encodeURL(server.URL, "", querySlow)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet(slowURL, nil).
+		Then(func([]Result) []*http.Request {
+			req, _ := http.NewRequest(http.MethodGet, slowURL, nil)
+			return []*http.Request{req}
+		})
+
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, ErrRequestIgnored}, errs)
+}
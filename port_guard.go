@@ -0,0 +1,213 @@
+package meniscus
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//PortGuard throttles the connections a client dials before it runs out of ephemeral ports or file descriptors,
+//counting the connections it holds open and those it closed recently, whose ports stay in TIME_WAIT.
+//Dials past Ceiling wait for a connection to be closed or to leave TIME_WAIT, idle connections are closed
+//to make room, instead of bulks failing with a storm of EADDRNOTAVAIL or EMFILE errors.
+type PortGuard struct {
+	Ceiling   int             // connections held at once, open or in TIME_WAIT
+	WarnAt    float64         // fraction of Ceiling OnWarning is called at, defaults to 0.8
+	TimeWait  time.Duration   // how long closed connections hold their port, defaults to 60s
+	OnWarning func(PortUsage) // called when the usage crosses WarnAt and when dials start waiting, it must not block
+}
+
+//PortUsage reports the connections held by a client guarded by a PortGuard
+type PortUsage struct {
+	Open      int
+	TimeWait  int
+	Ceiling   int
+	Throttled bool // dials are waiting for room
+}
+
+//WithPortGuard throttles the connections dialed by the client according to guard.
+//Only an *http.Client using an *http.Transport can be guarded, other clients are left as they are.
+func WithPortGuard(guard PortGuard) ClientOption {
+	return func(cl *BulkClient) {
+		cl.portGuard = &guard
+	}
+}
+
+// portTracker counts the connections held by one client
+type portTracker struct {
+	guard     PortGuard
+	clock     Clock
+	closeIdle func()
+
+	mu        sync.Mutex
+	open      int
+	closed    []time.Time // in the order they were closed
+	changed   chan struct{}
+	warned    bool
+	throttled bool
+}
+
+func newPortTracker(guard PortGuard, clock Clock) *portTracker {
+	if guard.WarnAt <= 0 {
+		guard.WarnAt = 0.8
+	}
+
+	if guard.TimeWait <= 0 {
+		guard.TimeWait = 60 * time.Second
+	}
+
+	return &portTracker{guard: guard, clock: clock, closeIdle: func() {}, changed: make(chan struct{})}
+}
+
+// client returns a copy of client whose dials are tracked, or client itself when it cannot be guarded
+func (t *portTracker) client(client HTTPClient) HTTPClient {
+	httpClient, ok := client.(*http.Client)
+	if !ok {
+		return client
+	}
+
+	roundTripper := httpClient.Transport
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+
+	transport, ok := roundTripper.(*http.Transport)
+	if !ok {
+		return client
+	}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+
+	guarded := *httpClient
+	guardedTransport := transport.Clone()
+	guardedTransport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if err := t.acquire(ctx); err != nil {
+			return nil, err
+		}
+
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			t.release(false)
+			return nil, err
+		}
+
+		return &trackedConn{Conn: conn, tracker: t}, nil
+	}
+	guarded.Transport = guardedTransport
+	return &guarded
+}
+
+// acquire waits until a connection can be dialed without going over the ceiling
+func (t *portTracker) acquire(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		now := t.clock.Now()
+		t.prune(now)
+		if t.open+len(t.closed) < t.guard.Ceiling {
+			t.open++
+			t.throttled = false
+			warning, warn := t.check(false)
+			t.mu.Unlock()
+			if warn {
+				t.guard.OnWarning(warning)
+			}
+			return nil
+		}
+
+		changed := t.changed
+		wait := t.guard.TimeWait
+		if len(t.closed) > 0 {
+			wait = t.closed[0].Add(t.guard.TimeWait).Sub(now)
+		}
+		warning, warn := t.check(!t.throttled)
+		t.throttled = true
+		t.mu.Unlock()
+
+		if warn {
+			t.guard.OnWarning(warning)
+		}
+		t.closeIdle()
+
+		if err := t.wait(ctx, changed, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *portTracker) wait(ctx context.Context, changed <-chan struct{}, d time.Duration) error {
+	timer := t.clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-changed:
+	case <-timer.C():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// release gives back the slot of a connection, which holds its port for TimeWait when it was established
+func (t *portTracker) release(established bool) {
+	t.mu.Lock()
+	t.open--
+	if established {
+		t.closed = append(t.closed, t.clock.Now())
+	}
+	close(t.changed)
+	t.changed = make(chan struct{})
+	t.mu.Unlock()
+}
+
+// prune forgets the connections whose TIME_WAIT is over
+func (t *portTracker) prune(now time.Time) {
+	expired := 0
+	for expired < len(t.closed) && !now.Before(t.closed[expired].Add(t.guard.TimeWait)) {
+		expired++
+	}
+	t.closed = t.closed[expired:]
+}
+
+// check returns the warning to send, if the usage crossed WarnAt or throttled is set, rearming it below WarnAt
+func (t *portTracker) check(throttled bool) (PortUsage, bool) {
+	usage := PortUsage{Open: t.open, TimeWait: len(t.closed), Ceiling: t.guard.Ceiling, Throttled: throttled}
+	high := float64(usage.Open+usage.TimeWait) >= t.guard.WarnAt*float64(t.guard.Ceiling)
+	warn := t.guard.OnWarning != nil && (throttled || high && !t.warned)
+	t.warned = high
+	return usage, warn
+}
+
+//PortUsage returns the connections currently held by the client, it is empty unless the client was built WithPortGuard
+func (cl *BulkClient) PortUsage() PortUsage {
+	if cl.ports == nil {
+		return PortUsage{}
+	}
+
+	return cl.ports.usage()
+}
+
+func (t *portTracker) usage() PortUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(t.clock.Now())
+	return PortUsage{Open: t.open, TimeWait: len(t.closed), Ceiling: t.guard.Ceiling, Throttled: t.throttled}
+}
+
+type trackedConn struct {
+	net.Conn
+	tracker *portTracker
+	once    sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { c.tracker.release(true) })
+	return err
+}
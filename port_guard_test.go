@@ -0,0 +1,61 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPortGuardThrottlesDialsPastTheCeiling(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var warnings []PortUsage
+	guard := PortGuard{Ceiling: 2, TimeWait: 50 * time.Millisecond, OnWarning: func(usage PortUsage) {
+		mu.Lock()
+		warnings = append(warnings, usage)
+		mu.Unlock()
+	}}
+	client := NewBulkHTTPClient(&http.Client{Transport: &http.Transport{DisableKeepAlives: true}}, NonFailingTimeoutValue,
+		WithPortGuard(guard))
+
+	bulkRequest := NewBulkRequest(nil, 4, 4)
+	for i := 0; i < 4; i++ {
+		bulkRequest.AddGet(server.URL, nil)
+	}
+
+	start := time.Now()
+	_, errs := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil, nil, nil}, errs)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond, "the last dials waited for TIME_WAIT to pass")
+	assert.Equal(t, 2, client.PortUsage().Ceiling)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.NotEmpty(t, warnings) {
+		assert.Equal(t, 2, warnings[0].Open+warnings[0].TimeWait, "warned when reaching 80% of the ceiling")
+		assert.False(t, warnings[0].Throttled)
+	}
+
+	throttled := false
+	for _, warning := range warnings {
+		throttled = throttled || warning.Throttled
+	}
+	assert.True(t, throttled)
+}
+
+func TestPortGuardLeavesOtherClientsAlone(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue, WithPortGuard(PortGuard{Ceiling: 1}))
+
+	_, errs := client.Do(NewBulkRequest(nil, 1, 1).AddGet("http://example.com/a", nil).AddGet("http://example.com/b", nil))
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, PortUsage{Ceiling: 1}, client.PortUsage())
+}
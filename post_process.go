@@ -0,0 +1,81 @@
+package meniscus
+
+import "sync"
+
+//PostProcessor transforms the result of a request, e.g. decompressing, parsing or hashing the body.
+//It runs on its own worker pool while other requests of the bulk are still in flight.
+//Responses received before the bulk timeout are always post processed and returned,
+//even if post processing them finishes after the deadline.
+//A post processor replacing the response is responsible for closing the one it received.
+type PostProcessor func(Result) Result
+
+func (cl *BulkClient) postProcessRequestsManager(processedResponses <-chan roundTripParcel,
+	postProcessedResponses chan<- roundTripParcel,
+	stopProcessing <-chan struct{},
+	postProcessWg *sync.WaitGroup) {
+
+	for pWorker := 0; pWorker < cl.postProcessWorkers; pWorker++ {
+		postProcessWg.Add(1)
+		go cl.postProcessRequests(processedResponses, postProcessedResponses, stopProcessing, postProcessWg)
+	}
+}
+
+func (cl *BulkClient) postProcessRequests(processedResponses <-chan roundTripParcel,
+	postProcessedResponses chan<- roundTripParcel,
+	stopProcessing <-chan struct{},
+	postProcessWg *sync.WaitGroup) {
+
+	stopped := false
+	for resParcel := range processedResponses {
+		if stopped {
+			// keep draining the queue so that no response is left unclosed
+			closeParcel(resParcel)
+			continue
+		}
+
+		result := resParcel.withResult(cl.postProcessor(resParcel.result()))
+
+		select {
+		case postProcessedResponses <- result:
+		case <-stopProcessing:
+			closeParcel(result)
+			stopped = true
+		}
+	}
+
+	postProcessWg.Done()
+}
+
+func closeParcel(parcel roundTripParcel) {
+	if parcel.response != nil {
+		parcel.response.Body.Close()
+	}
+}
+
+// admissionGate counts the responses handed to the post processors until it is closed at the deadline
+type admissionGate struct {
+	mu       sync.Mutex
+	closed   bool
+	admitted int
+}
+
+func (g *admissionGate) admit() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return false
+	}
+
+	g.admitted++
+	return true
+}
+
+// close stops admitting responses and returns how many were admitted
+func (g *admissionGate) close() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	return g.admitted
+}
@@ -0,0 +1,108 @@
+package meniscus
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostProcessorRunsOnEveryResultBeforeDoReturns(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+
+	upperCase := func(result Result) Result {
+		if result.Err != nil {
+			return result
+		}
+
+		body, err := ioutil.ReadAll(result.Response.Body)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+
+		result.Value = strings.ToUpper(string(body))
+		return result
+	}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithPostProcessor(upperCase, 2))
+
+	queryFast := url.Values{}
+	queryFast.Set("kind", "fast")
+	querySlow := url.Values{}
+	querySlow.Set("kind", "slow")
+
+	reqOne, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", querySlow), nil)
+	require.NoError(t, err, "no errors")
+	reqTwo, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", queryFast), nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{reqOne, reqTwo}, 2, 2)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, []interface{}{"SLOW", "FAST"}, bulkRequest.Values())
+}
+
+func TestPostProcessorErrorsBecomeRequestErrors(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	failure := errors.New("unexpected status")
+
+	checkStatus := func(result Result) Result {
+		if result.Err == nil && result.Response.StatusCode != http.StatusOK {
+			result.Response.Body.Close()
+			result.Response = nil
+			result.Err = failure
+		}
+		return result
+	}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithPostProcessor(checkStatus, 0))
+
+	bulkRequest := newBulkClientWithNRequests(2, server.URL)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []*http.Response{nil, nil}, responses)
+	assert.Equal(t, []error{failure, failure}, errs)
+}
+
+func TestResponsesReceivedBeforeTheDeadlineAreKeptWhilePostProcessing(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+
+	slowDecode := func(result Result) Result {
+		time.Sleep(MockServerSlowResponseSleep)
+		result.Value = "decoded"
+		return result
+	}
+	client := NewBulkHTTPClient(httpclient, FailingTimeoutValue, WithPostProcessor(slowDecode, 1), WithPostProcessQueue(4))
+
+	queryFast := url.Values{}
+	queryFast.Set("kind", "fast")
+	var requests []*http.Request
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", queryFast), nil)
+		require.NoError(t, err, "no errors")
+		requests = append(requests, req)
+	}
+
+	bulkRequest := NewBulkRequest(requests, 2, 2)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, []interface{}{"decoded", "decoded"}, bulkRequest.Values())
+	for _, resp := range responses {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
@@ -0,0 +1,127 @@
+package meniscus
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+//ErrInvalidResultExport is returned by ReadPriorResults for an export written neither by Results.WriteJSONL
+//nor by Results.WriteCSV with at least the index, method, url and status columns
+var ErrInvalidResultExport = errors.New("invalid result export")
+
+//PriorResults are the requests that succeeded in an earlier run of a bulk, read from its export with ReadPriorResults
+type PriorResults struct {
+	succeeded map[int]string // method and url of the requests that succeeded, by index
+}
+
+//ReadPriorResults reads the export of the results of an earlier run of a bulk, written by Results.WriteJSONL
+//or by Results.WriteCSV. Requests with no error and a 2xx status succeeded.
+func ReadPriorResults(export io.Reader) (PriorResults, error) {
+	reader := bufio.NewReader(export)
+	first, err := reader.Peek(1)
+	if err == io.EOF {
+		return PriorResults{succeeded: map[int]string{}}, nil
+	}
+	if err != nil {
+		return PriorResults{}, err
+	}
+
+	if first[0] == '{' {
+		return readJSONLResults(reader)
+	}
+
+	return readCSVResults(reader)
+}
+
+func readJSONLResults(export io.Reader) (PriorResults, error) {
+	prior := PriorResults{succeeded: map[int]string{}}
+	decoder := json.NewDecoder(export)
+	for {
+		var record resultRecord
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return prior, nil
+		}
+		if err != nil {
+			return PriorResults{}, ErrInvalidResultExport
+		}
+
+		prior.record(record)
+	}
+}
+
+func readCSVResults(export io.Reader) (PriorResults, error) {
+	rows, err := csv.NewReader(export).ReadAll()
+	if err != nil || len(rows) == 0 {
+		return PriorResults{}, ErrInvalidResultExport
+	}
+
+	columns := map[string]int{}
+	for position, name := range rows[0] {
+		columns[name] = position
+	}
+	for _, required := range []string{IndexColumn.Name, MethodColumn.Name, URLColumn.Name, StatusColumn.Name} {
+		if _, ok := columns[required]; !ok {
+			return PriorResults{}, ErrInvalidResultExport
+		}
+	}
+
+	prior := PriorResults{succeeded: map[int]string{}}
+	for _, row := range rows[1:] {
+		index, err := strconv.Atoi(row[columns[IndexColumn.Name]])
+		if err != nil {
+			return PriorResults{}, ErrInvalidResultExport
+		}
+
+		record := resultRecord{Index: index, Method: row[columns[MethodColumn.Name]], URL: row[columns[URLColumn.Name]]}
+		record.Status, _ = strconv.Atoi(row[columns[StatusColumn.Name]])
+		if position, ok := columns[ErrorColumn.Name]; ok {
+			record.Error = row[position]
+		}
+
+		prior.record(record)
+	}
+
+	return prior, nil
+}
+
+// record remembers the request of record if it succeeded
+func (p PriorResults) record(record resultRecord) {
+	if len(record.Error) == 0 && record.Status >= 200 && record.Status < 300 {
+		p.succeeded[record.Index] = requestKey(record.Method, record.URL)
+	}
+}
+
+//Succeeded tells whether the request at index succeeded in the earlier run, with the same method and url
+func (p PriorResults) Succeeded(index int, request *http.Request) bool {
+	key, ok := p.succeeded[index]
+	return ok && request.URL != nil && key == requestKey(request.Method, request.URL.String())
+}
+
+func requestKey(method, url string) string {
+	return method + " " + url
+}
+
+//SkipSucceeded leaves out the requests that succeeded in the earlier run of the bulk prior was read from,
+//so that only the gaps are sent again, e.g. when re-running a large backfill. Requests are matched by index,
+//method and url, any change in the order of the requests has them sent again rather than wrongly skipped.
+//Like Filter, it keeps them as tombstones failing with ErrAlreadySucceeded, so results still line up.
+func (r *RoundTrip) SkipSucceeded(prior PriorResults) *RoundTrip {
+	for i, request := range r.requests {
+		if r.invalid[i] != nil || !prior.Succeeded(r.origin(i), request) {
+			continue
+		}
+
+		if r.invalid == nil {
+			r.invalid = map[int]error{}
+		}
+		r.invalid[i] = ErrAlreadySucceeded
+	}
+
+	return r
+}
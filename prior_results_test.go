@@ -0,0 +1,78 @@
+package meniscus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func backfill() *RoundTrip {
+	return NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/b", nil).
+		AddGet("http://example.com/c", nil)
+}
+
+func TestSkipSucceededSendsOnlyTheGapsOfAPriorRun(t *testing.T) {
+	for name, export := range map[string]func(Results, *bytes.Buffer) error{
+		"jsonl": func(results Results, out *bytes.Buffer) error { return results.WriteJSONL(out) },
+		"csv":   func(results Results, out *bytes.Buffer) error { return results.WriteCSV(out) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			first := backfill()
+			NewBulkHTTPClient(flakyClient("/b"), NonFailingTimeoutValue).Do(first)
+			first.CloseAllResponses()
+
+			var out bytes.Buffer
+			assert.NoError(t, export(first.Results(), &out))
+			prior, err := ReadPriorResults(&out)
+			assert.NoError(t, err)
+
+			calls := map[string]int{}
+			rerun := backfill().SkipSucceeded(prior)
+			_, errs := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue).Do(rerun)
+			defer rerun.CloseAllResponses()
+
+			assert.Equal(t, map[string]int{"/b": 1}, calls)
+			assert.Equal(t, []error{ErrAlreadySucceeded, nil, ErrAlreadySucceeded}, errs)
+			assert.Equal(t, Counts{Succeeded: 1, Skipped: 2}, rerun.Counts())
+		})
+	}
+}
+
+func TestSkipSucceededSendsRequestsThatMovedAgain(t *testing.T) {
+	prior, err := ReadPriorResults(strings.NewReader(`{"index":0,"method":"GET","url":"http://example.com/a","status":200,"duration_ms":1}
+{"index":1,"method":"GET","url":"http://example.com/b","status":503,"duration_ms":1}
+{"index":2,"method":"POST","url":"http://example.com/c","status":201,"duration_ms":1}
+`))
+	assert.NoError(t, err)
+
+	calls := map[string]int{}
+	rerun := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/b", nil).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/c", nil).
+		AddGet("http://example.com/d", nil).
+		SkipSucceeded(prior)
+	NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue).Do(rerun)
+	defer rerun.CloseAllResponses()
+
+	assert.Equal(t, map[string]int{"/a": 1, "/b": 1, "/c": 1, "/d": 1}, calls)
+	assert.True(t, prior.Succeeded(0, mustRequest(t, "http://example.com/a")))
+	post, _ := http.NewRequest(http.MethodPost, "http://example.com/c", nil)
+	assert.True(t, prior.Succeeded(2, post))
+}
+
+func TestReadPriorResultsRejectsOtherFiles(t *testing.T) {
+	_, err := ReadPriorResults(strings.NewReader("url,status\nhttp://example.com/a,200\n"))
+	assert.Equal(t, ErrInvalidResultExport, err)
+
+	_, err = ReadPriorResults(strings.NewReader(`{"index":"zero"}`))
+	assert.Equal(t, ErrInvalidResultExport, err)
+
+	prior, err := ReadPriorResults(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.False(t, prior.Succeeded(0, mustRequest(t, "http://example.com/a")))
+}
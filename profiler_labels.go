@@ -0,0 +1,45 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+)
+
+var lastBulkID uint64
+
+func nextBulkID() string {
+	return strconv.FormatUint(atomic.AddUint64(&lastBulkID, 1), 10)
+}
+
+// profile runs f with the goroutine labelled with the bulk, destination host and tags of req,
+// so that CPU and goroutine profiles can be broken down by bulk, destination and endpoint.
+// Tags are labelled with a meniscus_tag_ prefix, the route with meniscus_route when a normalizer is set
+// and the name of the client with meniscus_client when it has one.
+// f receives req with the labels added to its context.
+func (cl *BulkClient) profile(bulkID string, tags Tags, req *http.Request, f func(*http.Request)) {
+	if !cl.profilerLabels {
+		f(req)
+		return
+	}
+
+	host := ""
+	if req.URL != nil {
+		host = req.URL.Host
+	}
+
+	pairs := []string{"meniscus_bulk", bulkID, "meniscus_host", host}
+	if cl.routes != nil {
+		pairs = append(pairs, "meniscus_route", cl.routes(req.URL))
+	}
+	if len(cl.name) != 0 {
+		pairs = append(pairs, "meniscus_client", cl.name)
+	}
+
+	labels := pprof.Labels(append(pairs, tags.pairs("meniscus_tag_")...)...)
+	pprof.Do(req.Context(), labels, func(ctx context.Context) {
+		f(req.WithContext(ctx))
+	})
+}
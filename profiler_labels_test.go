@@ -0,0 +1,29 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestProfilerLabelsIdentifyTheBulkAndHost(t *testing.T) {
+	var bulkLabel, hostLabel string
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		bulkLabel, _ = pprof.Label(req.Context(), "meniscus_bulk")
+		hostLabel, _ = pprof.Label(req.Context(), "meniscus_host")
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithProfilerLabels())
+
+	bulkRequest := newBulkClientWithNRequests(1, "http://example.com").SetID("pricing")
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil}, errs)
+	assert.Equal(t, "pricing", bulkLabel)
+	assert.Equal(t, "example.com", hostLabel)
+}
+
+func TestBulkRequestsGetDistinctIDs(t *testing.T) {
+	assert.NotEqual(t, NewBulkRequest(nil, 1, 1).ID(), NewBulkRequest(nil, 1, 1).ID())
+}
@@ -0,0 +1,40 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestQueueWaitMeasuresTheTimeRequestsWaitForAFireWorker(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+
+	var seen []time.Duration
+	recordQueueWait := func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			seen = append(seen, ScopeOf(req.Context()).QueueWait)
+			return next.Do(req)
+		})
+	}
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue, WithMiddleware(recordQueueWait))
+
+	query := url.Values{}
+	query.Set("kind", "slow")
+	bulkRequest := NewBulkRequest(nil, 1, 1)
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", query), nil)
+		require.NoError(t, err, "no errors")
+		bulkRequest.AddRequest(req)
+	}
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	results := bulkRequest.Results()
+	assert.True(t, results[0].QueueWait < MockServerSlowResponseSleep)
+	assert.True(t, results[1].QueueWait >= MockServerSlowResponseSleep)
+	assert.Equal(t, []time.Duration{results[0].QueueWait, results[1].QueueWait}, seen)
+}
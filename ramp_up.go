@@ -0,0 +1,21 @@
+package meniscus
+
+import "time"
+
+//WithRampUp starts the fire workers of every bulk one after the other over window, from one worker when the bulk
+//is dispatched to all of them once window elapsed, so that a bulk does not hit a cold downstream with a burst of
+//requests. The window counts towards the bulk timeout, requests keep being sent by the workers already started.
+func WithRampUp(window time.Duration) ClientOption {
+	return func(cl *BulkClient) {
+		cl.rampUp = window
+	}
+}
+
+// rampDelay is how long the fire worker numbered worker out of workers waits before picking up requests
+func (cl *BulkClient) rampDelay(worker, workers int) time.Duration {
+	if cl.rampUp <= 0 || workers <= 1 {
+		return 0
+	}
+
+	return cl.rampUp * time.Duration(worker) / time.Duration(workers-1)
+}
@@ -0,0 +1,57 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRampUpStartsTheFireWorkersOverTheWindow(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	var inFlight int32
+	release := make(chan struct{})
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&inFlight, 1)
+		<-release
+		return syntheticResponse(req, http.StatusOK), nil
+	}), time.Minute, WithClock(clock), WithRampUp(2*time.Second))
+
+	bulkRequest := newBulkClientWithNRequests(6, "http://example.com")
+	bulkRequest.fireRequestsWorkers = 3
+	done := make(chan []error)
+	go func() {
+		_, errs := client.Do(bulkRequest)
+		done <- errs
+	}()
+
+	inFlightIs := func(expected int32) {
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&inFlight) == expected }, time.Second, time.Millisecond)
+	}
+
+	// the bulk timeout and the 2 workers still waiting for their turn
+	clock.BlockUntil(3)
+	inFlightIs(1)
+	clock.Advance(time.Second)
+	inFlightIs(2)
+	clock.Advance(time.Second)
+	inFlightIs(3)
+
+	close(release)
+	assert.Equal(t, make([]error, 6), <-done)
+	bulkRequest.CloseAllResponses()
+}
+
+func TestRampDelaySpreadsTheWorkersLinearly(t *testing.T) {
+	client := NewBulkHTTPClient(nil, time.Minute, WithRampUp(time.Second))
+
+	var delays []time.Duration
+	for worker := 0; worker < 5; worker++ {
+		delays = append(delays, client.rampDelay(worker, 5))
+	}
+
+	assert.Equal(t, []time.Duration{0, 250 * time.Millisecond, 500 * time.Millisecond, 750 * time.Millisecond, time.Second}, delays)
+	assert.Equal(t, time.Duration(0), client.rampDelay(0, 1))
+	assert.Equal(t, time.Duration(0), NewBulkHTTPClient(nil, time.Minute).rampDelay(4, 5))
+}
@@ -0,0 +1,27 @@
+package meniscus
+
+import (
+	"math/rand"
+	"sync"
+)
+
+//Random is the source of randomness used for faults and sampling, it can be seeded to make tests and simulations
+//reproducible. It returns a pseudo random number in [0, 1) and must be safe for concurrent use.
+type Random func() float64
+
+//GlobalRandom returns the Random backed by the shared source of math/rand
+func GlobalRandom() Random {
+	return rand.Float64
+}
+
+//SeededRandom returns a Random yielding the same sequence of numbers for the same seed.
+//Requests of a bulk are fired concurrently, so only bulks fired by a single worker draw the numbers in a fixed order.
+func SeededRandom(seed int64) Random {
+	var mu sync.Mutex
+	source := rand.New(rand.NewSource(seed))
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return source.Float64()
+	}
+}
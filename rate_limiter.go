@@ -0,0 +1,33 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+)
+
+//Limiter paces the requests of the clients sharing it, Wait blocks until a request may be sent.
+//A *rate.Limiter of golang.org/x/time/rate is a Limiter, so that services or components sharing one
+//can keep together within the quota of a common downstream.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+//WithLimiter has every attempt of the requests of the client wait for limiter before being sent.
+//A request whose wait fails, e.g. as its bulk timed out first, fails with the error of Wait.
+func WithLimiter(limiter Limiter) ClientOption {
+	return func(cl *BulkClient) {
+		cl.limiter = limiter
+	}
+}
+
+func limiterMiddleware(limiter Limiter) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if err := limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+
+			return next.Do(req)
+		})
+	}
+}
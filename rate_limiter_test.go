@@ -0,0 +1,49 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+// quotaLimiter lets through a fixed number of requests, failing the others
+type quotaLimiter struct {
+	mu   sync.Mutex
+	left int
+}
+
+func (l *quotaLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.left == 0 {
+		return errors.New("quota exceeded")
+	}
+	l.left--
+	return ctx.Err()
+}
+
+func TestLimiterIsSharedBetweenClients(t *testing.T) {
+	limiter := &quotaLimiter{left: 3}
+	calls := map[string]int{}
+	first := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue, WithLimiter(limiter))
+	second := NewBulkHTTPClient(countingClient(map[string]int{}), NonFailingTimeoutValue, WithLimiter(limiter))
+
+	firstBulk := newBulkClientWithNRequests(2, "http://example.com/first")
+	_, errs := first.Do(firstBulk)
+	defer firstBulk.CloseAllResponses()
+	assert.Equal(t, []error{nil, nil}, errs)
+
+	secondBulk := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/b", nil)
+	_, errs = second.Do(secondBulk)
+	defer secondBulk.CloseAllResponses()
+
+	assert.NoError(t, errs[0])
+	assert.Contains(t, errs[1].Error(), "quota exceeded")
+	assert.Equal(t, map[string]int{"/first": 2}, calls)
+	assert.Equal(t, 0, limiter.left)
+}
@@ -0,0 +1,28 @@
+package meniscus
+
+import (
+	"net/http"
+	"strings"
+)
+
+//SetRawHeader sets a header on req keeping the exact casing of name, for upstreams that do not treat header names
+//case insensitively. http.Header.Set would canonicalize it, X-LEGACY-id becoming X-Legacy-Id.
+//Headers with the same name in another casing are removed. HTTP/2 always sends header names in lower case.
+func SetRawHeader(req *http.Request, name string, values ...string) {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	for key := range req.Header {
+		if strings.EqualFold(key, name) {
+			delete(req.Header, key)
+		}
+	}
+
+	req.Header[name] = values
+}
+
+//RawHeader returns the values of the header set on req with exactly the casing of name
+func RawHeader(req *http.Request, name string) []string {
+	return req.Header[name]
+}
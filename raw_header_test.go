@@ -0,0 +1,53 @@
+package meniscus
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRawHeadersAreSentWithTheirExactCasing(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "no errors")
+	defer listener.Close()
+
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var received []string
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			received = append(received, strings.TrimSpace(line))
+		}
+		lines <- received
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+	}()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+listener.Addr().String(), nil)
+	req.Header.Set("X-Legacy-Id", "canonical")
+	SetRawHeader(req, "X-LEGACY-id", "42")
+
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	received := <-lines
+	assert.Equal(t, []error{nil}, errs)
+	assert.Contains(t, received, "X-LEGACY-id: 42")
+	assert.NotContains(t, received, "X-Legacy-Id: canonical")
+	assert.Equal(t, []string{"42"}, RawHeader(req, "X-LEGACY-id"))
+}
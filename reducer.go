@@ -0,0 +1,36 @@
+package meniscus
+
+//Reducer folds the result of a request into the aggregate of the bulk, acc is nil for the first result.
+//Results are folded one at a time in the order they arrive, and each response is closed once folded,
+//so the bulk only holds on to the aggregate, read with RoundTrip.Aggregate once Do returns.
+//The responses and values returned by Do and Results are then nil, their errors are kept.
+type Reducer func(acc interface{}, result Result) interface{}
+
+//Aggregate returns the value the client Reducer folded the results of the last Do into
+func (r *RoundTrip) Aggregate() interface{} {
+	return r.aggregate
+}
+
+// collect records a result received by the response mux, folding it when the client has a reducer,
+// and returns the parcel to keep, without its response and value once folded
+func (cl *BulkClient) collect(bulkRequest *RoundTrip, resParcel roundTripParcel) roundTripParcel {
+	bulkRequest.tracker.advance(resParcel.index, StateCollecting)
+	defer bulkRequest.tracker.advance(resParcel.index, StateDone)
+
+	result := resParcel.result()
+	bulkRequest.captureVariable(result)
+	result.Index = bulkRequest.origin(result.Index)
+	if cl.reducer == nil {
+		bulkRequest.recordProgress(result)
+		return resParcel
+	}
+
+	bulkRequest.aggregate = cl.reducer(bulkRequest.aggregate, result)
+	closeParcel(resParcel)
+	resParcel.response, resParcel.value = nil, nil
+
+	// the progress keeps nothing, the stream gets the outcome of the request without its response
+	result.Response, result.Value = nil, nil
+	bulkRequest.streamResult(result)
+	return resParcel
+}
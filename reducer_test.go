@@ -0,0 +1,37 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReducerFoldsResultsIntoTheAggregate(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(req.URL.Path))
+		return resp, nil
+	}), NonFailingTimeoutValue, WithReducer(func(acc interface{}, result Result) interface{} {
+		total, _ := acc.(int)
+		body, _ := ioutil.ReadAll(result.Response.Body)
+		return total + len(body) + result.Index
+	}))
+
+	bulkRequest := NewBulkRequest(nil, 3, 3)
+	for i := 0; i < 4; i++ {
+		bulkRequest.AddGet("http://example.com/"+strconv.Itoa(i), nil)
+	}
+
+	responses, errs := client.Do(bulkRequest)
+
+	assert.Equal(t, []error{nil, nil, nil, nil}, errs)
+	assert.Equal(t, 4*len("/0")+0+1+2+3, bulkRequest.Aggregate())
+	assert.Equal(t, make([]*http.Response, 4), responses)
+	for _, result := range bulkRequest.Results() {
+		assert.Nil(t, result.Response)
+		assert.Nil(t, result.Value)
+	}
+}
@@ -0,0 +1,121 @@
+package meniscus
+
+import (
+	"sync"
+	"time"
+)
+
+//Registry aggregates the outcome of the bulks of every client reporting into it, see WithRegistry,
+//giving a process wide view of clients created per call site. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	clients map[string]*ClientMetrics
+}
+
+//NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{clients: map[string]*ClientMetrics{}}
+}
+
+//ClientMetrics counts the requests of the bulks reported under one name, or under all names in RegistrySnapshot.Total
+type ClientMetrics struct {
+	Bulks     int
+	Requests  int
+	Succeeded int // a response was received, whatever its status
+	Failed    int
+	Ignored   int // left unanswered when their bulk timed out or was cancelled
+	Skipped   int // not sent as their condition did not hold, they were filtered out or already succeeded
+
+	StatusCodes map[int]int
+	Latency     time.Duration // summed over the requests that succeeded or failed
+}
+
+//MeanLatency is the average latency of the requests that succeeded or failed
+func (m ClientMetrics) MeanLatency() time.Duration {
+	sent := m.Succeeded + m.Failed
+	if sent == 0 {
+		return 0
+	}
+
+	return m.Latency / time.Duration(sent)
+}
+
+func (m *ClientMetrics) add(other ClientMetrics) {
+	m.Bulks += other.Bulks
+	m.Requests += other.Requests
+	m.Succeeded += other.Succeeded
+	m.Failed += other.Failed
+	m.Ignored += other.Ignored
+	m.Skipped += other.Skipped
+	m.Latency += other.Latency
+
+	if m.StatusCodes == nil {
+		m.StatusCodes = map[int]int{}
+	}
+	for code, count := range other.StatusCodes {
+		m.StatusCodes[code] += count
+	}
+}
+
+//RegistrySnapshot is a copy of the metrics of a registry, by the name the clients reported them under
+type RegistrySnapshot struct {
+	Clients map[string]ClientMetrics
+	Total   ClientMetrics
+}
+
+//Snapshot returns a copy of the metrics collected so far
+func (r *Registry) Snapshot() RegistrySnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := RegistrySnapshot{Clients: map[string]ClientMetrics{}, Total: ClientMetrics{StatusCodes: map[int]int{}}}
+	for name, metrics := range r.clients {
+		clientMetrics := ClientMetrics{}
+		clientMetrics.add(*metrics)
+		snapshot.Clients[name] = clientMetrics
+		snapshot.Total.add(*metrics)
+	}
+
+	return snapshot
+}
+
+//WithRegistry reports the outcome of every bulk of the client to registry under name,
+//or under the name of the client when empty, see WithName. Clients sharing a name are aggregated together.
+func WithRegistry(registry *Registry, name string) ClientOption {
+	return func(cl *BulkClient) {
+		cl.registry = registry
+		cl.registryName = name
+	}
+}
+
+// record adds the results of one bulk to the metrics of name
+func (r *Registry) record(name string, results []Result) {
+	bulk := ClientMetrics{Bulks: 1, Requests: len(results), StatusCodes: map[int]int{}}
+	for _, result := range results {
+		switch Code(result.Err) {
+		case "":
+			bulk.Succeeded++
+			bulk.Latency += result.Latency
+			if result.Response != nil {
+				bulk.StatusCodes[result.Response.StatusCode]++
+			}
+		case CodeIgnored:
+			bulk.Ignored++
+		case CodeSkipped:
+			bulk.Skipped++
+		default:
+			bulk.Failed++
+			bulk.Latency += result.Latency
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics, ok := r.clients[name]
+	if !ok {
+		metrics = &ClientMetrics{StatusCodes: map[int]int{}}
+		r.clients[name] = metrics
+	}
+	metrics.add(bulk)
+}
@@ -0,0 +1,48 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestRegistryAggregatesTheBulksOfManyClients(t *testing.T) {
+	registry := NewRegistry()
+	orders := NewBulkHTTPClient(flakyClient("/a"), NonFailingTimeoutValue, WithRegistry(registry, "orders"))
+	drivers := NewBulkHTTPClient(flakyClient(), NonFailingTimeoutValue, WithRegistry(registry, "drivers"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(client *BulkClient) {
+			defer wg.Done()
+			bulkRequest := NewBulkRequest(nil).
+				AddGet("http://example.com/a", nil).
+				AddGet("http://example.com/b", nil).
+				AddRequestIf(mustRequest(t, "http://example.com/c"), func() bool { return false })
+			client.Do(bulkRequest)
+			bulkRequest.CloseAllResponses()
+		}(NewBulkHTTPClient(flakyClient("/a"), NonFailingTimeoutValue, WithRegistry(registry, "orders")))
+	}
+	wg.Wait()
+
+	orders.Do(NewBulkRequest(nil).AddGet("http://example.com/a", nil))
+	drivers.Do(NewBulkRequest(nil).AddGet("http://example.com/d", nil))
+	drivers.Do(NewBulkRequest(nil))
+
+	snapshot := registry.Snapshot()
+	assert.Equal(t, 5, snapshot.Clients["orders"].Bulks)
+	assert.Equal(t, 13, snapshot.Clients["orders"].Requests)
+	assert.Equal(t, 4, snapshot.Clients["orders"].Succeeded)
+	assert.Equal(t, 5, snapshot.Clients["orders"].Failed)
+	assert.Equal(t, 4, snapshot.Clients["orders"].Skipped)
+	assert.Equal(t, map[int]int{200: 4}, snapshot.Clients["orders"].StatusCodes)
+
+	driverMetrics := snapshot.Clients["drivers"]
+	driverMetrics.Latency = 0
+	assert.Equal(t, ClientMetrics{Bulks: 1, Requests: 1, Succeeded: 1, StatusCodes: map[int]int{200: 1}},
+		driverMetrics, "bulks failing as a whole are not counted")
+	assert.Equal(t, 6, snapshot.Total.Bulks)
+	assert.Equal(t, 14, snapshot.Total.Requests)
+	assert.Equal(t, map[int]int{200: 5}, snapshot.Total.StatusCodes)
+}
@@ -0,0 +1,76 @@
+package meniscus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+//AddGet adds a GET request for url with the given headers, which may be nil.
+//Like the other builders it does not return an error: a request that cannot be built is not sent
+//and its error is returned by Do in its place.
+func (r *RoundTrip) AddGet(url string, headers http.Header) *RoundTrip {
+	return r.addBuilt(http.MethodGet, url, headers, nil)
+}
+
+//AddHead adds a HEAD request for url
+func (r *RoundTrip) AddHead(url string) *RoundTrip {
+	return r.addBuilt(http.MethodHead, url, nil, nil)
+}
+
+//AddDelete adds a DELETE request for url with the given headers, which may be nil
+func (r *RoundTrip) AddDelete(url string, headers http.Header) *RoundTrip {
+	return r.addBuilt(http.MethodDelete, url, headers, nil)
+}
+
+//AddPostJSON adds a POST request for url with body encoded as JSON
+func (r *RoundTrip) AddPostJSON(url string, body interface{}) *RoundTrip {
+	return r.addJSON(http.MethodPost, url, body)
+}
+
+//AddPutJSON adds a PUT request for url with body encoded as JSON
+func (r *RoundTrip) AddPutJSON(url string, body interface{}) *RoundTrip {
+	return r.addJSON(http.MethodPut, url, body)
+}
+
+//AddPatchJSON adds a PATCH request for url with body encoded as JSON
+func (r *RoundTrip) AddPatchJSON(url string, body interface{}) *RoundTrip {
+	return r.addJSON(http.MethodPatch, url, body)
+}
+
+func (r *RoundTrip) addJSON(method, url string, body interface{}) *RoundTrip {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return r.addInvalid(method, fmt.Errorf("error while encoding request body: %s", err))
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	return r.addBuilt(method, url, headers, bytes.NewReader(encoded))
+}
+
+func (r *RoundTrip) addBuilt(method, url string, headers http.Header, body io.Reader) *RoundTrip {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return r.addInvalid(method, fmt.Errorf("error while building request: %s", err))
+	}
+
+	for key, values := range headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+
+	return r.AddRequest(req)
+}
+
+// addInvalid adds a placeholder for a request that could not be built, failing with err
+func (r *RoundTrip) addInvalid(method string, err error) *RoundTrip {
+	if r.invalid == nil {
+		r.invalid = map[int]error{}
+	}
+
+	r.invalid[len(r.requests)] = err
+	return r.AddRequest(&http.Request{Method: method, URL: &url.URL{}, Header: http.Header{}})
+}
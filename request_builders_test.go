@@ -0,0 +1,56 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestBuilders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Write([]byte(req.Method + " " + req.Header.Get("Content-Type") + req.Header.Get("X-Trace") + " " + string(body)))
+	}))
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 4, 4).
+		AddGet(server.URL, http.Header{"X-Trace": {"abc"}}).
+		AddPostJSON(server.URL, map[string]int{"id": 42}).
+		AddDelete(server.URL, nil).
+		AddPutJSON(server.URL, []string{"a"})
+
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil, nil, nil}, errs)
+	var bodies []string
+	for _, response := range responses {
+		body, _ := ioutil.ReadAll(response.Body)
+		bodies = append(bodies, string(body))
+	}
+	assert.Equal(t, []string{"GET abc ", `POST application/json {"id":42}`, "DELETE  ", `PUT application/json ["a"]`}, bodies)
+}
+
+func TestRequestsTheBuildersCouldNotBuildFailWithoutBeingSent(t *testing.T) {
+	sent := 0
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddHead("http://example.com").
+		AddGet("http://exa mple.com", nil).
+		AddPostJSON("http://example.com", make(chan int))
+
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.NoError(t, errs[0])
+	assert.Contains(t, errs[1].Error(), "error while building request")
+	assert.Contains(t, errs[2].Error(), "error while encoding request body")
+	assert.Equal(t, 1, sent)
+}
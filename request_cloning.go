@@ -0,0 +1,44 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+)
+
+//RequestCloner copies a request for a new attempt at sending it, bound to ctx and with a body that can be read again
+type RequestCloner func(ctx context.Context, req *http.Request) (*http.Request, error)
+
+//CloneRequest is the default RequestCloner, it makes a deep copy of req with http.Request.Clone
+//and gives it a fresh body from GetBody. Requests with a body but no GetBody fail with ErrBodyNotReplayable.
+func CloneRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	cloned := req.Clone(ctx)
+	if req.Body == nil || req.Body == http.NoBody {
+		return cloned, nil
+	}
+
+	if req.GetBody == nil {
+		return cloned, ErrBodyNotReplayable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return cloned, err
+	}
+
+	cloned.Body = body
+	return cloned, nil
+}
+
+//CloneForAttempt copies req for a new attempt at sending it, bound to ctx, with the RequestCloner of the client
+//that fired req, see WithRequestCloner, or CloneRequest. Retrying and hedging middlewares must send such a copy
+//for every attempt rather than req itself, whose body an earlier attempt may have read and whose headers
+//the middlewares of an earlier attempt may have changed.
+func CloneForAttempt(ctx context.Context, req *http.Request) (*http.Request, error) {
+	if cloner, ok := req.Context().Value(clonerKey{}).(RequestCloner); ok {
+		return cloner(ctx, req)
+	}
+
+	return CloneRequest(ctx, req)
+}
+
+type clonerKey struct{}
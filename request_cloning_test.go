@@ -0,0 +1,83 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// retryOnce is a middleware sending a second attempt, copied with CloneForAttempt, at requests failing with an error
+func retryOnce(next HTTPClient) HTTPClient {
+	return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		attempt, err := CloneForAttempt(req.Context(), req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+
+		return next.Do(attempt)
+	})
+}
+
+func TestRetriedAttemptsGetAFreshBody(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/upload"), NonFailingTimeoutValue, WithMiddleware(retryOnce))
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddPostJSON("http://example.com/upload", []int{1, 2})
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil}, errs)
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, "[1,2]", string(body))
+}
+
+func TestRequestClonersAreUsedForEveryAttempt(t *testing.T) {
+	var cloned int32
+	cloner := func(ctx context.Context, req *http.Request) (*http.Request, error) {
+		atomic.AddInt32(&cloned, 1)
+		return CloneRequest(ctx, req)
+	}
+	client := NewBulkHTTPClient(flakyClient("/a"), NonFailingTimeoutValue, WithMiddleware(retryOnce), WithRequestCloner(cloner))
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://example.com/a", nil)
+	_, errs := client.Do(bulkRequest)
+
+	assert.Equal(t, []error{nil}, errs)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cloned))
+}
+
+func TestRunningABulkAgainResendsTheBodies(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient(), NonFailingTimeoutValue)
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddPostJSON("http://example.com/upload", "payload")
+
+	for run := 0; run < 2; run++ {
+		responses, errs := client.Do(bulkRequest)
+		assert.Equal(t, []error{nil}, errs)
+		body, _ := ioutil.ReadAll(responses[0].Body)
+		assert.Equal(t, `"payload"`, string(body))
+		bulkRequest.CloseAllResponses()
+	}
+}
+
+func TestCloneRequestCopiesHeadersAndNeedsGetBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("X-Attempt", "1")
+
+	cloned, err := CloneRequest(context.Background(), req)
+	cloned.Header.Set("X-Attempt", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", req.Header.Get("X-Attempt"))
+
+	req, _ = http.NewRequest(http.MethodPost, "http://example.com", ioutil.NopCloser(strings.NewReader("data")))
+	_, err = CloneRequest(context.Background(), req)
+	assert.True(t, errors.Is(err, ErrBodyNotReplayable))
+}
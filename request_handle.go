@@ -0,0 +1,51 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+//RequestHandle aborts a single request of a bulk without affecting the others
+type RequestHandle struct {
+	mu        sync.Mutex
+	index     int
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+//Index is the position of the request in the bulk, and of its response and error in the results of Do
+func (h *RequestHandle) Index() int {
+	return h.index
+}
+
+//Cancel aborts the request, whether it is still waiting to be fired or already in flight.
+//Its error becomes ErrRequestCancelled unless its response was already received.
+func (h *RequestHandle) Cancel() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cancelled = true
+	if h.cancel != nil {
+		h.cancel()
+	}
+}
+
+// bind derives the context the request is fired with from the bulk context,
+// a handle cancelled before Do starts gets an already cancelled context
+func (h *RequestHandle) bind(ctx context.Context) context.Context {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ctx, h.cancel = context.WithCancel(ctx)
+	if h.cancelled {
+		h.cancel()
+	}
+
+	return ctx
+}
+
+// requestCancelled tells whether req was aborted through its handle rather than by the bulk timing out
+func requestCancelled(ctx context.Context, req *http.Request) bool {
+	return req != nil && ctx.Err() == nil && req.Context().Err() == context.Canceled
+}
@@ -0,0 +1,63 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCancellingOneRequestLeavesTheOthersRunning(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue)
+
+	querySlow := url.Values{}
+	querySlow.Set("kind", "slow")
+
+	reqOne, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", querySlow), nil)
+	require.NoError(t, err, "no errors")
+
+	reqTwo, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", querySlow), nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{reqOne}, 10, 10)
+	handle := bulkRequest.AddCancellableRequest(reqTwo)
+	assert.Equal(t, 1, handle.Index())
+
+	time.AfterFunc(MockServerSlowResponseSleep/5, handle.Cancel)
+	responses, errs := client.Do(bulkRequest)
+
+	assert.NoError(t, errs[0])
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, "slow", string(body))
+
+	assert.Nil(t, responses[1])
+	assert.Equal(t, ErrRequestCancelled, errs[1])
+
+	bulkRequest.CloseAllResponses()
+}
+
+func TestRequestCancelledBeforeDoIsNeverSent(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue)
+
+	queryFast := url.Values{}
+	queryFast.Set("kind", "fast")
+
+	req, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", queryFast), nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest(nil, 1, 1)
+	bulkRequest.AddCancellableRequest(req).Cancel()
+	responses, errs := client.Do(bulkRequest)
+
+	assert.Equal(t, []*http.Response{nil}, responses)
+	assert.Equal(t, []error{ErrRequestCancelled}, errs)
+}
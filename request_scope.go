@@ -0,0 +1,109 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//RequestScope is shared by every attempt made at sending one request of a bulk, e.g. by retrying or hedging middlewares,
+//so that middlewares can relate the attempts to each other. It is also returned on the result of the request.
+type RequestScope struct {
+	BulkID    string
+	Index     int
+	Client    string        // the name of the client firing the request, see WithName
+	Tags      Tags          // the tags the request was added with, see AddTaggedRequest
+	Route     string        // the route of the request, set when the client has a RouteNormalizer
+	QueueWait time.Duration // how long the request waited for a fire worker, set before it is sent
+
+	mu      sync.Mutex
+	values  map[interface{}]interface{}
+	phase   string
+	picked  time.Time // when a fire worker picked the request up
+	retried bool
+}
+
+type scopeKey struct{}
+
+//ScopeOf returns the scope of the request of a bulk ctx belongs to, nil outside of a bulk
+func ScopeOf(ctx context.Context) *RequestScope {
+	scope, _ := ctx.Value(scopeKey{}).(*RequestScope)
+	return scope
+}
+
+//Load returns the value stored for key, nil when there is none
+func (s *RequestScope) Load(key interface{}) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key]
+}
+
+//Update atomically replaces the value stored for key with update applied to it, and returns the new value
+func (s *RequestScope) Update(key interface{}, update func(interface{}) interface{}) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.values == nil {
+		s.values = map[interface{}]interface{}{}
+	}
+
+	s.values[key] = update(s.values[key])
+	return s.values[key]
+}
+
+//MarkRetried records that the request is being sent again, retrying middlewares call it so that
+//the request counts as retried in RoundTrip.Counts
+func (s *RequestScope) MarkRetried() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retried = true
+}
+
+//Retried tells whether the request was sent again, see MarkRetried
+func (s *RequestScope) Retried() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retried
+}
+
+// pickUp records a fire worker starting on the request at now
+func (s *RequestScope) pickUp(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.picked = now
+	s.phase = PhaseConnecting
+}
+
+func (s *RequestScope) enter(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = phase
+}
+
+// progress returns the phase the request was in at deadline and how long it has been running at now,
+// requests picked up after deadline were still queued when it passed
+func (s *RequestScope) progress(now, deadline time.Time) (string, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.picked.IsZero() || s.picked.After(deadline) {
+		return PhaseQueued, 0
+	}
+
+	return s.phase, now.Sub(s.picked)
+}
+
+func withScope(ctx context.Context, bulkID string, index int, tags Tags) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &RequestScope{BulkID: bulkID, Index: index, Client: clientNameFromContext(ctx), Tags: tags})
+}
+
+// scopeOfRequest returns the scope req was fired with, nil for requests that were never fired
+func scopeOfRequest(req *http.Request) *RequestScope {
+	if req == nil {
+		return nil
+	}
+
+	return ScopeOf(req.Context())
+}
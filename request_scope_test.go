@@ -0,0 +1,61 @@
+package meniscus
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+type requestScopeTestKey struct{}
+
+func TestEveryAttemptAtARequestSharesItsScope(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+
+	var parentValues []interface{}
+	countAttempts := func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			parentValues = append(parentValues, req.Context().Value(requestScopeTestKey{}))
+			for attempt := 0; attempt < 2; attempt++ {
+				ScopeOf(req.Context()).Update("attempts", func(attempts interface{}) interface{} {
+					count, _ := attempts.(int)
+					return count + 1
+				})
+			}
+			return next.Do(req)
+		})
+	}
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue, WithMiddleware(countAttempts))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err, "no errors")
+	ctx := context.WithValue(context.Background(), requestScopeTestKey{}, "parent")
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1).SetID("scoped").SetContext(ctx)
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	scope := bulkRequest.Results()[0].Scope
+	require.NotNil(t, scope)
+	assert.Equal(t, "scoped", scope.BulkID)
+	assert.Equal(t, 0, scope.Index)
+	assert.Equal(t, 2, scope.Load("attempts"))
+	assert.Equal(t, []interface{}{"parent"}, parentValues)
+	assert.Nil(t, ScopeOf(context.Background()))
+}
+
+func TestCancellingTheBulkContextAbortsTheBulk(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL+"?kind=slow", nil)
+	require.NoError(t, err, "no errors")
+
+	_, errs := client.Do(NewBulkRequest([]*http.Request{req}, 1, 1).SetContext(ctx))
+
+	assert.Equal(t, []error{ErrRequestIgnored}, errs)
+}
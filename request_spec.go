@@ -0,0 +1,75 @@
+package meniscus
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+)
+
+//MaxSpecRetries caps the Retries of a RequestSpec
+const MaxSpecRetries = 10
+
+//RequestSpec describes a request without building an *http.Request, e.g. for jobs reading their requests from configuration
+type RequestSpec struct {
+	Method  string // defaults to GET
+	URL     string
+	Headers map[string]string
+	Body    []byte
+	Timeout time.Duration // bounds every attempt, including reading the response body, the bulk timeout always applies
+	Retries int           // attempts made again as with WithRetry, while the bulk has time left, up to MaxSpecRetries
+	Tags    Tags
+}
+
+//AddSpec adds the request described by spec to the bulk. Like the other builders it does not return an error:
+//a spec that cannot be built is not sent and its error is returned by Do in its place.
+//Attempts cut short by the Timeout of the spec fail like those cut short by StagedTimeouts.Total,
+//and a request failing its last retry fails with a *RetriesExhaustedError. Retries wait for the backoff of the client,
+//or for an exponential backoff with jitter starting at 50ms when it was built without WithRetry.
+func (r *RoundTrip) AddSpec(spec RequestSpec) *RoundTrip {
+	method := spec.Method
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if spec.Body != nil {
+		body = bytes.NewReader(spec.Body)
+	}
+
+	headers := http.Header{}
+	for key, value := range spec.Headers {
+		headers.Set(key, value)
+	}
+
+	index := len(r.requests)
+	r.addBuilt(method, spec.URL, headers, body)
+
+	if spec.Tags != nil {
+		if r.tags == nil {
+			r.tags = map[int]Tags{}
+		}
+		r.tags[index] = spec.Tags
+	}
+
+	if spec.Timeout > 0 || spec.Retries > 0 {
+		if r.policies == nil {
+			r.policies = map[int]requestPolicy{}
+		}
+		retries := spec.Retries
+		if retries > MaxSpecRetries {
+			retries = MaxSpecRetries
+		}
+		r.policies[index] = requestPolicy{timeout: spec.Timeout, retries: retries}
+	}
+
+	return r
+}
+
+// requestPolicy is the timeout and retries of a request added with AddSpec, applied by retryMiddleware
+type requestPolicy struct {
+	timeout time.Duration
+	retries int
+}
+
+type policyKey struct{}
@@ -0,0 +1,92 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAddSpecBuildsTheRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Write([]byte(req.Method + " " + req.Header.Get("X-Trace") + " " + string(body)))
+	}))
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{}, NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil).
+		AddSpec(RequestSpec{URL: server.URL, Headers: map[string]string{"x-trace": "abc"}, Tags: Tags{"job": "sync"}}).
+		AddSpec(RequestSpec{Method: http.MethodPut, URL: server.URL, Body: []byte("payload")}).
+		AddSpec(RequestSpec{URL: "://invalid"})
+
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Nil(t, errs[0])
+	assert.Nil(t, errs[1])
+	assert.EqualError(t, errs[2], `error while building request: parse "://invalid": missing protocol scheme`)
+	var bodies []string
+	for _, response := range responses[:2] {
+		body, _ := ioutil.ReadAll(response.Body)
+		bodies = append(bodies, string(body))
+	}
+	assert.Equal(t, []string{"GET abc ", "PUT  payload"}, bodies)
+	assert.Equal(t, Tags{"job": "sync"}, bulkRequest.Results()[0].Tags)
+}
+
+func TestAddSpecRetriesFailedAttemptsWithAFreshBody(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		if req.Body != nil {
+			body, _ = ioutil.ReadAll(req.Body)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, req.URL.Path+" "+string(body))
+		if len(bodies) < 3 {
+			return syntheticResponse(req, http.StatusBadGateway), nil
+		}
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil).
+		AddSpec(RequestSpec{Method: http.MethodPost, URL: "http://example.com/retried", Body: []byte("x"), Retries: 2})
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil}, errs)
+	assert.Equal(t, http.StatusOK, responses[0].StatusCode)
+	assert.Equal(t, []string{"/retried x", "/retried x", "/retried x"}, bodies)
+
+	bodies = nil
+	bulkRequest = NewBulkRequest(nil).AddSpec(RequestSpec{URL: "http://example.com/once"})
+	responses, _ = client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+	assert.Equal(t, http.StatusBadGateway, responses[0].StatusCode, "specs without retries are sent once")
+	assert.Equal(t, []string{"/once "}, bodies)
+}
+
+func TestAddSpecTimesOutEveryAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-req.Context().Done():
+		}
+	}))
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{}, NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil).AddSpec(RequestSpec{URL: server.URL, Timeout: 20 * time.Millisecond, Retries: 1})
+	start := time.Now()
+	_, errs := client.Do(bulkRequest)
+
+	assert.EqualError(t, errs[0], "http client error: giving up after 2 attempts: "+ErrRequestTimeout.Error())
+	assert.Equal(t, CodeTimeout, Code(errs[0]))
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+}
@@ -0,0 +1,465 @@
+package meniscus
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const staleWarning = `110 - "Response is Stale"`
+
+//DefaultCacheMaxBytes is the bound on the bodies a ResponseCache keeps unless changed with SetMaxBytes
+const DefaultCacheMaxBytes = 64 << 20
+
+//CacheStats reports on the use of a ResponseCache
+type CacheStats struct {
+	Hits      uint64 // including stale hits
+	Stale     uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+//HitRate is the fraction of lookups served from the cache
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+//ResponseCache keeps successful GET responses across Do calls, for ttl and up to maxEntries and DefaultCacheMaxBytes
+//of bodies, evicting the least recently used entries first. It can be shared between clients.
+//Responses are kept apart by the request headers they Vary on, and are not kept when marked no-store or private,
+//or when the request carries an Authorization header unless they are marked public.
+type ResponseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	staleFor   time.Duration
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	clock      Clock
+	headers    *DateHeaders
+	entries    map[string]*list.Element
+	varies     map[string][]string // the request headers the responses of a URL vary on, by URL
+	recency    *list.List          // most recently used at the front
+	refreshing map[string]bool
+	stats      CacheStats
+}
+
+type cacheEntry struct {
+	key        string
+	url        string
+	statusCode int
+	status     string
+	header     http.Header
+	body       []byte
+	public     bool // may be served to requests carrying an Authorization header
+	storedAt   time.Time
+	expiresAt  time.Time // from the response headers, zero to use the cache ttl
+}
+
+//NewResponseCache returns an empty cache, maxEntries of 0 means no bound
+func NewResponseCache(maxEntries int, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   DefaultCacheMaxBytes,
+		entries:    map[string]*list.Element{},
+		varies:     map[string][]string{},
+		recency:    list.New(),
+		refreshing: map[string]bool{},
+	}
+}
+
+//SetMaxBytes bounds the size of the bodies kept by the cache, 0 means no bound.
+//Responses larger than maxBytes are never kept.
+func (c *ResponseCache) SetMaxBytes(maxBytes int64) *ResponseCache {
+	c.mu.Lock()
+	c.maxBytes = maxBytes
+	c.evict()
+	c.mu.Unlock()
+	return c
+}
+
+//SetStaleWhileRevalidate serves entries expired for less than window as they are, flagged as stale (see IsStale),
+//while they are refreshed in the background. A refresh taking longer than window is abandoned.
+func (c *ResponseCache) SetStaleWhileRevalidate(window time.Duration) *ResponseCache {
+	c.mu.Lock()
+	c.staleFor = window
+	c.mu.Unlock()
+	return c
+}
+
+//SetHeaderExpiry keeps responses for as long as their Cache-Control max-age or Expires headers allow,
+//read through headers, falling back to the cache ttl for responses without either.
+//Responses already expired when received, e.g. marked no-store, are not kept.
+//Expiry is measured on the clock of the cache, that of its client, rather than headers.Clock.
+func (c *ResponseCache) SetHeaderExpiry(headers DateHeaders) *ResponseCache {
+	c.mu.Lock()
+	c.headers = &headers
+	c.mu.Unlock()
+	return c
+}
+
+//IsStale tells whether resp was served from a ResponseCache past its ttl
+func IsStale(resp *http.Response) bool {
+	return resp != nil && resp.Header.Get("Warning") == staleWarning
+}
+
+//Stats returns the hits, misses and evictions so far and the number of entries
+func (c *ResponseCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.recency.Len()
+	return stats
+}
+
+//Invalidate drops the entries whose URL matches pattern, where * matches any run of characters,
+//e.g. https://example.com/drivers/*, and returns how many were dropped
+func (c *ResponseCache) Invalidate(pattern string) int {
+	matcher := globPattern(pattern)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dropped := 0
+	for _, element := range c.entries {
+		if matcher.MatchString(element.Value.(*cacheEntry).url) {
+			c.remove(element)
+			dropped++
+		}
+	}
+
+	return dropped
+}
+
+//Middleware serves GET requests from the cache and stores their successful responses
+func (c *ResponseCache) Middleware() Middleware {
+	return c.middleware(nil, spillConfig{})
+}
+
+// middleware reads the bodies to cache like the client does, through its buffer pool and spilling the large ones,
+// which are then not cached
+func (c *ResponseCache) middleware(pool *bufferPool, spill spillConfig) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet {
+				return next.Do(req)
+			}
+
+			key := c.key(req)
+			entry, stale, ok := c.lookup(key, authorized(req))
+			if ok && stale {
+				go c.refresh(next, req, key, pool, spill)
+			}
+
+			if ok {
+				return entry.response(req, stale), nil
+			}
+
+			return c.fetch(next, req, pool, spill)
+		})
+	}
+}
+
+// fetch sends req and stores its response when successful and cacheable
+func (c *ResponseCache) fetch(next HTTPClient, req *http.Request, pool *bufferPool, spill spillConfig) (*http.Response, error) {
+	resp, err := next.Do(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, err
+	}
+
+	directives := cacheDirectives(resp.Header.Get("Cache-Control"))
+	_, public := directives["public"]
+	if !cacheable(directives) || (authorized(req) && !public) {
+		return resp, nil
+	}
+
+	vary, ok := varyingHeaders(resp.Header)
+	if !ok {
+		return resp, nil
+	}
+
+	var expiresAt time.Time
+	if headers := c.dateHeaders(); headers != nil {
+		now := c.now()
+		expiry, ok := headers.expiry(resp, now)
+		if ok && !expiry.After(now) {
+			return resp, nil
+		}
+		if ok {
+			expiresAt = expiry
+		}
+	}
+
+	maxBytes := c.bodyBound()
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return resp, nil
+	}
+
+	body, err := readBody(resp, pool, spill)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = body
+	if cached, ok := bufferedBytes(body); ok && (maxBytes <= 0 || int64(len(cached)) <= maxBytes) {
+		c.store(&cacheEntry{
+			key:        cacheKey(req, vary),
+			url:        req.URL.String(),
+			statusCode: resp.StatusCode,
+			status:     resp.Status,
+			header:     cloneHeader(resp.Header),
+			body:       cached,
+			public:     public,
+			expiresAt:  expiresAt,
+		}, vary)
+	}
+
+	return resp, nil
+}
+
+// refresh fetches a stale entry again, detached from the bulk that found it stale
+func (c *ResponseCache) refresh(next HTTPClient, req *http.Request, key string, pool *bufferPool, spill spillConfig) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	window := c.staleFor
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), window)
+	defer cancel()
+
+	if attempt, err := CloneForAttempt(ctx, req); err == nil {
+		if resp, err := c.fetch(next, attempt, pool, spill); err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	c.mu.Lock()
+	delete(c.refreshing, key)
+	c.mu.Unlock()
+}
+
+// lookup returns the entry for key, and whether it is stale, unless it is missing, expired
+// or not public while the request is authorized
+func (c *ResponseCache) lookup(key string, authorized bool) (*cacheEntry, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok || (authorized && !element.Value.(*cacheEntry).public) {
+		c.stats.Misses++
+		return nil, false, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	now := c.now()
+	freshUntil, expires := entry.freshUntil(c.ttl)
+	if expires && !now.Before(freshUntil.Add(c.staleFor)) {
+		c.stats.Misses++
+		return nil, false, false
+	}
+
+	stale := expires && !now.Before(freshUntil)
+	c.stats.Hits++
+	if stale {
+		c.stats.Stale++
+	}
+	c.recency.MoveToFront(element)
+	return entry, stale, true
+}
+
+func (c *ResponseCache) store(entry *cacheEntry, vary []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.storedAt = c.now()
+	if element, ok := c.entries[entry.key]; ok {
+		c.bytes -= int64(len(element.Value.(*cacheEntry).body))
+		element.Value = entry
+		c.recency.MoveToFront(element)
+	} else {
+		c.entries[entry.key] = c.recency.PushFront(entry)
+	}
+
+	c.bytes += int64(len(entry.body))
+	if len(vary) != 0 {
+		c.varies[entry.url] = vary
+	} else {
+		delete(c.varies, entry.url)
+	}
+	c.evict()
+}
+
+// evict drops the least recently used entries until the cache is within its bounds
+func (c *ResponseCache) evict() {
+	for c.recency.Len() > 0 && ((c.maxEntries > 0 && c.recency.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.remove(c.recency.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove drops the entry of element, along with the headers its URL varies on,
+// its other variants being found again once a response of the URL was stored anew
+func (c *ResponseCache) remove(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	c.recency.Remove(element)
+	delete(c.entries, entry.key)
+	delete(c.varies, entry.url)
+	c.bytes -= int64(len(entry.body))
+}
+
+// key returns the key the response to req would be cached under, after the headers its URL last varied on
+func (c *ResponseCache) key(req *http.Request) string {
+	c.mu.Lock()
+	vary := c.varies[req.URL.String()]
+	c.mu.Unlock()
+
+	return cacheKey(req, vary)
+}
+
+// cacheKey is the URL of req followed by the values of the headers in vary
+func cacheKey(req *http.Request, vary []string) string {
+	key := req.URL.String()
+	for _, name := range vary {
+		key += "\x00" + name + ":" + strings.Join(req.Header[name], ",")
+	}
+
+	return key
+}
+
+func (c *ResponseCache) bodyBound() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maxBytes
+}
+
+// cacheable tells whether the Cache-Control directives of a response allow keeping it in a shared cache,
+// no-store and private being honoured with or without SetHeaderExpiry
+func cacheable(directives map[string]string) bool {
+	_, noStore := directives["no-store"]
+	_, private := directives["private"]
+	return !noStore && !private
+}
+
+func authorized(req *http.Request) bool {
+	return len(req.Header.Get("Authorization")) != 0
+}
+
+// varyingHeaders returns the sorted canonical names of the request headers resp varies on,
+// and false when it varies on everything and cannot be cached
+func varyingHeaders(header http.Header) ([]string, bool) {
+	var names []string
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if len(name) != 0 {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, true
+}
+
+// bufferedBytes returns a copy of a body read in memory by readBody, false when it was spilled to a file
+func bufferedBytes(body io.ReadCloser) ([]byte, bool) {
+	var reader *bytes.Reader
+	switch body := body.(type) {
+	case memoryBody:
+		reader = body.Reader
+	case *pooledBody:
+		reader = body.reader
+	default:
+		return nil, false
+	}
+
+	copied := make([]byte, reader.Size())
+	reader.ReadAt(copied, 0)
+	return copied, true
+}
+
+func (c *ResponseCache) dateHeaders() *DateHeaders {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.headers
+}
+
+func (c *ResponseCache) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+
+	return c.clock.Now()
+}
+
+// freshUntil returns when the entry goes stale, and false when it never does
+func (e *cacheEntry) freshUntil(ttl time.Duration) (time.Time, bool) {
+	if !e.expiresAt.IsZero() {
+		return e.expiresAt, true
+	}
+
+	if ttl > 0 {
+		return e.storedAt.Add(ttl), true
+	}
+
+	return time.Time{}, false
+}
+
+func (e *cacheEntry) response(req *http.Request, stale bool) *http.Response {
+	header := cloneHeader(e.header)
+	if stale {
+		header.Set("Warning", staleWarning)
+	}
+
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          memoryBody{bytes.NewReader(e.body)},
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+//Invalidate drops the cached responses whose URL matches pattern, see ResponseCache.Invalidate
+func (cl *BulkClient) Invalidate(pattern string) int {
+	if cl.cache == nil {
+		return 0
+	}
+
+	return cl.cache.Invalidate(pattern)
+}
+
+func globPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
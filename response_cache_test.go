@@ -0,0 +1,203 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func countingClient(calls map[string]int) HTTPClientFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		calls[req.URL.Path]++
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(req.URL.Path))
+		return resp, nil
+	}
+}
+
+func fetch(client *BulkClient, urls ...string) []string {
+	bulkRequest := NewBulkRequest(nil, 1, 1)
+	for _, url := range urls {
+		bulkRequest.AddGet(url, nil)
+	}
+
+	responses, _ := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	var bodies []string
+	for _, response := range responses {
+		body, _ := ioutil.ReadAll(response.Body)
+		bodies = append(bodies, string(body))
+	}
+
+	return bodies
+}
+
+func TestResponseCacheServesRepeatedRequestsUntilTheyExpire(t *testing.T) {
+	calls := map[string]int{}
+	clock := NewFakeClock(time.Unix(0, 0))
+	cache := NewResponseCache(10, time.Minute)
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue, WithClock(clock), WithResponseCache(cache))
+
+	assert.Equal(t, []string{"/a"}, fetch(client, "http://example.com/a"))
+	assert.Equal(t, []string{"/a"}, fetch(client, "http://example.com/a"))
+	clock.Advance(time.Minute)
+	assert.Equal(t, []string{"/a"}, fetch(client, "http://example.com/a"))
+
+	assert.Equal(t, 2, calls["/a"])
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 2, Entries: 1}, cache.Stats())
+}
+
+func TestResponseCacheEvictsTheLeastRecentlyUsed(t *testing.T) {
+	calls := map[string]int{}
+	cache := NewResponseCache(2, time.Minute)
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue, WithResponseCache(cache))
+
+	fetch(client, "http://example.com/a", "http://example.com/b")
+	fetch(client, "http://example.com/a", "http://example.com/c")
+	fetch(client, "http://example.com/a", "http://example.com/b")
+
+	assert.Equal(t, map[string]int{"/a": 1, "/b": 2, "/c": 1}, calls)
+	assert.Equal(t, uint64(2), cache.Stats().Evictions)
+}
+
+func TestInvalidateDropsMatchingEntries(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue, WithResponseCache(NewResponseCache(0, time.Minute)))
+
+	fetch(client, "http://example.com/drivers/1", "http://example.com/drivers/2", "http://example.com/orders/1")
+
+	assert.Equal(t, 2, client.Invalidate("http://example.com/drivers/*"))
+	fetch(client, "http://example.com/drivers/1", "http://example.com/orders/1")
+	assert.Equal(t, map[string]int{"/drivers/1": 2, "/drivers/2": 1, "/orders/1": 1}, calls)
+}
+
+func TestStaleEntriesAreServedWhileRefreshedInTheBackground(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	backend := countingClient(calls)
+	clock := NewFakeClock(time.Unix(0, 0))
+	cache := NewResponseCache(10, time.Minute).SetStaleWhileRevalidate(time.Second)
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return backend(req)
+	}), NonFailingTimeoutValue, WithClock(clock), WithResponseCache(cache))
+	stale := func() bool {
+		bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://example.com/a", nil)
+		responses, _ := client.Do(bulkRequest)
+		defer bulkRequest.CloseAllResponses()
+		return IsStale(responses[0])
+	}
+
+	assert.False(t, stale())
+	clock.Advance(time.Minute)
+	assert.True(t, stale())
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		refreshed := calls["/a"] == 2
+		mu.Unlock()
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return refreshed && len(cache.refreshing) == 0
+	}, time.Second, time.Millisecond)
+
+	assert.False(t, stale())
+	assert.Equal(t, uint64(1), cache.Stats().Stale)
+}
+
+func headerClient(calls map[string]int, header http.Header) HTTPClientFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		calls[req.URL.Path]++
+		resp := syntheticResponse(req, http.StatusOK)
+		for name, values := range header {
+			resp.Header[name] = values
+		}
+		resp.Body = ioutil.NopCloser(strings.NewReader(req.URL.Path + req.Header.Get("Accept-Language")))
+		return resp, nil
+	}
+}
+
+func fetchWithHeader(client *BulkClient, url string, name string, value string) string {
+	header := http.Header{}
+	header.Set(name, value)
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet(url, header)
+	responses, _ := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	return string(body)
+}
+
+func TestResponseCacheKeepsTheVariantsOfVaryingResponsesApart(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(headerClient(calls, http.Header{"Vary": {"accept-language"}}), NonFailingTimeoutValue,
+		WithResponseCache(NewResponseCache(0, time.Minute)))
+
+	assert.Equal(t, "/aen", fetchWithHeader(client, "http://example.com/a", "Accept-Language", "en"))
+	assert.Equal(t, "/aid", fetchWithHeader(client, "http://example.com/a", "Accept-Language", "id"))
+	assert.Equal(t, "/aen", fetchWithHeader(client, "http://example.com/a", "Accept-Language", "en"))
+	assert.Equal(t, 2, calls["/a"])
+
+	everything := NewBulkHTTPClient(headerClient(calls, http.Header{"Vary": {"*"}}), NonFailingTimeoutValue,
+		WithResponseCache(NewResponseCache(0, time.Minute)))
+	fetch(everything, "http://example.com/b")
+	fetch(everything, "http://example.com/b")
+	assert.Equal(t, 2, calls["/b"])
+}
+
+func TestResponseCacheHonoursPrivateAndNoStoreWithoutHeaderExpiry(t *testing.T) {
+	for _, cacheControl := range []string{"private, max-age=60", "no-store"} {
+		calls := map[string]int{}
+		client := NewBulkHTTPClient(headerClient(calls, http.Header{"Cache-Control": {cacheControl}}), NonFailingTimeoutValue,
+			WithResponseCache(NewResponseCache(0, time.Minute)))
+
+		fetch(client, "http://example.com/a")
+		fetch(client, "http://example.com/a")
+		assert.Equal(t, 2, calls["/a"], cacheControl)
+	}
+}
+
+func TestResponseCacheOnlySharesPublicResponsesOfAuthorizedRequests(t *testing.T) {
+	calls := map[string]int{}
+	private := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue, WithResponseCache(NewResponseCache(0, time.Minute)))
+
+	fetch(private, "http://example.com/a")
+	fetchWithHeader(private, "http://example.com/a", "Authorization", "Bearer driver")
+	fetchWithHeader(private, "http://example.com/a", "Authorization", "Bearer driver")
+	assert.Equal(t, 3, calls["/a"])
+
+	public := NewBulkHTTPClient(headerClient(calls, http.Header{"Cache-Control": {"public"}}), NonFailingTimeoutValue,
+		WithResponseCache(NewResponseCache(0, time.Minute)))
+	fetchWithHeader(public, "http://example.com/b", "Authorization", "Bearer driver")
+	fetchWithHeader(public, "http://example.com/b", "Authorization", "Bearer driver")
+	assert.Equal(t, 1, calls["/b"])
+}
+
+func TestResponseCacheIsBoundedByTheSizeOfItsBodies(t *testing.T) {
+	calls := map[string]int{}
+	cache := NewResponseCache(0, time.Minute).SetMaxBytes(int64(len("/a") + len("/b")))
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue, WithResponseCache(cache))
+
+	fetch(client, "http://example.com/a", "http://example.com/b", "http://example.com/c")
+	fetch(client, "http://example.com/c", "http://example.com/long/enough/to/be/skipped")
+	fetch(client, "http://example.com/long/enough/to/be/skipped")
+
+	assert.Equal(t, map[string]int{"/a": 1, "/b": 1, "/c": 1, "/long/enough/to/be/skipped": 2}, calls)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 5, Evictions: 1, Entries: 2}, cache.Stats())
+}
+
+func TestResponseCacheReadsBodiesThroughTheBufferPool(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue, WithBufferPool(),
+		WithResponseCache(NewResponseCache(0, time.Minute)))
+
+	assert.Equal(t, []string{"/a"}, fetch(client, "http://example.com/a"))
+	assert.Equal(t, []string{"/a"}, fetch(client, "http://example.com/a"))
+	assert.Equal(t, 1, calls["/a"])
+	assert.True(t, client.BufferPoolStats().Gets > 2)
+}
@@ -0,0 +1,37 @@
+package meniscus
+
+import "net/http"
+
+//ResponseHandler consumes the response of a request on the response workers, e.g. decoding its body into a struct.
+//A non nil error fails the request with that error.
+type ResponseHandler func(*http.Response) error
+
+//AddRequestWithHandler adds request to the bulk, its response being handed to handle on the response workers.
+//The body is closed once handle returns, the response returned by Do keeps its status and headers with an empty body,
+//so it does not need to be closed by CloseAllResponses. Requests that fail are not handed to handle.
+func (r *RoundTrip) AddRequestWithHandler(request *http.Request, handle ResponseHandler) *RoundTrip {
+	if r.handlers == nil {
+		r.handlers = map[int]ResponseHandler{}
+	}
+
+	r.handlers[len(r.requests)] = handle
+	return r.AddRequest(request)
+}
+
+// handleResponse runs handle on the response of result and closes its body
+func handleResponse(result roundTripParcel, handle ResponseHandler) roundTripParcel {
+	if handle == nil || result.err != nil || result.response == nil {
+		return result
+	}
+
+	err := handle(result.response)
+	result.response.Body.Close()
+	if err != nil {
+		return roundTripParcel{err: err, index: result.index}
+	}
+
+	handled := *result.response
+	handled.Body = http.NoBody
+	result.response = &handled
+	return result
+}
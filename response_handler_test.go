@@ -0,0 +1,42 @@
+package meniscus
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseHandlersConsumeTheResponsesOnTheWorkers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"id":"` + req.URL.Path[1:] + `"}`))
+	}))
+	defer server.Close()
+
+	for _, opts := range [][]ClientOption{nil, {WithUnbufferedResponses()}} {
+		client := NewBulkHTTPClient(&http.Client{}, NonFailingTimeoutValue, opts...)
+
+		var driver struct{ ID string }
+		decode := func(resp *http.Response) error { return json.NewDecoder(resp.Body).Decode(&driver) }
+		refuse := func(*http.Response) error { return errors.New("refused") }
+
+		bulkRequest := NewBulkRequest(nil).
+			AddRequestWithHandler(mustRequest(t, server.URL+"/d1"), decode).
+			AddRequestWithHandler(mustRequest(t, server.URL+"/d2"), refuse).
+			AddGet(server.URL+"/d3", nil)
+		responses, errs := client.Do(bulkRequest)
+
+		assert.Equal(t, []error{nil, errors.New("refused"), nil}, errs)
+		assert.Equal(t, "d1", driver.ID)
+		assert.Equal(t, http.StatusOK, responses[0].StatusCode)
+		assert.Equal(t, http.NoBody, responses[0].Body)
+		assert.Nil(t, responses[1])
+
+		body, _ := ioutil.ReadAll(responses[2].Body)
+		assert.Equal(t, `{"id":"d3"}`, string(body), "requests without handler are left alone")
+		bulkRequest.CloseAllResponses()
+	}
+}
@@ -0,0 +1,55 @@
+package meniscus
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+)
+
+//ResponseVerifier checks a received response, e.g. its HMAC or detached JWS signature header against the body.
+//It runs inside the response workers on a buffered copy of the body, which is rewound afterwards.
+//A non nil error fails the request with that error, ErrInvalidSignature is provided for the purpose.
+type ResponseVerifier func(*http.Response) error
+
+//HMACVerifier returns a verifier comparing the hex encoded HMAC of the body, keyed with key, to the header of the response
+func HMACVerifier(header string, key []byte, newHash func() hash.Hash) ResponseVerifier {
+	return func(resp *http.Response) error {
+		signature, err := hex.DecodeString(resp.Header.Get(header))
+		if err != nil || len(signature) == 0 {
+			return ErrInvalidSignature
+		}
+
+		mac := hmac.New(newHash, key)
+		if _, err := io.Copy(mac, resp.Body); err != nil {
+			return err
+		}
+
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	}
+}
+
+// verify runs the verifier on resp and rewinds its buffered body for the caller
+func (cl *BulkClient) verify(resp *http.Response) error {
+	if err := cl.verifier(resp); err != nil {
+		return err
+	}
+
+	if seeker, ok := resp.Body.(io.Seeker); ok {
+		_, err := seeker.Seek(0, io.SeekStart)
+		return err
+	}
+
+	return nil
+}
+
+// buffered tells whether response bodies are copied (or dropped) before being returned, which verification,
+// decryption and the header allow list need even when the client was built WithUnbufferedResponses
+func (cl *BulkClient) buffered() bool {
+	return !cl.unbuffered || cl.verifier != nil || cl.cipher.Decrypt != nil || cl.allowList.enabled
+}
@@ -0,0 +1,48 @@
+package meniscus
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func signedServer(key []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body := []byte("signed " + req.URL.Query().Get("kind"))
+		mac := hmac.New(sha256.New, key)
+		mac.Write(body)
+		if req.URL.Query().Get("kind") != "tampered" {
+			w.Header().Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+		} else {
+			w.Header().Set("X-Signature", hex.EncodeToString(mac.Sum([]byte("x"))))
+		}
+		w.Write(body)
+	}))
+}
+
+func TestResponsesFailingVerificationFailTheRequest(t *testing.T) {
+	key := []byte("secret")
+	server := signedServer(key)
+	defer server.Close()
+
+	for _, opts := range [][]ClientOption{nil, {WithUnbufferedResponses()}, {WithBufferPool()}} {
+		opts = append(opts, WithResponseVerifier(HMACVerifier("X-Signature", key, sha256.New)))
+		client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue, opts...)
+
+		valid, _ := http.NewRequest(http.MethodGet, server.URL+"?kind=valid", nil)
+		tampered, _ := http.NewRequest(http.MethodGet, server.URL+"?kind=tampered", nil)
+		bulkRequest := NewBulkRequest([]*http.Request{valid, tampered}, 2, 2)
+
+		responses, errs := client.Do(bulkRequest)
+
+		assert.Equal(t, []error{nil, ErrInvalidSignature}, errs)
+		body, _ := ioutil.ReadAll(responses[0].Body)
+		assert.Equal(t, "signed valid", string(body))
+		bulkRequest.CloseAllResponses()
+	}
+}
@@ -0,0 +1,65 @@
+package meniscus
+
+import (
+	"net/http"
+	"time"
+)
+
+//Result is the outcome of a single request of a bulk
+type Result struct {
+	Index     int // position of the request in the bulk
+	Request   *http.Request
+	Response  *http.Response
+	Err       error
+	Value     interface{}   // set by post processors, e.g. the decoded body
+	Tags      Tags          // set with RoundTrip.AddTaggedRequest
+	Latency   time.Duration // from sending the request to receiving the response headers
+	QueueWait time.Duration // from the bulk being dispatched to a fire worker picking the request up
+	Scope     *RequestScope // shared by the attempts at sending the request, nil when it was never fired
+}
+
+//Results are the results of a bulk, ordered by request index
+type Results []Result
+
+//Merge returns a copy of results where the successful results of retry, e.g. the second pass of a bulk
+//built with RoundTrip.RetryFailed, replace those with the same index. Failed retries leave the original result,
+//results of retry whose index is not in results are dropped.
+func (results Results) Merge(retry Results) Results {
+	merged := make(Results, len(results))
+	copy(merged, results)
+
+	positions := make(map[int]int, len(merged))
+	for position, result := range merged {
+		positions[result.Index] = position
+	}
+
+	for _, result := range retry {
+		if position, ok := positions[result.Index]; ok && result.Err == nil {
+			merged[position] = result
+		}
+	}
+
+	return merged
+}
+
+func (p roundTripParcel) result() Result {
+	return Result{
+		Index:     p.index,
+		Request:   p.request,
+		Response:  p.response,
+		Err:       p.err,
+		Value:     p.value,
+		Tags:      p.tags,
+		Latency:   p.latency,
+		QueueWait: p.queueWait,
+		Scope:     scopeOfRequest(p.request),
+	}
+}
+
+func (p roundTripParcel) withResult(result Result) roundTripParcel {
+	p.request = result.Request
+	p.response = result.Response
+	p.err = result.Err
+	p.value = result.Value
+	return p
+}
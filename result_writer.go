@@ -0,0 +1,159 @@
+package meniscus
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// resultRecord is the JSON line written for one result by Results.WriteJSONL
+type resultRecord struct {
+	Index    int       `json:"index"`
+	Method   string    `json:"method,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Status   int       `json:"status,omitempty"`
+	Duration float64   `json:"duration_ms"`
+	Error    string    `json:"error,omitempty"`
+	Code     ErrorCode `json:"code,omitempty"`
+	Tags     Tags      `json:"tags,omitempty"`
+	Body     string    `json:"body,omitempty"`
+}
+
+//WriteJSONL writes one JSON record per result to w, with the method, url, response status, latency in milliseconds,
+//error and its code and tags of the request, for piping the outcome of a bulk into logs or offline analysis.
+//Given a snippet length, the record also holds up to that many bytes of the response body. Only buffered bodies,
+//which are rewound afterwards, get a snippet as unbuffered ones could not be read again by the caller.
+func (results Results) WriteJSONL(w io.Writer, snippet ...int) error {
+	limit := 0
+	if len(snippet) > 0 {
+		limit = snippet[0]
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		record := recordOf(result)
+		if result.Response != nil && limit > 0 {
+			record.Body = bodySnippet(result.Response.Body, limit)
+		}
+
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordOf returns the record of result, without a body snippet
+func recordOf(result Result) resultRecord {
+	record := resultRecord{
+		Index:    result.Index,
+		Duration: float64(result.Latency) / 1e6,
+		Code:     Code(result.Err),
+		Tags:     result.Tags,
+	}
+
+	if result.Request != nil {
+		record.Method = result.Request.Method
+		if result.Request.URL != nil {
+			record.URL = result.Request.URL.String()
+		}
+	}
+
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+
+	if result.Response != nil {
+		record.Status = result.Response.StatusCode
+	}
+
+	return record
+}
+
+//CSVColumn is a column written by Results.WriteCSV, Value returns the cell of a result
+type CSVColumn struct {
+	Name  string
+	Value func(Result) string
+}
+
+// Columns written by Results.WriteCSV when none are given, with the same values as the fields of WriteJSONL
+var (
+	//IndexColumn is the index of the request in its bulk
+	IndexColumn = CSVColumn{Name: "index", Value: func(result Result) string { return strconv.Itoa(result.Index) }}
+	//MethodColumn is the method of the request
+	MethodColumn = CSVColumn{Name: "method", Value: func(result Result) string { return recordOf(result).Method }}
+	//URLColumn is the URL of the request
+	URLColumn = CSVColumn{Name: "url", Value: func(result Result) string { return recordOf(result).URL }}
+	//StatusColumn is the status code of the response, empty when there is none
+	StatusColumn = CSVColumn{Name: "status", Value: func(result Result) string {
+		if result.Response == nil {
+			return ""
+		}
+		return strconv.Itoa(result.Response.StatusCode)
+	}}
+	//DurationColumn is the latency of the request in milliseconds
+	DurationColumn = CSVColumn{Name: "duration_ms", Value: func(result Result) string {
+		return strconv.FormatFloat(recordOf(result).Duration, 'f', -1, 64)
+	}}
+	//ErrorColumn is the error of the request, empty when it succeeded
+	ErrorColumn = CSVColumn{Name: "error", Value: func(result Result) string { return recordOf(result).Error }}
+	//CodeColumn is the code of the error of the request, see Code
+	CodeColumn = CSVColumn{Name: "code", Value: func(result Result) string { return string(Code(result.Err)) }}
+)
+
+//TagColumn is the value of the tag name of the request, see RoundTrip.AddTaggedRequest
+func TagColumn(name string) CSVColumn {
+	return CSVColumn{Name: name, Value: func(result Result) string { return result.Tags[name] }}
+}
+
+//WriteCSV writes a header row and one row per result to w, with the given columns,
+//or the index, method, url, status, duration in milliseconds, error and code of the requests when none are given
+func (results Results) WriteCSV(w io.Writer, columns ...CSVColumn) error {
+	if len(columns) == 0 {
+		columns = []CSVColumn{IndexColumn, MethodColumn, URLColumn, StatusColumn, DurationColumn, ErrorColumn, CodeColumn}
+	}
+
+	writer := csv.NewWriter(w)
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = column.Name
+	}
+	if err := writer.Write(row); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		for i, column := range columns {
+			row[i] = column.Value(result)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// bodySnippet reads up to limit bytes of body and rewinds it, leaving bodies that cannot be rewound alone
+func bodySnippet(body io.Reader, limit int) string {
+	seeker, ok := body.(io.ReadSeeker)
+	if !ok {
+		return ""
+	}
+
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return ""
+	}
+
+	snippet, _ := ioutil.ReadAll(io.LimitReader(seeker, int64(limit)))
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		return ""
+	}
+
+	return string(snippet)
+}
@@ -0,0 +1,93 @@
+package meniscus
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJSONLWritesOneRecordPerResult(t *testing.T) {
+	ok := syntheticResponse(mustRequest(t, "http://example.com/drivers"), http.StatusOK)
+	results := Results{
+		{Index: 0, Request: ok.Request, Response: ok, Latency: 1500 * time.Microsecond, Tags: Tags{"endpoint": "drivers"}},
+		{Index: 1, Request: mustRequest(t, "http://example.com/orders"), Err: ErrRequestIgnored},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, results.WriteJSONL(&out))
+
+	assert.Equal(t, `{"index":0,"method":"GET","url":"http://example.com/drivers","status":200,"duration_ms":1.5,"tags":{"endpoint":"drivers"}}
+{"index":1,"method":"GET","url":"http://example.com/orders","duration_ms":0,"error":"request ignored","code":"MENISCUS_IGNORED"}
+`, out.String())
+}
+
+func TestWriteJSONLSnippetsBufferedBodiesAndRewindsThem(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(`{"id":42,"name":"driver"}`))
+		return resp, nil
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/drivers/42", nil)
+	responses, _ := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	var out bytes.Buffer
+	assert.NoError(t, bulkRequest.Results().WriteJSONL(&out, 8))
+
+	assert.Contains(t, out.String(), `"body":"{\"id\":42"`)
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, `{"id":42,"name":"driver"}`, string(body))
+}
+
+func TestWriteJSONLLeavesUnbufferedBodiesUnread(t *testing.T) {
+	client := NewBulkHTTPClient(streamingClient(nil), NonFailingTimeoutValue, WithUnbufferedResponses())
+
+	bulkRequest := NewBulkRequest(nil).AddGet("http://example.com/short", nil)
+	responses, _ := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	var out bytes.Buffer
+	assert.NoError(t, bulkRequest.Results().WriteJSONL(&out, 8))
+
+	assert.NotContains(t, out.String(), `"body"`)
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, "done", string(body))
+}
+
+func TestWriteCSVWritesTheDefaultColumns(t *testing.T) {
+	ok := syntheticResponse(mustRequest(t, "http://example.com/drivers"), http.StatusOK)
+	results := Results{
+		{Index: 0, Request: ok.Request, Response: ok, Latency: 1500 * time.Microsecond},
+		{Index: 1, Request: mustRequest(t, "http://example.com/orders"), Err: errors.New("dial tcp: connection refused, retrying")},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, results.WriteCSV(&out))
+
+	assert.Equal(t, `index,method,url,status,duration_ms,error,code
+0,GET,http://example.com/drivers,200,1.5,,
+1,GET,http://example.com/orders,,0,"dial tcp: connection refused, retrying",MENISCUS_UNKNOWN
+`, out.String())
+}
+
+func TestWriteCSVWritesTheGivenColumns(t *testing.T) {
+	results := Results{
+		{Index: 3, Tags: Tags{"tenant": "id"}, Err: ErrRequestSkipped},
+		{Index: 4, Tags: Tags{"tenant": "sg"}},
+	}
+	retried := CSVColumn{Name: "retried", Value: func(result Result) string {
+		return strconv.FormatBool(result.Scope != nil && result.Scope.Retried())
+	}}
+
+	var out bytes.Buffer
+	assert.NoError(t, results.WriteCSV(&out, IndexColumn, TagColumn("tenant"), CodeColumn, retried))
+
+	assert.Equal(t, "index,tenant,code,retried\n3,id,MENISCUS_SKIPPED,false\n4,sg,,false\n", out.String())
+}
@@ -0,0 +1,167 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//Backoff returns how long to wait before the retry numbered retry of a request, the first retry being 1
+type Backoff func(retry int) time.Duration
+
+//ExponentialBackoff waits a random duration up to base for the first retry, the bound doubling for every following
+//retry up to max, so that requests failing together do not retry together. A nil random uses GlobalRandom.
+func ExponentialBackoff(base, max time.Duration, random Random) Backoff {
+	if random == nil {
+		random = GlobalRandom()
+	}
+
+	return func(retry int) time.Duration {
+		bound := base
+		for i := 1; i < retry && bound < max; i++ {
+			bound *= 2
+		}
+		if bound > max {
+			bound = max
+		}
+
+		return time.Duration(random() * float64(bound))
+	}
+}
+
+//RetriesExhaustedError is returned, with WithRetry, for a request whose every attempt failed
+type RetriesExhaustedError struct {
+	Attempts   int
+	StatusCode int   // status of the last attempt, zero when it failed with an error
+	Err        error // error of the last attempt, nil when it got a 5xx or 429 response
+}
+
+func (e *RetriesExhaustedError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("giving up after %d attempts: %s", e.Attempts, e.Err)
+	}
+
+	return fmt.Sprintf("giving up after %d attempts: status %d", e.Attempts, e.StatusCode)
+}
+
+//Unwrap returns the error of the last attempt
+func (e *RetriesExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+//WithRetry sends again the requests of the client failing with a network error or a 5xx or 429 response,
+//making up to maxAttempts attempts in all and waiting for backoff between them. Requests refused by a policy
+//of the client or cancelled are not retried. The Retries of a RequestSpec take precedence over maxAttempts.
+//Retries stop with the bulk: a request whose bulk timed out or was cancelled while waiting to be retried is ignored. A request failing its last attempt fails with a *RetriesExhaustedError,
+//its response being closed when it got one.
+func WithRetry(maxAttempts int, backoff Backoff) ClientOption {
+	return func(cl *BulkClient) {
+		cl.retry = &retryPolicy{maxAttempts: maxAttempts, backoff: backoff}
+	}
+}
+
+// specBackoffBase and specBackoffMax bound the exponential backoff of the retries of specs on a client built
+// without WithRetry, so that they do not retry back to back
+const (
+	specBackoffBase = 50 * time.Millisecond
+	specBackoffMax  = 2 * time.Second
+)
+
+type retryPolicy struct {
+	maxAttempts int
+	backoff     Backoff
+}
+
+// retryMiddleware is the one retry path of the client, for the requests of a client built WithRetry as well as
+// those added with AddSpec. The Retries of a spec take precedence over maxAttempts, its Timeout bounds every attempt,
+// and every attempt is a copy of the request.
+func retryMiddleware(policy *retryPolicy, clock Clock) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			maxAttempts, backoff := 1, Backoff(nil)
+			if policy != nil {
+				maxAttempts, backoff = policy.maxAttempts, policy.backoff
+			}
+
+			attempt := next
+			if spec, ok := req.Context().Value(policyKey{}).(requestPolicy); ok {
+				if spec.timeout > 0 {
+					attempt = stagedTimeoutMiddleware(StagedTimeouts{Total: spec.timeout}, clock)(next)
+				}
+				if spec.retries > 0 {
+					maxAttempts = spec.retries + 1
+				}
+				if spec.retries > 0 && backoff == nil {
+					backoff = ExponentialBackoff(specBackoffBase, specBackoffMax, nil)
+				}
+			}
+
+			resp, err := attempt.Do(req)
+			attempts := 1
+			for ; attempts < maxAttempts && retryable(req, resp, err); attempts++ {
+				closeResponse(resp)
+				if backoff != nil {
+					if sleepErr := sleep(req.Context(), clock, backoff(attempts)); sleepErr != nil {
+						return nil, sleepErr
+					}
+				}
+
+				retry, cloneErr := CloneForAttempt(req.Context(), req)
+				if cloneErr != nil {
+					return nil, cloneErr
+				}
+
+				if scope := ScopeOf(req.Context()); scope != nil {
+					scope.MarkRetried()
+				}
+
+				resp, err = attempt.Do(retry)
+			}
+
+			if attempts > 1 && retryable(req, resp, err) {
+				return nil, exhausted(attempts, resp, err)
+			}
+
+			return resp, err
+		})
+	}
+}
+
+// retryable tells whether an attempt failed in a way another attempt may not.
+// Nothing is retried once the bulk timed out or was cancelled, nor are errors of a context or of a policy.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+
+		code := Code(err)
+		return code != CodeRejected && code != CodeSkipped && code != CodeIgnored
+	}
+
+	return resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// exhausted closes the response of the last attempt and returns the error of the request
+func exhausted(attempts int, resp *http.Response, err error) error {
+	if resp == nil {
+		return &RetriesExhaustedError{Attempts: attempts, Err: err}
+	}
+
+	closeResponse(resp)
+	return &RetriesExhaustedError{Attempts: attempts, StatusCode: resp.StatusCode}
+}
+
+// closeResponse drains the body of a response before closing it, so that its connection can be reused
+func closeResponse(resp *http.Response) {
+	if resp != nil {
+		drain(resp.Body)
+		resp.Body.Close()
+	}
+}
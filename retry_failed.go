@@ -0,0 +1,131 @@
+package meniscus
+
+import (
+	"errors"
+	"net/http"
+)
+
+//RetryFailed returns a new bulk holding the requests of r that failed with an error in its last Do, for a second pass.
+//Requests that were never meant to be sent, those that could not be built, were filtered out, skipped, cancelled
+//or already succeeded, are left out, whether or not their error is wrapped. Requests are copied with CloneForAttempt, those with a body and no GetBody fail with ErrBodyNotReplayable.
+//The results of the new bulk keep the indices the requests had in r, see Results.Merge.
+//Tags, conditions, streamed bodies, captures, response handlers, connect-to addresses, timeouts and retries of specs,
+//attempt counts, variables, tenant, workers and context are carried over, phases added with Then and RequestHandles are not.
+func (r *RoundTrip) RetryFailed() *RoundTrip {
+	retry := NewBulkRequest(nil, r.fireRequestsWorkers, r.processResponseWorkers).SetTenant(r.tenant)
+	retry.parent = r.parent
+	retry.variables = r.variables
+	retry.retryPass = true
+
+	for index := range r.errors {
+		if !r.outstanding(index) {
+			continue
+		}
+
+		position := len(retry.requests)
+		req, err := CloneForAttempt(r.parentContext(), r.requests[index])
+		if err != nil {
+			if retry.invalid == nil {
+				retry.invalid = map[int]error{}
+			}
+			retry.invalid[position] = err
+		}
+
+		if retry.attempts == nil {
+			retry.attempts = map[int]int{}
+		}
+		retry.attempts[position] = r.failedAttempts(index)
+
+		r.carry(index, req, retry)
+	}
+
+	return retry
+}
+
+// carry adds req in place of the request at index to into, with its settings
+func (r *RoundTrip) carry(index int, req *http.Request, into *RoundTrip) {
+	position := len(into.requests)
+	if tags, ok := r.tags[index]; ok {
+		if into.tags == nil {
+			into.tags = map[int]Tags{}
+		}
+		into.tags[position] = tags
+	}
+
+	if condition, ok := r.conditions[index]; ok {
+		if into.conditions == nil {
+			into.conditions = map[int]func() bool{}
+		}
+		into.conditions[position] = condition
+	}
+
+	if via, ok := r.via[index]; ok {
+		if into.via == nil {
+			into.via = map[int]viaAddress{}
+		}
+		into.via[position] = via
+	}
+
+	if policy, ok := r.policies[index]; ok {
+		if into.policies == nil {
+			into.policies = map[int]requestPolicy{}
+		}
+		into.policies[position] = policy
+	}
+
+	if handle, ok := r.handlers[index]; ok {
+		if into.handlers == nil {
+			into.handlers = map[int]ResponseHandler{}
+		}
+		into.handlers[position] = handle
+	}
+
+	if produce, ok := r.streams[index]; ok {
+		into.AddStreamingRequest(req, produce)
+	} else if capture, ok := r.captures[index]; ok {
+		into.AddCapturingRequest(req, capture.name, capture.capture)
+	} else {
+		into.AddRequest(req)
+	}
+	into.origins = append(into.origins, r.origin(index))
+}
+
+// origin returns the index the request at index had in the bulk it was retried from
+func (r *RoundTrip) origin(index int) int {
+	if index < len(r.origins) {
+		return r.origins[index]
+	}
+
+	return index
+}
+
+// outstanding tells whether the request at index failed in the last Do and is worth sending again
+func (r *RoundTrip) outstanding(index int) bool {
+	err := r.errors[index]
+	if err == nil || r.invalid[index] != nil {
+		return false
+	}
+
+	// matched by code as errors may be wrapped, e.g. WithTimeoutAttribution. Chunks held back by an unhealthy
+	// downstream were meant to be sent, they are sent again.
+	if errors.Is(err, ErrDownstreamUnhealthy) {
+		return true
+	}
+
+	return Code(err) != CodeSkipped && !errors.Is(err, ErrRequestCancelled)
+}
+
+// failedAttempts is the number of runs of Do the request at index failed in, counting the last one
+// unless it was interrupted before the request was sent
+func (r *RoundTrip) failedAttempts(index int) int {
+	attempts := r.attempts[index]
+	if r.interruption != nil {
+		for _, notStarted := range r.interruption.NotStarted {
+			if notStarted == r.origin(index) {
+				return attempts
+			}
+		}
+	}
+
+	return attempts + 1
+}
@@ -0,0 +1,155 @@
+package meniscus
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// flakyClient fails the first attempt at every path in failing, echoing the request body otherwise
+func flakyClient(failing ...string) HTTPClientFunc {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	return func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		attempts[req.URL.Path]++
+		attempt := attempts[req.URL.Path]
+		mu.Unlock()
+
+		for _, path := range failing {
+			if path == req.URL.Path && attempt == 1 {
+				return nil, errors.New("connection reset")
+			}
+		}
+
+		var body []byte
+		if req.Body != nil {
+			body, _ = ioutil.ReadAll(req.Body)
+		}
+
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+}
+
+func TestRetryFailedReplaysTheFailedRequestsUnderTheirOriginalIndices(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/b", "/d"), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 2, 2).
+		AddGet("http://example.com/a", nil).
+		AddPostJSON("http://example.com/b", map[string]int{"id": 1}).
+		AddGet("http://example.com/c", nil).
+		AddTaggedRequest(mustRequest(t, "http://example.com/d"), Tags{"kind": "d"})
+	_, errs := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+	assert.Error(t, errs[1])
+	assert.Error(t, errs[3])
+
+	retry := bulkRequest.RetryFailed()
+	_, errs = client.Do(retry)
+	defer retry.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	results := retry.Results()
+	assert.Equal(t, 1, results[0].Index)
+	assert.Equal(t, 3, results[1].Index)
+	assert.Equal(t, Tags{"kind": "d"}, results[1].Tags)
+
+	body, _ := ioutil.ReadAll(results[0].Response.Body)
+	assert.Equal(t, `{"id":1}`, string(body))
+}
+
+func TestRetryFailedKeepsIndicesAcrossPasses(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/b"), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("http://example.com/a", nil).AddGet("http://example.com/b", nil)
+	client.Do(bulkRequest)
+	bulkRequest.errors[0] = ErrRequestIgnored
+
+	second := bulkRequest.RetryFailed()
+	client.Do(second)
+	second.errors[1] = ErrRequestIgnored
+
+	third := second.RetryFailed()
+	client.Do(third)
+
+	assert.Len(t, third.Results(), 1)
+	assert.Equal(t, 1, third.Results()[0].Index)
+}
+
+func TestRetryFailedLeavesOutRequestsNeverMeantToBeSent(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient(), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/%zz", nil).
+		AddRequestIf(mustRequest(t, "http://example.com/skipped"), func() bool { return false })
+	client.Do(bulkRequest)
+
+	assert.Empty(t, bulkRequest.RetryFailed().requests)
+}
+
+func TestRetryFailedLeavesOutWrappedErrorsOfRequestsNeverMeantToBeSent(t *testing.T) {
+	bulkRequest := newBulkClientWithNRequests(6, "http://example.com")
+	bulkRequest.errors = []error{
+		&TimeoutError{Source: BulkDeadline, Err: ErrRequestCancelled},
+		&codedError{code: CodeSkipped, msg: "skipped", cause: ErrRequestSkipped},
+		&codedError{code: CodeSkipped, msg: "filtered", cause: ErrRequestFiltered},
+		&codedError{code: CodeSkipped, msg: "succeeded", cause: ErrAlreadySucceeded},
+		&TimeoutError{Source: BulkDeadline, Err: ErrRequestIgnored},
+		ErrDownstreamUnhealthy,
+	}
+
+	retry := bulkRequest.RetryFailed()
+
+	assert.Len(t, retry.requests, 2)
+	assert.Equal(t, []int{4, 5}, retry.origins)
+}
+
+func TestRetryFailedFailsRequestsWhoseBodyCannotBeRebuilt(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/upload"), NonFailingTimeoutValue)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/upload", ioutil.NopCloser(strings.NewReader("data")))
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+	client.Do(bulkRequest)
+
+	_, errs := client.Do(bulkRequest.RetryFailed())
+	assert.Equal(t, []error{ErrBodyNotReplayable}, errs)
+}
+
+func mustRequest(t *testing.T, url string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.NoError(t, err)
+	return req
+}
+
+func TestMergeOverlaysSuccessfulRetries(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/b", "/c"), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/b", nil).
+		AddGet("http://example.com/c", nil)
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	retry := bulkRequest.RetryFailed()
+	client.Do(retry)
+	defer retry.CloseAllResponses()
+	failed := retry.Results()
+	failed[1].Err, failed[1].Response = ErrRequestIgnored, nil
+
+	original := bulkRequest.Results()
+	merged := original.Merge(failed)
+
+	assert.Len(t, merged, 3)
+	assert.Equal(t, original[0], merged[0])
+	assert.Equal(t, failed[0], merged[1])
+	assert.Equal(t, original[2], merged[2])
+	assert.Error(t, original[1].Err, "the original results are left alone")
+}
@@ -0,0 +1,192 @@
+package meniscus
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetrySendsFailedRequestsAgain(t *testing.T) {
+	client := NewBulkHTTPClient(flakyClient("/b"), NonFailingTimeoutValue, WithRetry(3, nil))
+	bulkRequest := NewBulkRequest(nil, 2, 2).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/b", nil)
+
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, 1, bulkRequest.Counts().Retried)
+}
+
+func TestRetryReportsExhaustedRetries(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	statuses := map[string]int{"/unavailable": http.StatusServiceUnavailable, "/throttled": http.StatusTooManyRequests, "/missing": http.StatusNotFound}
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls[req.URL.Path]++
+		mu.Unlock()
+		if req.URL.Path == "/reset" {
+			return nil, errors.New("connection reset")
+		}
+		return syntheticResponse(req, statuses[req.URL.Path]), nil
+	}), NonFailingTimeoutValue, WithRetry(3, ExponentialBackoff(time.Millisecond, 2*time.Millisecond, nil)))
+
+	bulkRequest := NewBulkRequest(nil, 4, 4).
+		AddGet("http://example.com/unavailable", nil).
+		AddGet("http://example.com/throttled", nil).
+		AddGet("http://example.com/reset", nil).
+		AddGet("http://example.com/missing", nil)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, map[string]int{"/unavailable": 3, "/throttled": 3, "/reset": 3, "/missing": 1}, calls)
+	for i, status := range []int{http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		var exhausted *RetriesExhaustedError
+		assert.True(t, errors.As(errs[i], &exhausted))
+		assert.Equal(t, &RetriesExhaustedError{Attempts: 3, StatusCode: status}, exhausted)
+	}
+	assert.Contains(t, errs[2].Error(), "giving up after 3 attempts: connection reset")
+	assert.NoError(t, errs[3])
+	assert.Equal(t, http.StatusNotFound, responses[3].StatusCode)
+}
+
+func TestRetryStopsWithTheBulk(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusBadGateway), nil
+	}), 50*time.Millisecond, WithRetry(5, func(int) time.Duration { return time.Hour }))
+
+	bulkRequest := newBulkClientWithNRequests(1, "http://example.com")
+	start := time.Now()
+	_, errs := client.Do(bulkRequest)
+
+	assert.Equal(t, []error{ErrRequestIgnored}, errs)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestExponentialBackoffDoublesUpToMax(t *testing.T) {
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second, func() float64 { return 0.5 })
+
+	var delays []time.Duration
+	for retry := 1; retry <= 5; retry++ {
+		delays = append(delays, backoff(retry))
+	}
+
+	assert.Equal(t, []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond}, delays)
+}
+
+func TestRetriesOfASpecTakePrecedenceOverThoseOfTheClient(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return syntheticResponse(req, http.StatusBadGateway), nil
+	}), NonFailingTimeoutValue, WithRetry(3, nil))
+
+	bulkRequest := NewBulkRequest(nil).AddSpec(RequestSpec{URL: "http://example.com/", Retries: 1})
+	_, errs := client.Do(bulkRequest)
+
+	assert.Equal(t, 2, calls)
+	var exhausted *RetriesExhaustedError
+	assert.True(t, errors.As(errs[0], &exhausted))
+	assert.Equal(t, 2, exhausted.Attempts)
+}
+
+func TestRetryLeavesContextErrorsAlone(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return nil, context.Canceled
+	}), NonFailingTimeoutValue, WithRetry(3, nil))
+
+	client.Do(newBulkClientWithNRequests(1, "http://example.com"))
+
+	assert.Equal(t, 1, calls)
+}
+
+// drainedBody counts the bytes read from it before it was closed
+type drainedBody struct {
+	io.Reader
+	read *int
+}
+
+func (b drainedBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	*b.read += n
+	return n, err
+}
+
+func (drainedBody) Close() error {
+	return nil
+}
+
+func TestRetryDrainsTheResponsesOfFailedAttempts(t *testing.T) {
+	read := 0
+	attempts := 0
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			resp := syntheticResponse(req, http.StatusServiceUnavailable)
+			resp.Body = drainedBody{Reader: strings.NewReader("try again"), read: &read}
+			return resp, nil
+		}
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithRetry(2, nil))
+
+	bulkRequest := newBulkClientWithNRequests(1, "http://example.com")
+	client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, len("try again"), read)
+}
+
+func TestRetriesOfASpecBackOffWithoutWithRetry(t *testing.T) {
+	var calls int32
+	clock := NewFakeClock(time.Now())
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return syntheticResponse(req, http.StatusBadGateway), nil
+	}), time.Minute, WithClock(clock))
+
+	bulkRequest := NewBulkRequest(nil).AddSpec(RequestSpec{URL: "http://example.com/", Retries: 1})
+	done := make(chan []error)
+	go func() {
+		_, errs := client.Do(bulkRequest)
+		done <- errs
+	}()
+
+	// the bulk timeout and the backoff of the retry
+	clock.BlockUntil(2)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	clock.Advance(specBackoffBase)
+
+	errs := <-done
+	assert.Contains(t, errs[0].Error(), "giving up after 2 attempts")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestRetriesOfASpecAreCapped(t *testing.T) {
+	var calls int32
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return syntheticResponse(req, http.StatusBadGateway), nil
+	}), NonFailingTimeoutValue, WithRetry(1, func(int) time.Duration { return 0 }))
+
+	bulkRequest := NewBulkRequest(nil).AddSpec(RequestSpec{URL: "http://example.com/", Retries: 1000})
+	client.Do(bulkRequest)
+
+	assert.Equal(t, int32(MaxSpecRetries+1), atomic.LoadInt32(&calls))
+}
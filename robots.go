@@ -0,0 +1,201 @@
+package meniscus
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//RobotsCache keeps the robots.txt rules of every host crawled, it can be shared between crawls
+type RobotsCache struct {
+	mu    sync.Mutex
+	hosts map[string]*robotsRules
+}
+
+//NewRobotsCache returns an empty cache
+func NewRobotsCache() *RobotsCache {
+	return &RobotsCache{hosts: map[string]*robotsRules{}}
+}
+
+func (c *RobotsCache) get(origin string) (*robotsRules, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rules, ok := c.hosts[origin]
+	return rules, ok
+}
+
+func (c *RobotsCache) set(origin string, rules *robotsRules) {
+	c.mu.Lock()
+	c.hosts[origin] = rules
+	c.mu.Unlock()
+}
+
+// fetch loads the robots.txt of the origins of urls missing from the cache, all in one bulk.
+// As robots.txt asks, a missing file allows everything and an unreachable one disallows everything.
+func (c *RobotsCache) fetch(cl *BulkClient, urls []string, opts CrawlOptions) {
+	var origins []string
+	missing := map[string]bool{}
+	for _, u := range urls {
+		origin := originOf(u)
+		if _, ok := c.get(origin); !ok && !missing[origin] {
+			missing[origin] = true
+			origins = append(origins, origin)
+		}
+	}
+
+	if len(origins) == 0 {
+		return
+	}
+
+	bulkRequest := NewBulkRequest(nil, opts.Workers, opts.Workers)
+	for _, origin := range origins {
+		bulkRequest.AddGet(origin+"/robots.txt", opts.headers())
+	}
+
+	cl.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	for _, result := range bulkRequest.Results() {
+		rules := &robotsRules{disallowAll: true}
+		if result.Response != nil && result.Response.StatusCode < http.StatusInternalServerError {
+			rules = &robotsRules{}
+			if result.Response.StatusCode < http.StatusBadRequest {
+				rules = parseRobots(result.Response.Body, opts.userAgent())
+			}
+		}
+		c.set(origins[result.Index], rules)
+	}
+}
+
+func (c *RobotsCache) allowed(u string) bool {
+	rules, ok := c.get(originOf(u))
+	if !ok {
+		return false
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+
+	return rules.allowed(parsed.RequestURI())
+}
+
+func (c *RobotsCache) delay(u string) time.Duration {
+	if rules, ok := c.get(originOf(u)); ok {
+		return rules.delay
+	}
+
+	return 0
+}
+
+type robotsRules struct {
+	disallowAll bool
+	rules       []robotsRule
+	delay       time.Duration
+}
+
+type robotsRule struct {
+	allow  bool
+	prefix string
+}
+
+// allowed applies the rule with the longest matching prefix, allow winning ties
+func (r *robotsRules) allowed(path string) bool {
+	if r.disallowAll {
+		return false
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !strings.HasPrefix(path, rule.prefix) {
+			continue
+		}
+
+		if len(rule.prefix) > longest || (len(rule.prefix) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.prefix)
+		}
+	}
+
+	return allowed
+}
+
+type robotsGroup struct {
+	agents []string
+	rules  robotsRules
+}
+
+// parseRobots returns the rules of the group naming userAgent, or of the * group when none does
+func parseRobots(body io.Reader, userAgent string) *robotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			continue
+		}
+
+		field := strings.ToLower(strings.TrimSpace(line[:colon]))
+		value := strings.TrimSpace(line[colon+1:])
+		switch field {
+		case "user-agent":
+			if !inAgents {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+			continue
+		case "allow", "disallow":
+			if current != nil && len(value) != 0 {
+				current.rules.rules = append(current.rules.rules, robotsRule{allow: field == "allow", prefix: value})
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); current != nil && err == nil {
+				current.rules.delay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+		inAgents = false
+	}
+
+	userAgent = strings.ToLower(userAgent)
+	var fallback *robotsRules
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			if agent == "*" && fallback == nil {
+				fallback = &group.rules
+			} else if agent != "*" && strings.Contains(userAgent, agent) {
+				return &group.rules
+			}
+		}
+	}
+
+	if fallback == nil {
+		return &robotsRules{}
+	}
+
+	return fallback
+}
+
+func originOf(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Scheme + "://" + parsed.Host
+}
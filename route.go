@@ -0,0 +1,70 @@
+package meniscus
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const routeParam = ":id"
+
+var identifierSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+//RouteNormalizer maps a request URL to the route it belongs to, so that stats are kept per route
+//instead of per URL when a bulk holds thousands of distinct URLs
+type RouteNormalizer func(*url.URL) string
+
+//NormalizeIDs replaces path segments that look like identifiers (numbers, UUIDs, long hex strings) with :id
+//and drops the query, e.g. /drivers/42/orders?page=2 becomes /drivers/:id/orders
+func NormalizeIDs(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if identifierSegment.MatchString(segment) {
+			segments[i] = routeParam
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+//RouteTemplates returns a normalizer matching paths against templates such as /drivers/:driver/orders,
+//where segments starting with a colon match any value. Paths matching no template fall back to NormalizeIDs.
+func RouteTemplates(templates ...string) RouteNormalizer {
+	split := make([][]string, len(templates))
+	for i, template := range templates {
+		split[i] = strings.Split(template, "/")
+	}
+
+	return func(u *url.URL) string {
+		if u == nil {
+			return ""
+		}
+
+		segments := strings.Split(u.Path, "/")
+		for i, template := range split {
+			if matchesTemplate(template, segments) {
+				return templates[i]
+			}
+		}
+
+		return NormalizeIDs(u)
+	}
+}
+
+func matchesTemplate(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+
+	for i := range template {
+		if !strings.HasPrefix(template[i], ":") && template[i] != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
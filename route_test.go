@@ -0,0 +1,45 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestNormalizeIDsReplacesIdentifierSegments(t *testing.T) {
+	for raw, route := range map[string]string{
+		"http://example.com/drivers/42/orders?page=2":                    "/drivers/:id/orders",
+		"http://example.com/orders/3f2504e0-4f89-11d3-9a0c-0305e82c3301": "/orders/:id",
+		"http://example.com/blobs/0123456789abcdef0123":                  "/blobs/:id",
+		"http://example.com/drivers/v2/profile":                          "/drivers/v2/profile",
+	} {
+		u, _ := url.Parse(raw)
+		assert.Equal(t, route, NormalizeIDs(u), raw)
+	}
+}
+
+func TestRouteTemplatesMatchBeforeFallingBack(t *testing.T) {
+	normalize := RouteTemplates("/drivers/:driver/vehicles/:plate")
+
+	matching, _ := url.Parse("http://example.com/drivers/42/vehicles/B1234XYZ")
+	other, _ := url.Parse("http://example.com/drivers/42")
+
+	assert.Equal(t, "/drivers/:driver/vehicles/:plate", normalize(matching))
+	assert.Equal(t, "/drivers/:id", normalize(other))
+}
+
+func TestRouteIsAddedToTheProfilerLabels(t *testing.T) {
+	var routeLabel string
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		routeLabel, _ = pprof.Label(req.Context(), "meniscus_route")
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithProfilerLabels(), WithRouteNormalizer(NormalizeIDs))
+
+	bulkRequest := newBulkClientWithNRequests(1, "http://example.com/drivers/42")
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, "/drivers/:id", routeLabel)
+}
@@ -0,0 +1,269 @@
+// Package s3 gets, puts and deletes many objects of an S3 compatible bucket at once through a meniscus.BulkClient
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//ErrChecksumMismatch is returned for an object whose body does not match the MD5 checksum sent along with it
+var ErrChecksumMismatch = errors.New("s3: object checksum mismatch")
+
+//Bucket runs bulk operations on the objects of one bucket, zero values get the defaults
+type Bucket struct {
+	Client      *meniscus.BulkClient
+	Endpoint    string // e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000, objects are addressed path style
+	Name        string
+	Region      string // defaults to us-east-1
+	Credentials Credentials
+	Retries     int           // extra attempts for objects failing with a network error, a 5xx or a checksum mismatch
+	Backoff     time.Duration // wait before the first retry, doubled for every following one
+	Workers     int           // defaults to one per object, up to 20
+	Clock       meniscus.Clock
+}
+
+//Object is an object to upload
+type Object struct {
+	Key         string
+	Body        []byte
+	ContentType string // defaults to application/octet-stream
+}
+
+//ObjectResult is the outcome of the operation on one object
+type ObjectResult struct {
+	Key        string
+	Body       []byte // for Get
+	ETag       string
+	StatusCode int
+	Attempts   int
+	Err        error
+}
+
+//ResponseError is the error S3 answered an operation with
+type ResponseError struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("s3: %s: %s (status %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// operation builds the request of one object and reads its response
+type operation struct {
+	key     string
+	method  string
+	body    []byte
+	headers http.Header
+	read    bool
+}
+
+//Get downloads the objects with the given keys, verifying their body against their ETag when it is an MD5 checksum
+func (b *Bucket) Get(keys []string) []ObjectResult {
+	ops := make([]operation, len(keys))
+	for i, key := range keys {
+		ops[i] = operation{key: key, method: http.MethodGet, read: true}
+	}
+
+	return b.run(ops)
+}
+
+//Put uploads objects, sending the MD5 checksum of every body for S3 to verify
+func (b *Bucket) Put(objects []Object) []ObjectResult {
+	ops := make([]operation, len(objects))
+	for i, object := range objects {
+		contentType := object.ContentType
+		if len(contentType) == 0 {
+			contentType = "application/octet-stream"
+		}
+
+		sum := md5.Sum(object.Body)
+		headers := http.Header{}
+		headers.Set("Content-Type", contentType)
+		headers.Set("Content-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+		ops[i] = operation{key: object.Key, method: http.MethodPut, body: object.Body, headers: headers}
+	}
+
+	return b.run(ops)
+}
+
+//Delete removes the objects with the given keys, deleting a missing object succeeds
+func (b *Bucket) Delete(keys []string) []ObjectResult {
+	ops := make([]operation, len(keys))
+	for i, key := range keys {
+		ops[i] = operation{key: key, method: http.MethodDelete}
+	}
+
+	return b.run(ops)
+}
+
+// run fires every operation and then the ones worth retrying again, until they succeed or run out of attempts
+func (b *Bucket) run(ops []operation) []ObjectResult {
+	clock := b.Clock
+	if clock == nil {
+		clock = meniscus.RealClock()
+	}
+
+	results := make([]ObjectResult, len(ops))
+	pending := make([]int, len(ops))
+	for i := range ops {
+		pending[i] = i
+	}
+
+	backoff := b.Backoff
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > 0 && backoff > 0 {
+			<-clock.NewTimer(backoff).C()
+			backoff *= 2
+		}
+
+		var retry []int
+		for i, result := range b.attempt(ops, pending, clock.Now()) {
+			index := pending[i]
+			result.Attempts = attempt + 1
+			results[index] = result
+			if attempt < b.Retries && retryable(result) {
+				retry = append(retry, index)
+			}
+		}
+		pending = retry
+	}
+
+	return results
+}
+
+func (b *Bucket) attempt(ops []operation, pending []int, now time.Time) []ObjectResult {
+	results := make([]ObjectResult, len(pending))
+	workers := b.Workers
+	if workers <= 0 {
+		workers = len(pending)
+		if workers > 20 {
+			workers = 20
+		}
+	}
+
+	bulkRequest := meniscus.NewBulkRequest(nil, workers, workers)
+	for _, index := range pending {
+		req, err := b.request(ops[index], now)
+		if err != nil {
+			// an invalid endpoint fails every object the same way
+			for i, index := range pending {
+				results[i] = ObjectResult{Key: ops[index].key, Err: err}
+			}
+			return results
+		}
+		bulkRequest.AddRequest(req)
+	}
+
+	b.Client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	for _, result := range bulkRequest.Results() {
+		results[result.Index] = ops[pending[result.Index]].result(result)
+	}
+
+	return results
+}
+
+func (b *Bucket) request(op operation, now time.Time) (*http.Request, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(b.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing endpoint: %s", err)
+	}
+
+	endpoint.Path += "/" + b.Name + "/" + op.key
+	endpoint.RawPath = escapePath(endpoint.Path)
+
+	req, err := http.NewRequest(op.method, endpoint.String(), bytes.NewReader(op.body))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range op.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+
+	region := b.Region
+	if len(region) == 0 {
+		region = "us-east-1"
+	}
+
+	b.Credentials.sign(req, region, hashHex(op.body), now)
+	return req, nil
+}
+
+func (op operation) result(result meniscus.Result) ObjectResult {
+	object := ObjectResult{Key: op.key, Err: result.Err}
+	if result.Response == nil {
+		return object
+	}
+
+	object.StatusCode = result.Response.StatusCode
+	object.ETag = strings.Trim(result.Response.Header.Get("ETag"), `"`)
+
+	body, err := ioutil.ReadAll(result.Response.Body)
+	if err != nil {
+		object.Err = fmt.Errorf("error while reading response body: %s", err)
+		return object
+	}
+
+	if object.StatusCode < 200 || object.StatusCode > 299 {
+		if op.method == http.MethodDelete && object.StatusCode == http.StatusNotFound {
+			return object
+		}
+
+		responseErr := &ResponseError{StatusCode: object.StatusCode}
+		if xml.Unmarshal(body, responseErr) != nil || len(responseErr.Code) == 0 {
+			responseErr.Code, responseErr.Message = http.StatusText(object.StatusCode), strings.TrimSpace(string(body))
+		}
+		object.Err = responseErr
+		return object
+	}
+
+	if op.read {
+		object.Body = body
+		if result.Response.Header.Get("X-Amz-Server-Side-Encryption") != "aws:kms" {
+			object.Err = verifyETag(object.ETag, body)
+		}
+	}
+
+	return object
+}
+
+// verifyETag checks body against etag when it looks like an MD5 checksum, which it is not for multipart uploads
+func verifyETag(etag string, body []byte) error {
+	expected, err := hex.DecodeString(etag)
+	if err != nil || len(expected) != md5.Size {
+		return nil
+	}
+
+	if sum := md5.Sum(body); !bytes.Equal(sum[:], expected) {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+func retryable(result ObjectResult) bool {
+	if result.Err == nil {
+		return false
+	}
+
+	if result.Err == ErrChecksumMismatch {
+		return true
+	}
+
+	return result.StatusCode == 0 || result.StatusCode >= 500 || result.StatusCode == http.StatusTooManyRequests
+}
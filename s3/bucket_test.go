@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore keeps objects in memory, failing the first request for keys starting with "flaky"
+// and corrupting the first download of keys starting with "corrupt"
+type fakeStore struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	requests map[string]int
+}
+
+func (s *fakeStore) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(req.URL.Path, "/drivers/")
+	s.requests[key]++
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if strings.HasPrefix(key, "flaky") && s.requests[key] == 1 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`))
+		return
+	}
+
+	switch req.Method {
+	case http.MethodPut:
+		body, _ := ioutil.ReadAll(req.Body)
+		sum := md5.Sum(body)
+		if req.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<Error><Code>BadDigest</Code><Message>The Content-MD5 you specified did not match what we received.</Message></Error>`))
+			return
+		}
+		s.objects[key] = body
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	case http.MethodGet:
+		body, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+		sum := md5.Sum(body)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		if strings.HasPrefix(key, "corrupt") && s.requests[key] == 1 {
+			body = []byte("garbage")
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newBucket(store *fakeStore, retries int) (*Bucket, *httptest.Server) {
+	server := httptest.NewServer(store)
+	client := meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second)
+	return &Bucket{
+		Client:      client,
+		Endpoint:    server.URL,
+		Name:        "drivers",
+		Credentials: Credentials{AccessKeyID: "key", SecretAccessKey: "secret"},
+		Retries:     retries,
+	}, server
+}
+
+func TestPutGetAndDeleteReportEveryObject(t *testing.T) {
+	store := &fakeStore{objects: map[string][]byte{}, requests: map[string]int{}}
+	bucket, server := newBucket(store, 1)
+	defer server.Close()
+
+	puts := bucket.Put([]Object{{Key: "photos/1 a.jpg", Body: []byte("first")}, {Key: "flaky/2.jpg", Body: []byte("second")}})
+	require.Len(t, puts, 2)
+	assert.NoError(t, puts[0].Err)
+	assert.Equal(t, 1, puts[0].Attempts)
+	assert.NoError(t, puts[1].Err)
+	assert.Equal(t, 2, puts[1].Attempts)
+	assert.Equal(t, map[string][]byte{"photos/1 a.jpg": []byte("first"), "flaky/2.jpg": []byte("second")}, store.objects)
+
+	gets := bucket.Get([]string{"photos/1 a.jpg", "missing.jpg"})
+	assert.Equal(t, []byte("first"), gets[0].Body)
+	assert.NoError(t, gets[0].Err)
+	assert.Equal(t, &ResponseError{StatusCode: 404, Code: "NoSuchKey", Message: "The specified key does not exist."}, gets[1].Err)
+	assert.Equal(t, 1, gets[1].Attempts)
+
+	deletes := bucket.Delete([]string{"photos/1 a.jpg", "missing.jpg"})
+	assert.NoError(t, deletes[0].Err)
+	assert.NoError(t, deletes[1].Err)
+	assert.Empty(t, store.objects["photos/1 a.jpg"])
+}
+
+func TestGetRetriesObjectsFailingTheirChecksum(t *testing.T) {
+	store := &fakeStore{objects: map[string][]byte{"corrupt.txt": []byte("body")}, requests: map[string]int{}}
+	bucket, server := newBucket(store, 1)
+	defer server.Close()
+
+	results := bucket.Get([]string{"corrupt.txt"})
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, []byte("body"), results[0].Body)
+	assert.Equal(t, 2, results[0].Attempts)
+}
+
+func TestFailuresAreReturnedOnceRetriesAreExhausted(t *testing.T) {
+	store := &fakeStore{objects: map[string][]byte{}, requests: map[string]int{}}
+	bucket, server := newBucket(store, 0)
+	defer server.Close()
+
+	results := bucket.Put([]Object{{Key: "flaky.txt", Body: []byte("body")}})
+
+	assert.Equal(t, &ResponseError{StatusCode: 503, Code: "SlowDown", Message: "Please reduce your request rate."}, results[0].Err)
+	assert.Equal(t, 1, results[0].Attempts)
+}
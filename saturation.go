@@ -0,0 +1,121 @@
+package meniscus
+
+import (
+	"sync"
+	"time"
+)
+
+//SaturationKind tells which threshold a SaturationEvent crossed
+type SaturationKind int
+
+const (
+	//SaturationQueueWait is fired when a request waited longer than SaturationThresholds.QueueWait for a fire worker
+	SaturationQueueWait SaturationKind = iota
+	//SaturationUtilization is fired when the busy fire workers reach SaturationThresholds.Utilization with requests still waiting
+	SaturationUtilization
+)
+
+//SaturationThresholds configures when saturation events are fired, zero values get the defaults
+type SaturationThresholds struct {
+	QueueWait   time.Duration // defaults to 100ms
+	Utilization float64       // fraction of the fire workers busy, defaults to 1
+}
+
+func (t SaturationThresholds) withDefaults() SaturationThresholds {
+	if t.QueueWait <= 0 {
+		t.QueueWait = 100 * time.Millisecond
+	}
+
+	if t.Utilization <= 0 {
+		t.Utilization = 1
+	}
+
+	return t
+}
+
+//SaturationEvent reports a bulk whose fire workers cannot keep up with its requests
+type SaturationEvent struct {
+	Kind        SaturationKind
+	Client      string // the name of the client, see WithName
+	BulkID      string
+	Index       int           // of the request whose pick up crossed the threshold
+	QueueWait   time.Duration // of that request
+	BusyWorkers int
+	Workers     int
+	Waiting     int // requests not picked up by a worker yet
+}
+
+//Utilization is the fraction of the fire workers that were busy
+func (e SaturationEvent) Utilization() float64 {
+	if e.Workers == 0 {
+		return 0
+	}
+
+	return float64(e.BusyWorkers) / float64(e.Workers)
+}
+
+// saturationMonitor follows the fire workers of one run of a bulk, firing every kind of event at most once
+type saturationMonitor struct {
+	bulkID     string
+	client     string
+	workers    int
+	thresholds SaturationThresholds
+	callback   func(SaturationEvent)
+
+	mu      sync.Mutex
+	busy    int
+	waiting int
+	fired   map[SaturationKind]bool
+}
+
+func newSaturationMonitor(bulkRequest *RoundTrip, thresholds SaturationThresholds, callback func(SaturationEvent)) *saturationMonitor {
+	return &saturationMonitor{
+		bulkID:     bulkRequest.id,
+		workers:    bulkRequest.fireWorkers(),
+		thresholds: thresholds.withDefaults(),
+		callback:   callback,
+		waiting:    len(bulkRequest.requests),
+		fired:      map[SaturationKind]bool{},
+	}
+}
+
+// pickedUp records a worker starting on the request at index after it waited for wait
+func (m *saturationMonitor) pickedUp(index int, wait time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	m.busy++
+	m.waiting--
+	event := SaturationEvent{Client: m.client, BulkID: m.bulkID, Index: index, QueueWait: wait, BusyWorkers: m.busy, Workers: m.workers, Waiting: m.waiting}
+
+	var events []SaturationEvent
+	if wait > m.thresholds.QueueWait && !m.fired[SaturationQueueWait] {
+		m.fired[SaturationQueueWait] = true
+		event.Kind = SaturationQueueWait
+		events = append(events, event)
+	}
+
+	if m.waiting > 0 && event.Utilization() >= m.thresholds.Utilization && !m.fired[SaturationUtilization] {
+		m.fired[SaturationUtilization] = true
+		event.Kind = SaturationUtilization
+		events = append(events, event)
+	}
+	m.mu.Unlock()
+
+	for _, event := range events {
+		m.callback(event)
+	}
+}
+
+// done records a worker being done with its request
+func (m *saturationMonitor) done() {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	m.busy--
+	m.mu.Unlock()
+}
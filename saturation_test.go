@@ -0,0 +1,50 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestSaturationCallbackReportsUnderProvisionedWorkers(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+
+	var mu sync.Mutex
+	var events []SaturationEvent
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue,
+		WithSaturationCallback(func(event SaturationEvent) {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		}),
+		WithSaturationThresholds(SaturationThresholds{QueueWait: MockServerSlowResponseSleep / 2}))
+
+	query := url.Values{}
+	query.Set("kind", "slow")
+	newBulk := func(workers int) *RoundTrip {
+		bulkRequest := NewBulkRequest(nil, workers, workers).SetID("saturated")
+		for i := 0; i < 3; i++ {
+			req, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", query), nil)
+			require.NoError(t, err, "no errors")
+			bulkRequest.AddRequest(req)
+		}
+		return bulkRequest
+	}
+
+	client.Do(newBulk(3))
+	assert.Empty(t, events)
+
+	client.Do(newBulk(1))
+	require.Len(t, events, 2)
+	assert.Equal(t, SaturationUtilization, events[0].Kind)
+	assert.Equal(t, "saturated", events[0].BulkID)
+	assert.Equal(t, 1.0, events[0].Utilization())
+	assert.Equal(t, 2, events[0].Waiting)
+	assert.Equal(t, SaturationQueueWait, events[1].Kind)
+	assert.Equal(t, 1, events[1].Index)
+	assert.True(t, events[1].QueueWait >= MockServerSlowResponseSleep)
+}
@@ -0,0 +1,74 @@
+package meniscus
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+)
+
+//ShardKey returns the key a request is sharded by, requests with the same key landing in the same shard
+type ShardKey func(request *http.Request) string
+
+//HostKey shards requests by host, e.g. for each replica to keep its connections to a few hosts
+func HostKey(request *http.Request) string {
+	if request == nil || request.URL == nil {
+		return ""
+	}
+
+	return request.URL.Host
+}
+
+//Shard returns a new bulk holding the requests of r that fall in shard out of shards, numbered from 0,
+//so that replicas running shard i of N each send their part of a large bulk. Requests are split by their index,
+//round robin, or by the FNV-1a hash of their key when key is not nil. The split only depends on the requests,
+//every replica building the same bulk gets disjoint shards covering it.
+//The results of the new bulk keep the indices the requests had in r, and the settings of the requests,
+//variables, tenant, workers and context are carried over as with RetryFailed.
+//Shard fails with an error wrapping ErrInvalidShard when shards is not positive or shard is not in [0, shards),
+//e.g. for a replica deployed with a bad configuration.
+func (r *RoundTrip) Shard(shard, shards int, key ShardKey) (*RoundTrip, error) {
+	if shards <= 0 || shard < 0 || shard >= shards {
+		return nil, &codedError{code: CodeBulkErr, msg: fmt.Sprintf("invalid shard: shard %d out of %d shards", shard, shards), cause: ErrInvalidShard}
+	}
+
+	part := NewBulkRequest(nil, r.fireRequestsWorkers, r.processResponseWorkers).SetTenant(r.tenant)
+	part.parent = r.parent
+	part.variables = r.variables
+	part.retryPass = r.retryPass
+
+	for index, request := range r.requests {
+		if shardOf(r.origin(index), request, shards, key) != shard {
+			continue
+		}
+
+		position := len(part.requests)
+		if err, ok := r.invalid[index]; ok {
+			if part.invalid == nil {
+				part.invalid = map[int]error{}
+			}
+			part.invalid[position] = err
+		}
+
+		if attempts, ok := r.attempts[index]; ok {
+			if part.attempts == nil {
+				part.attempts = map[int]int{}
+			}
+			part.attempts[position] = attempts
+		}
+
+		r.carry(index, request, part)
+	}
+
+	return part, nil
+}
+
+// shardOf returns the shard of the request at index
+func shardOf(index int, request *http.Request, shards int, key ShardKey) int {
+	if key == nil {
+		return index % shards
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key(request)))
+	return int(hash.Sum32() % uint32(shards))
+}
@@ -0,0 +1,94 @@
+package meniscus
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func bulkOf(t *testing.T, urls ...string) *RoundTrip {
+	bulkRequest := NewBulkRequest(nil, 2, 2)
+	for i, url := range urls {
+		bulkRequest.AddTaggedRequest(mustRequest(t, url), Tags{"position": string(rune('a' + i))})
+	}
+
+	return bulkRequest
+}
+
+func TestShardSplitsABulkByIndex(t *testing.T) {
+	urls := []string{"http://example.com/0", "http://example.com/1", "http://example.com/2", "http://example.com/3", "http://example.com/4"}
+
+	var indices [][]int
+	for shard := 0; shard < 2; shard++ {
+		calls := map[string]int{}
+		part, err := bulkOf(t, urls...).Shard(shard, 2, nil)
+		assert.NoError(t, err)
+		_, errs := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue).Do(part)
+		defer part.CloseAllResponses()
+
+		assert.Equal(t, make([]error, len(calls)), errs)
+		var shardIndices []int
+		for _, result := range part.Results() {
+			shardIndices = append(shardIndices, result.Index)
+			assert.Equal(t, string(rune('a'+result.Index)), result.Tags["position"])
+			assert.Equal(t, 1, calls[result.Request.URL.Path])
+		}
+		indices = append(indices, shardIndices)
+		assert.Equal(t, len(calls), part.Counts().Succeeded)
+		assert.Equal(t, 0, part.Counts().Retried)
+	}
+
+	assert.Equal(t, [][]int{{0, 2, 4}, {1, 3}}, indices)
+}
+
+func TestShardByKeyKeepsRequestsWithTheSameKeyTogether(t *testing.T) {
+	urls := []string{"http://a.example.com/0", "http://b.example.com/1", "http://a.example.com/2", "http://c.example.com/3", "http://b.example.com/4"}
+
+	hosts := map[string]int{}
+	total := 0
+	for shard := 0; shard < 3; shard++ {
+		part, err := bulkOf(t, urls...).Shard(shard, 3, HostKey)
+		assert.NoError(t, err)
+		for _, request := range part.requests {
+			if seen, ok := hosts[request.URL.Host]; ok {
+				assert.Equal(t, seen, shard)
+			}
+			hosts[request.URL.Host] = shard
+		}
+		total += len(part.requests)
+	}
+
+	assert.Equal(t, len(urls), total)
+	assert.Len(t, hosts, 3)
+}
+
+func TestShardKeepsInvalidRequestsAsTombstones(t *testing.T) {
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddGet("http://example.com/a", nil).
+		AddGet("http://example.com/b", nil).
+		Filter(func(i int, _ *http.Request) bool { return i == 0 })
+
+	part, _ := bulkRequest.Shard(1, 2, nil)
+	_, errs := NewBulkHTTPClient(countingClient(map[string]int{}), NonFailingTimeoutValue).Do(part)
+
+	assert.Equal(t, []error{ErrRequestFiltered}, errs)
+	assert.Equal(t, 1, part.Results()[0].Index)
+	whole, _ := bulkRequest.Shard(0, 1, nil)
+	assert.Len(t, whole.requests, 2)
+}
+
+func TestShardFailsOnAShardOutOfRange(t *testing.T) {
+	bulkRequest := bulkOf(t, "http://example.com/0", "http://example.com/1")
+
+	for _, shard := range [][2]int{{0, 0}, {-1, 2}, {2, 2}} {
+		part, err := bulkRequest.Shard(shard[0], shard[1], nil)
+		assert.Nil(t, part)
+		assert.True(t, errors.Is(err, ErrInvalidShard))
+		assert.Equal(t, CodeBulkErr, Code(err))
+	}
+
+	_, err := bulkRequest.Shard(0, 1, HostKey)
+	assert.NoError(t, err)
+	assert.Empty(t, HostKey(nil))
+}
@@ -0,0 +1,267 @@
+package meniscus
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+type tenantContextKey struct{}
+
+func withTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+func tenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+//TenantQuota limits the share of a SharedPool a single tenant can take, zero values mean no limit
+type TenantQuota struct {
+	MaxConcurrent int // requests of the tenant in flight at once
+	MaxQueued     int // requests of the tenant waiting for a slot, beyond which ErrTenantQueueFull is returned
+}
+
+//SharedPool caps the requests in flight across every client and bulk using its middleware.
+//Slots are split between tenants (see RoundTrip.SetTenant) according to their quotas,
+//and freed slots are handed to waiting tenants in turn so a large bulk cannot starve the others.
+type SharedPool struct {
+	mu           sync.Mutex
+	capacity     int
+	inUse        int
+	defaultQuota TenantQuota
+	quotas       map[string]TenantQuota
+	tenants      map[string]*tenantState
+	order        []string
+	next         int
+}
+
+type tenantState struct {
+	inUse int
+	queue []chan struct{}
+}
+
+//TenantStats is the current usage of a SharedPool by one tenant
+type TenantStats struct {
+	InFlight int
+	Queued   int
+}
+
+//NewSharedPool returns a pool of capacity slots, tenants without a quota of their own get defaultQuota
+func NewSharedPool(capacity int, defaultQuota TenantQuota) *SharedPool {
+	return &SharedPool{
+		capacity:     capacity,
+		defaultQuota: defaultQuota,
+		quotas:       map[string]TenantQuota{},
+		tenants:      map[string]*tenantState{},
+	}
+}
+
+//SetQuota overrides the quota of tenant
+func (p *SharedPool) SetQuota(tenant string, quota TenantQuota) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.quotas[tenant] = quota
+	p.dispatch()
+}
+
+//Stats returns the usage of every tenant with requests in flight or queued,
+//idle tenants being forgotten by the pool
+func (p *SharedPool) Stats() map[string]TenantStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make(map[string]TenantStats, len(p.tenants))
+	for tenant, state := range p.tenants {
+		stats[tenant] = TenantStats{InFlight: state.inUse, Queued: len(state.queue)}
+	}
+
+	return stats
+}
+
+//Acquire waits for a slot for tenant, it fails when ctx is done first or the tenant queue is full
+func (p *SharedPool) Acquire(ctx context.Context, tenant string) error {
+	p.mu.Lock()
+	state := p.tenant(tenant)
+	quota := p.quota(tenant)
+
+	if len(state.queue) == 0 && p.canRun(state, quota) {
+		p.grant(state)
+		p.mu.Unlock()
+		return nil
+	}
+
+	if quota.MaxQueued > 0 && len(state.queue) >= quota.MaxQueued {
+		p.mu.Unlock()
+		return ErrTenantQueueFull
+	}
+
+	ready := make(chan struct{})
+	state.queue = append(state.queue, ready)
+	p.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, waiter := range state.queue {
+		if waiter == ready {
+			state.queue = append(state.queue[:i], state.queue[i+1:]...)
+			p.forget(tenant, state)
+			return ctx.Err()
+		}
+	}
+
+	// the slot was granted while giving up on it
+	p.release(state)
+	p.forget(tenant, state)
+	return ctx.Err()
+}
+
+//Release frees a slot previously acquired for tenant
+func (p *SharedPool) Release(tenant string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := p.tenant(tenant)
+	p.release(state)
+	p.forget(tenant, state)
+}
+
+//Middleware holds a pool slot from the moment a request is fired until its response body is closed
+func (p *SharedPool) Middleware() Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			tenant := tenantFromContext(req.Context())
+			if err := p.Acquire(req.Context(), tenant); err != nil {
+				return nil, err
+			}
+
+			resp, err := next.Do(req)
+			if err != nil {
+				p.Release(tenant)
+				return nil, err
+			}
+
+			var once sync.Once
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() { once.Do(func() { p.Release(tenant) }) }}
+			return resp, nil
+		})
+	}
+}
+
+func (p *SharedPool) tenant(tenant string) *tenantState {
+	state, ok := p.tenants[tenant]
+	if !ok {
+		state = &tenantState{}
+		p.tenants[tenant] = state
+		p.order = append(p.order, tenant)
+	}
+
+	return state
+}
+
+// forget drops the state of a tenant left with nothing in flight and nothing queued,
+// so that a pool serving many short lived tenants does not grow without bound
+func (p *SharedPool) forget(tenant string, state *tenantState) {
+	if state.inUse > 0 || len(state.queue) > 0 || p.tenants[tenant] != state {
+		return
+	}
+
+	delete(p.tenants, tenant)
+	for i, name := range p.order {
+		if name != tenant {
+			continue
+		}
+
+		p.order = append(p.order[:i], p.order[i+1:]...)
+		if i < p.next {
+			p.next--
+		}
+		if p.next >= len(p.order) {
+			p.next = 0
+		}
+		return
+	}
+}
+
+func (p *SharedPool) quota(tenant string) TenantQuota {
+	if quota, ok := p.quotas[tenant]; ok {
+		return quota
+	}
+
+	return p.defaultQuota
+}
+
+func (p *SharedPool) canRun(state *tenantState, quota TenantQuota) bool {
+	if p.capacity > 0 && p.inUse >= p.capacity {
+		return false
+	}
+
+	return quota.MaxConcurrent <= 0 || state.inUse < quota.MaxConcurrent
+}
+
+func (p *SharedPool) grant(state *tenantState) {
+	p.inUse++
+	state.inUse++
+}
+
+func (p *SharedPool) release(state *tenantState) {
+	p.inUse--
+	state.inUse--
+	p.dispatch()
+}
+
+// dispatch hands free slots to waiting tenants one at a time in round robin order
+func (p *SharedPool) dispatch() {
+	for {
+		granted := false
+		for i := 0; i < len(p.order); i++ {
+			tenant := p.order[(p.next+i)%len(p.order)]
+			state := p.tenants[tenant]
+			if len(state.queue) == 0 || !p.canRun(state, p.quota(tenant)) {
+				continue
+			}
+
+			p.grant(state)
+			close(state.queue[0])
+			state.queue = state.queue[1:]
+			p.next = (p.next + i + 1) % len(p.order)
+			granted = true
+			break
+		}
+
+		if !granted {
+			return
+		}
+	}
+}
+
+// releasingBody calls release once the body has been read to the end or closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,97 @@
+package meniscus
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSharedPoolEnforcesTenantConcurrency(t *testing.T) {
+	pool := NewSharedPool(10, TenantQuota{})
+	pool.SetQuota("batch", TenantQuota{MaxConcurrent: 1, MaxQueued: 1})
+	ctx := context.Background()
+
+	require.NoError(t, pool.Acquire(ctx, "batch"))
+
+	acquired := make(chan error)
+	go func() { acquired <- pool.Acquire(ctx, "batch") }()
+
+	assert.Eventually(t, func() bool { return pool.Stats()["batch"].Queued == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, ErrTenantQueueFull, pool.Acquire(ctx, "batch"))
+	assert.NoError(t, pool.Acquire(ctx, "interactive"))
+
+	pool.Release("batch")
+	assert.NoError(t, <-acquired)
+	assert.Equal(t, TenantStats{InFlight: 1}, pool.Stats()["batch"])
+}
+
+func TestSharedPoolHandsFreedSlotsToTenantsInTurn(t *testing.T) {
+	pool := NewSharedPool(1, TenantQuota{})
+	ctx := context.Background()
+	require.NoError(t, pool.Acquire(ctx, "batch"))
+
+	order := make(chan string, 3)
+	waitFor := func(tenant string, queued int) {
+		go func() {
+			pool.Acquire(ctx, tenant)
+			order <- tenant
+		}()
+		assert.Eventually(t, func() bool { return pool.Stats()[tenant].Queued == queued }, time.Second, time.Millisecond)
+	}
+	waitFor("batch", 1)
+	waitFor("batch", 2)
+	waitFor("interactive", 1)
+
+	pool.Release("batch")
+	first := <-order
+	pool.Release(first)
+	second := <-order
+
+	assert.ElementsMatch(t, []string{"batch", "interactive"}, []string{first, second})
+}
+
+func TestSharedPoolGivesUpWhenTheContextIsDone(t *testing.T) {
+	pool := NewSharedPool(1, TenantQuota{})
+	require.NoError(t, pool.Acquire(context.Background(), "a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, pool.Acquire(ctx, "b"))
+	assert.NotContains(t, pool.Stats(), "b")
+}
+
+func TestSharedPoolForgetsIdleTenants(t *testing.T) {
+	pool := NewSharedPool(2, TenantQuota{})
+	ctx := context.Background()
+	require.NoError(t, pool.Acquire(ctx, "a"))
+	require.NoError(t, pool.Acquire(ctx, "b"))
+
+	pool.Release("a")
+
+	assert.Equal(t, map[string]TenantStats{"b": {InFlight: 1}}, pool.Stats())
+	assert.Equal(t, []string{"b"}, pool.order)
+
+	pool.Release("b")
+	assert.Empty(t, pool.Stats())
+	assert.Empty(t, pool.order)
+	assert.NoError(t, pool.Acquire(ctx, "a"))
+}
+
+func TestBulkClientsShareThePoolSlots(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	pool := NewSharedPool(1, TenantQuota{})
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithSharedPool(pool))
+
+	bulkRequest := newBulkClientWithNRequests(3, server.URL).SetTenant("team")
+	_, errs := client.Do(bulkRequest)
+
+	assert.Equal(t, []error{nil, nil, nil}, errs)
+	assert.NotContains(t, pool.Stats(), "team")
+	bulkRequest.CloseAllResponses()
+}
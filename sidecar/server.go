@@ -0,0 +1,233 @@
+// Package sidecar serves a BulkClient over HTTP: bulks are posted as JSON specs and their results are streamed back
+// as they come, as JSON lines or server-sent events, so that services in any language can use meniscus as a fan-out sidecar
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	//DefaultMaxRequests caps the requests of a bulk when Server.MaxRequests is zero
+	DefaultMaxRequests = 10000
+	//DefaultMaxWorkers caps the workers of a bulk when Server.MaxWorkers is zero
+	DefaultMaxWorkers = 100
+	//DefaultMaxBodyBytes caps the body bytes sent back per result when Server.MaxBodyBytes is zero
+	DefaultMaxBodyBytes = 64 << 10
+	//DefaultMaxBulkBytes caps the size of a posted bulk when Server.MaxBulkBytes is zero
+	DefaultMaxBulkBytes = 32 << 20
+	//DefaultMaxRetries caps the retries of a request when Server.MaxRetries is zero
+	DefaultMaxRetries = 3
+	//DefaultMaxTimeout caps the timeouts of a bulk and of its requests when Server.MaxTimeout is zero
+	DefaultMaxTimeout = time.Minute
+)
+
+//Request is a request of a Bulk, its Body being base64 encoded in JSON
+type Request struct {
+	Method    string            `json:"method,omitempty"` // defaults to GET
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      []byte            `json:"body,omitempty"`
+	TimeoutMS int               `json:"timeout_ms,omitempty"` // bounds every attempt, see meniscus.RequestSpec, capped by the server
+	Retries   int               `json:"retries,omitempty"`    // capped by the server
+	Tags      meniscus.Tags     `json:"tags,omitempty"`
+}
+
+//Bulk is the JSON spec of a bulk posted to a Server
+type Bulk struct {
+	ID        string    `json:"id,omitempty"`
+	Requests  []Request `json:"requests"`
+	Workers   int       `json:"workers,omitempty"`    // fire and process workers, defaulting as for meniscus.NewBulkRequest, capped by the server
+	TimeoutMS int       `json:"timeout_ms,omitempty"` // shortens the timeout of the client for this bulk, capped by the server
+	BodyBytes int       `json:"body_bytes,omitempty"` // bytes of every response body sent back, none by default, capped by the server
+}
+
+//Server runs the bulks posted to it through Client. Every result is sent back as soon as it is processed,
+//in the record format of meniscus.Results.WriteJSONL: as JSON lines, or as "result" server-sent events followed by
+//a "done" event holding the meniscus.Counts of the bulk when the request accepts text/event-stream.
+//A bulk is cancelled when its caller goes away. The size of a posted bulk, and the workers, body bytes, retries
+//and timeouts it asks for are capped, so that a single caller cannot make the server spawn unbounded goroutines,
+//hold requests for long or send back whole bodies.
+type Server struct {
+	Client       *meniscus.BulkClient
+	MaxRequests  int           // defaults to DefaultMaxRequests
+	MaxWorkers   int           // defaults to DefaultMaxWorkers
+	MaxBodyBytes int           // defaults to DefaultMaxBodyBytes
+	MaxBulkBytes int64         // defaults to DefaultMaxBulkBytes
+	MaxRetries   int           // defaults to DefaultMaxRetries
+	MaxTimeout   time.Duration // defaults to DefaultMaxTimeout
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "bulks are posted", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var bulk Bulk
+	err := json.NewDecoder(http.MaxBytesReader(w, req.Body, s.maxBulkBytes())).Decode(&bulk)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("bulk exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid bulk: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if len(bulk.Requests) > s.maxRequests() {
+		http.Error(w, fmt.Sprintf("bulk of %d requests exceeds %d", len(bulk.Requests), s.maxRequests()), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	bulk = s.clamp(bulk)
+	ctx := req.Context()
+	if bulk.TimeoutMS > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(bulk.TimeoutMS)*time.Millisecond)
+		defer cancel()
+	}
+
+	bulkRequest := newBulkRequest(bulk).SetContext(ctx)
+	events := strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+	if events {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	for result := range s.Client.DoStream(bulkRequest) {
+		var record bytes.Buffer
+		meniscus.Results{result}.WriteJSONL(&record, bulk.BodyBytes)
+		if result.Response != nil {
+			result.Response.Body.Close()
+		}
+
+		if events {
+			fmt.Fprintf(w, "event: result\ndata: %s\n", record.Bytes())
+		} else {
+			w.Write(record.Bytes())
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if events {
+		counts, _ := json.Marshal(bulkRequest.Counts())
+		fmt.Fprintf(w, "event: done\ndata: %s\n\n", counts)
+	}
+}
+
+func (s *Server) maxRequests() int {
+	if s.MaxRequests > 0 {
+		return s.MaxRequests
+	}
+
+	return DefaultMaxRequests
+}
+
+// clamp caps the workers of bulk to its requests and to the server limit, and its body bytes, retries and timeouts
+// to the server limits. Negative values fall back to the defaults of a bulk.
+func (s *Server) clamp(bulk Bulk) Bulk {
+	maxTimeoutMS := int(s.maxTimeout() / time.Millisecond)
+	bulk.Workers = bound(bound(bulk.Workers, len(bulk.Requests)), s.maxWorkers())
+	bulk.BodyBytes = bound(bulk.BodyBytes, s.maxBodyBytes())
+	bulk.TimeoutMS = bound(bulk.TimeoutMS, maxTimeoutMS)
+
+	requests := make([]Request, len(bulk.Requests))
+	for i, request := range bulk.Requests {
+		request.Retries = bound(request.Retries, s.maxRetries())
+		request.TimeoutMS = bound(request.TimeoutMS, maxTimeoutMS)
+		requests[i] = request
+	}
+	bulk.Requests = requests
+
+	return bulk
+}
+
+func (s *Server) maxWorkers() int {
+	if s.MaxWorkers > 0 {
+		return s.MaxWorkers
+	}
+
+	return DefaultMaxWorkers
+}
+
+func (s *Server) maxBodyBytes() int {
+	if s.MaxBodyBytes > 0 {
+		return s.MaxBodyBytes
+	}
+
+	return DefaultMaxBodyBytes
+}
+
+func (s *Server) maxBulkBytes() int64 {
+	if s.MaxBulkBytes > 0 {
+		return s.MaxBulkBytes
+	}
+
+	return DefaultMaxBulkBytes
+}
+
+func (s *Server) maxRetries() int {
+	if s.MaxRetries > 0 {
+		return s.MaxRetries
+	}
+
+	return DefaultMaxRetries
+}
+
+func (s *Server) maxTimeout() time.Duration {
+	if s.MaxTimeout > 0 {
+		return s.MaxTimeout
+	}
+
+	return DefaultMaxTimeout
+}
+
+// bound returns value within [0, limit]
+func bound(value, limit int) int {
+	if value < 0 {
+		return 0
+	}
+	if value > limit {
+		return limit
+	}
+
+	return value
+}
+
+func newBulkRequest(bulk Bulk) *meniscus.RoundTrip {
+	bulkRequest := meniscus.NewBulkRequest(nil, bulk.Workers, bulk.Workers)
+	if len(bulk.ID) != 0 {
+		bulkRequest.SetID(bulk.ID)
+	}
+
+	for _, request := range bulk.Requests {
+		bulkRequest.AddSpec(meniscus.RequestSpec{
+			Method:  request.Method,
+			URL:     request.URL,
+			Headers: request.Headers,
+			Body:    request.Body,
+			Timeout: time.Duration(request.TimeoutMS) * time.Millisecond,
+			Retries: request.Retries,
+			Tags:    request.Tags,
+		})
+	}
+
+	return bulkRequest
+}
@@ -0,0 +1,133 @@
+package sidecar
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, url, accept, bulk string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(bulk))
+	require.NoError(t, err)
+	req.Header.Set("Accept", accept)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+type record struct {
+	Index  int           `json:"index"`
+	Status int           `json:"status"`
+	Body   string        `json:"body"`
+	Tags   meniscus.Tags `json:"tags"`
+}
+
+func TestServerStreamsResultsAsJSONLines(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Write(body)
+	}))
+	defer downstream.Close()
+	sidecar := httptest.NewServer(&Server{Client: meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second)})
+	defer sidecar.Close()
+
+	resp := post(t, sidecar.URL, "application/x-ndjson", `{"requests":[
+		{"url":"`+downstream.URL+`/slow","method":"POST","body":"c2xvdw=="},
+		{"url":"`+downstream.URL+`/fast","method":"POST","body":"ZmFzdA==","tags":{"kind":"fast"}}
+	],"workers":2,"body_bytes":16}`)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	var records []record
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var r record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+
+	assert.Equal(t, []record{
+		{Index: 1, Status: http.StatusOK, Body: "fast", Tags: meniscus.Tags{"kind": "fast"}},
+		{Index: 0, Status: http.StatusOK, Body: "slow"},
+	}, records)
+}
+
+func TestServerStreamsResultsAsServerSentEvents(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer downstream.Close()
+	sidecar := httptest.NewServer(&Server{Client: meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second)})
+	defer sidecar.Close()
+
+	resp := post(t, sidecar.URL, "text/event-stream", `{"id":"sse","requests":[{"url":"`+downstream.URL+`"}]}`)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `event: result
+data: {"index":0,"method":"GET","url":"`+downstream.URL+`","status":202,"duration_ms":`, string(body[:strings.Index(string(body), "duration_ms")+13]))
+	assert.True(t, strings.HasSuffix(string(body), "}\n\nevent: done\n"+
+		`data: {"Succeeded":1,"Failed":0,"Ignored":0,"Skipped":0,"Retried":0}`+"\n\n"))
+}
+
+func TestServerRejectsInvalidBulks(t *testing.T) {
+	sidecar := httptest.NewServer(&Server{Client: meniscus.NewBulkHTTPClient(http.DefaultClient, time.Second), MaxRequests: 1})
+	defer sidecar.Close()
+
+	for bulk, status := range map[string]int{
+		`{"requests":`: http.StatusBadRequest,
+		`{"requests":[{"url":"http://example.com"},{"url":"http://example.com"}]}`: http.StatusRequestEntityTooLarge,
+	} {
+		resp := post(t, sidecar.URL, "", bulk)
+		resp.Body.Close()
+		assert.Equal(t, status, resp.StatusCode)
+	}
+
+	resp, err := http.Get(sidecar.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestServerCapsWhatBulksAskFor(t *testing.T) {
+	server := &Server{MaxWorkers: 4, MaxBodyBytes: 16, MaxRetries: 2, MaxTimeout: time.Second}
+	requests := make([]Request, 8)
+
+	assert.Equal(t, 4, server.clamp(Bulk{Requests: requests, Workers: 1000000}).Workers)
+	assert.Equal(t, 2, server.clamp(Bulk{Requests: requests[:2], Workers: 3}).Workers)
+	assert.Equal(t, 0, server.clamp(Bulk{Requests: requests, Workers: -1}).Workers)
+	assert.Equal(t, 16, server.clamp(Bulk{Requests: requests, BodyBytes: 1 << 30}).BodyBytes)
+	assert.Equal(t, 8, server.clamp(Bulk{Requests: requests, BodyBytes: 8}).BodyBytes)
+	assert.Equal(t, 1000, server.clamp(Bulk{TimeoutMS: 1 << 30}).TimeoutMS)
+
+	clamped := server.clamp(Bulk{Requests: []Request{{Retries: 1000, TimeoutMS: 1 << 30}, {Retries: 1, TimeoutMS: 10}, {Retries: -1}}})
+	assert.Equal(t, []Request{{Retries: 2, TimeoutMS: 1000}, {Retries: 1, TimeoutMS: 10}, {}}, clamped.Requests)
+
+	defaults := &Server{}
+	assert.Equal(t, DefaultMaxWorkers, defaults.clamp(Bulk{Requests: make([]Request, 1000), Workers: 1000}).Workers)
+	assert.Equal(t, DefaultMaxBodyBytes, defaults.clamp(Bulk{BodyBytes: 1 << 30}).BodyBytes)
+	assert.Equal(t, DefaultMaxRetries, defaults.clamp(Bulk{Requests: []Request{{Retries: 1000}}}).Requests[0].Retries)
+}
+
+func TestServerRejectsBulksLargerThanMaxBulkBytes(t *testing.T) {
+	sidecar := httptest.NewServer(&Server{Client: meniscus.NewBulkHTTPClient(http.DefaultClient, time.Second), MaxBulkBytes: 64})
+	defer sidecar.Close()
+
+	resp := post(t, sidecar.URL, "", `{"requests":[{"url":"http://example.com/`+strings.Repeat("a", 64)+`"}]}`)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
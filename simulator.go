@@ -0,0 +1,153 @@
+package meniscus
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//LatencyModel draws the latency of a simulated request from random
+type LatencyModel func(random Random) time.Duration
+
+//ConstantLatency answers every request after d
+func ConstantLatency(d time.Duration) LatencyModel {
+	return func(Random) time.Duration {
+		return d
+	}
+}
+
+//UniformLatency draws latencies evenly between min and max
+func UniformLatency(min, max time.Duration) LatencyModel {
+	return func(random Random) time.Duration {
+		return min + time.Duration(random()*float64(max-min))
+	}
+}
+
+//NormalLatency draws latencies from a normal distribution, negative draws being answered at once
+func NormalLatency(mean, stddev time.Duration) LatencyModel {
+	return func(random Random) time.Duration {
+		latency := float64(mean) + standardNormal(random)*float64(stddev)
+		if latency < 0 {
+			return 0
+		}
+
+		return time.Duration(latency)
+	}
+}
+
+//LogNormalLatency draws latencies from a log-normal distribution around median, sigma widening its long tail,
+//which matches most services better than a normal distribution
+func LogNormalLatency(median time.Duration, sigma float64) LatencyModel {
+	return func(random Random) time.Duration {
+		return time.Duration(float64(median) * math.Exp(sigma*standardNormal(random)))
+	}
+}
+
+// standardNormal draws from the standard normal distribution with the Box-Muller transform
+func standardNormal(random Random) float64 {
+	return math.Sqrt(-2*math.Log(1-random())) * math.Cos(2*math.Pi*random())
+}
+
+//HostProfile describes how a simulated host answers. Percentages are in the range [0, 100],
+//as in ChaosConfig, and requests failing with an error never get a response.
+type HostProfile struct {
+	Latency        LatencyModel // defaults to answering at once
+	MaxConcurrency int          // requests served at once, later ones queue for a slot, unlimited when not positive
+
+	ErrorPercentage float64
+	Error           error // defaults to ErrInjectedFault
+
+	StatusPercentage float64
+	StatusCode       int // defaults to http.StatusServiceUnavailable, other requests get http.StatusOK
+}
+
+//SimulatorConfig configures a Simulator
+type SimulatorConfig struct {
+	Hosts   map[string]HostProfile // by host name, without port
+	Default HostProfile            // for the hosts missing from Hosts
+
+	Clock  Clock  // defaults to RealClock
+	Random Random // defaults to GlobalRandom, see SeededRandom
+}
+
+//Simulator is an HTTPClient answering requests from a model of the hosts they are sent to instead of sending them,
+//to predict how worker counts and timeouts of bulks behave before pointing them at real systems
+type Simulator struct {
+	config SimulatorConfig
+
+	mu    sync.Mutex
+	slots map[string]chan struct{} // of the hosts with a MaxConcurrency
+}
+
+//NewSimulator returns a simulator of the hosts of config
+func NewSimulator(config SimulatorConfig) *Simulator {
+	if config.Clock == nil {
+		config.Clock = RealClock()
+	}
+
+	if config.Random == nil {
+		config.Random = GlobalRandom()
+	}
+
+	return &Simulator{config: config, slots: map[string]chan struct{}{}}
+}
+
+//Do answers req after the latency drawn for its host, with an error, the status of its profile or an empty 200 OK
+func (s *Simulator) Do(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	profile, ok := s.config.Hosts[host]
+	if !ok {
+		profile = s.config.Default
+	}
+
+	if slots := s.slotsOf(host, profile); slots != nil {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	if profile.Latency != nil {
+		if err := sleep(req.Context(), s.config.Clock, profile.Latency(s.config.Random)); err != nil {
+			return nil, err
+		}
+	}
+
+	if chance(s.config.Random, profile.ErrorPercentage) {
+		if profile.Error == nil {
+			return nil, ErrInjectedFault
+		}
+		return nil, profile.Error
+	}
+
+	if chance(s.config.Random, profile.StatusPercentage) {
+		if profile.StatusCode == 0 {
+			return syntheticResponse(req, http.StatusServiceUnavailable), nil
+		}
+		return syntheticResponse(req, profile.StatusCode), nil
+	}
+
+	return syntheticResponse(req, http.StatusOK), nil
+}
+
+// slotsOf returns the slots of the requests served at once by host, nil when they are unlimited
+func (s *Simulator) slotsOf(host string, profile HostProfile) chan struct{} {
+	if profile.MaxConcurrency <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slots, ok := s.slots[host]
+	if !ok {
+		slots = make(chan struct{}, profile.MaxConcurrency)
+		s.slots[host] = slots
+	}
+
+	return slots
+}
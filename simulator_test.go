@@ -0,0 +1,80 @@
+package meniscus
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLatencyModelsDrawFromTheirDistribution(t *testing.T) {
+	median := func(model LatencyModel) time.Duration {
+		random := SeededRandom(1)
+		var latencies []time.Duration
+		for i := 0; i < 1001; i++ {
+			latency := model(random)
+			assert.True(t, latency >= 0)
+			latencies = append(latencies, latency)
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		return latencies[500]
+	}
+
+	assert.Equal(t, time.Second, median(ConstantLatency(time.Second)))
+	assert.InDelta(t, float64(150*time.Millisecond), float64(median(UniformLatency(100*time.Millisecond, 200*time.Millisecond))), float64(10*time.Millisecond))
+	assert.InDelta(t, float64(time.Second), float64(median(NormalLatency(time.Second, 100*time.Millisecond))), float64(20*time.Millisecond))
+	assert.InDelta(t, float64(time.Second), float64(median(LogNormalLatency(time.Second, 0.5))), float64(100*time.Millisecond))
+}
+
+func TestSimulatorAnswersFromTheProfileOfEachHost(t *testing.T) {
+	simulator := NewSimulator(SimulatorConfig{
+		Hosts: map[string]HostProfile{
+			"down.example.com":    {ErrorPercentage: 100, Error: errors.New("connection refused")},
+			"limited.example.com": {StatusPercentage: 100, StatusCode: http.StatusTooManyRequests},
+		},
+		Default: HostProfile{ErrorPercentage: 0},
+		Random:  SeededRandom(1),
+	})
+	client := NewBulkHTTPClient(simulator, NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil).
+		AddGet("http://down.example.com", nil).
+		AddGet("http://LIMITED.example.com:8080", nil).
+		AddGet("http://example.com", nil)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.EqualError(t, errs[0], "http client error: connection refused")
+	assert.Equal(t, http.StatusTooManyRequests, responses[1].StatusCode)
+	assert.Equal(t, http.StatusOK, responses[2].StatusCode)
+}
+
+func TestSimulatorQueuesRequestsPastTheConcurrencyOfAHost(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	simulator := NewSimulator(SimulatorConfig{
+		Default: HostProfile{Latency: ConstantLatency(time.Second), MaxConcurrency: 2},
+		Clock:   clock,
+	})
+	client := NewBulkHTTPClient(simulator, time.Minute, WithClock(clock))
+
+	bulkRequest := newBulkClientWithNRequests(4, "http://example.com")
+	done := make(chan []error)
+	go func() {
+		_, errs := client.Do(bulkRequest)
+		done <- errs
+	}()
+
+	clock.BlockUntil(3)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 3, clock.Pending(), "the bulk timeout and the 2 requests served at once")
+	clock.Advance(time.Second)
+	clock.BlockUntil(3)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 3, clock.Pending(), "the bulk timeout and the 2 queued requests")
+	clock.Advance(time.Second)
+
+	assert.Equal(t, []error{nil, nil, nil, nil}, <-done)
+	bulkRequest.CloseAllResponses()
+}
@@ -0,0 +1,228 @@
+package meniscus
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+//StagedTimeouts bounds the individual phases of every request in a bulk.
+//A zero value disables the timeout for that phase, the bulk timeout always applies.
+type StagedTimeouts struct {
+	Dial           time.Duration // DNS lookup and TCP connect
+	TLSHandshake   time.Duration
+	ResponseHeader time.Duration // from the request being written to the first response byte
+	Total          time.Duration // the whole request, including reading the response body
+	Stall          time.Duration // without receiving any byte while reading the response body, however long it takes overall
+}
+
+func (t StagedTimeouts) enabled() bool {
+	return t.Dial > 0 || t.TLSHandshake > 0 || t.ResponseHeader > 0 || t.Total > 0 || t.Stall > 0
+}
+
+func stagedTimeoutMiddleware(timeouts StagedTimeouts, clock Clock) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, cancel := context.WithCancel(req.Context())
+			stages := &stageTracker{clock: clock, cancel: cancel, timers: map[error]func() bool{}}
+
+			stages.start(ErrRequestTimeout, timeouts.Total)
+			ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+				DNSStart: func(httptrace.DNSStartInfo) {
+					stages.start(ErrDialTimeout, timeouts.Dial)
+				},
+				ConnectStart: func(string, string) {
+					stages.start(ErrDialTimeout, timeouts.Dial)
+				},
+				TLSHandshakeStart: func() {
+					stages.stop(ErrDialTimeout)
+					stages.start(ErrTLSHandshakeTimeout, timeouts.TLSHandshake)
+				},
+				TLSHandshakeDone: func(tls.ConnectionState, error) {
+					stages.stop(ErrTLSHandshakeTimeout)
+				},
+				GotConn: func(httptrace.GotConnInfo) {
+					stages.stop(ErrDialTimeout)
+					stages.stop(ErrTLSHandshakeTimeout)
+				},
+				WroteRequest: func(httptrace.WroteRequestInfo) {
+					stages.start(ErrResponseHeaderTimeout, timeouts.ResponseHeader)
+				},
+				GotFirstResponseByte: func() {
+					stages.stop(ErrResponseHeaderTimeout)
+				},
+			})
+
+			resp, err := next.Do(req.WithContext(ctx))
+			if err != nil {
+				stages.stopAll()
+				cancel()
+				if expired := stages.expired(); expired != nil {
+					return nil, expired
+				}
+
+				return nil, err
+			}
+
+			stages.stop(ErrResponseHeaderTimeout)
+			body := &stagedBody{ReadCloser: resp.Body, stages: stages, cancel: cancel}
+			if timeouts.Stall > 0 {
+				body.watchdog = newStallWatchdog(clock, timeouts.Stall, stages)
+			}
+			resp.Body = body
+			return resp, nil
+		})
+	}
+}
+
+// stageTracker runs one timer per phase, keyed by the error reported when that phase expires
+type stageTracker struct {
+	clock  Clock
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	timers map[error]func() bool
+	err    error
+}
+
+func (s *stageTracker) start(stage error, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, running := s.timers[stage]; running {
+		return
+	}
+
+	s.timers[stage] = afterFunc(s.clock, timeout, func() { s.expire(stage) })
+}
+
+func (s *stageTracker) stop(stage error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stop, running := s.timers[stage]; running {
+		stop()
+		delete(s.timers, stage)
+	}
+}
+
+func (s *stageTracker) stopAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stop := range s.timers {
+		stop()
+	}
+}
+
+func (s *stageTracker) expire(stage error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = stage
+	}
+	s.mu.Unlock()
+
+	s.cancel()
+}
+
+func (s *stageTracker) expired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// stagedBody keeps the request context alive until the body is closed and
+// reports reads interrupted by the total timeout with ErrRequestTimeout
+type stagedBody struct {
+	io.ReadCloser
+	stages   *stageTracker
+	cancel   context.CancelFunc
+	watchdog *stallWatchdog
+}
+
+func (b *stagedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.watchdog != nil {
+		b.watchdog.progress()
+	}
+
+	if err != nil && err != io.EOF {
+		if expired := b.stages.expired(); expired != nil {
+			return n, expired
+		}
+	}
+
+	return n, err
+}
+
+func (b *stagedBody) Close() error {
+	if b.watchdog != nil {
+		b.watchdog.halt()
+	}
+	b.stages.stopAll()
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// stallWatchdog expires the request with ErrStalled once no body byte was received for timeout.
+// Rather than restarting a timer on every read it wakes up when the timeout could have elapsed
+// and goes back to sleep for whatever is left since the last progress.
+type stallWatchdog struct {
+	clock   Clock
+	timeout time.Duration
+	stages  *stageTracker
+
+	mu     sync.Mutex
+	last   time.Time
+	stop   func() bool
+	halted bool
+}
+
+func newStallWatchdog(clock Clock, timeout time.Duration, stages *stageTracker) *stallWatchdog {
+	w := &stallWatchdog{clock: clock, timeout: timeout, stages: stages, last: clock.Now()}
+	w.mu.Lock()
+	w.stop = afterFunc(clock, timeout, w.check)
+	w.mu.Unlock()
+	return w
+}
+
+func (w *stallWatchdog) progress() {
+	w.mu.Lock()
+	w.last = w.clock.Now()
+	w.mu.Unlock()
+}
+
+func (w *stallWatchdog) check() {
+	w.mu.Lock()
+	if w.halted {
+		w.mu.Unlock()
+		return
+	}
+
+	idle := w.clock.Now().Sub(w.last)
+	if idle < w.timeout {
+		w.stop = afterFunc(w.clock, w.timeout-idle, w.check)
+		w.mu.Unlock()
+		return
+	}
+	w.mu.Unlock()
+
+	w.stages.expire(ErrStalled)
+}
+
+func (w *stallWatchdog) halt() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.halted = true
+	w.stop()
+}
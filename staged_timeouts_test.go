@@ -0,0 +1,109 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStagedResponseHeaderTimeoutFailsSlowRequestsOnly(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue,
+		WithStagedTimeouts(StagedTimeouts{ResponseHeader: FailingTimeoutValue}))
+
+	queryFast := url.Values{}
+	queryFast.Set("kind", "fast")
+	querySlow := url.Values{}
+	querySlow.Set("kind", "slow")
+
+	reqOne, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", querySlow), nil)
+	require.NoError(t, err, "no errors")
+	reqTwo, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", queryFast), nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{reqOne, reqTwo}, 2, 2)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.EqualError(t, errs[0], "http client error: "+ErrResponseHeaderTimeout.Error())
+	assert.Nil(t, errs[1])
+	assert.Equal(t, http.StatusOK, responses[1].StatusCode)
+}
+
+func TestStagedTotalTimeoutCoversBodyTransfer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(MockServerSlowResponseSleep)
+		w.Write([]byte("late body"))
+	}))
+	defer server.Close()
+
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue,
+		WithStagedTimeouts(StagedTimeouts{ResponseHeader: FailingTimeoutValue, Total: FailingTimeoutValue}))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.EqualError(t, errs[0], "error while reading response body: "+ErrRequestTimeout.Error())
+}
+
+func TestStallTimeoutAbortsStalledBodiesButNotSlowOnes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 20; i++ {
+			w.Write([]byte("."))
+			w.(http.Flusher).Flush()
+			if req.URL.Path == "/stalled" && i == 2 {
+				time.Sleep(MockServerSlowResponseSleep * 2)
+			}
+			time.Sleep(MockServerSlowResponseSleep / 10)
+		}
+	}))
+	defer server.Close()
+
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	client := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue,
+		WithStagedTimeouts(StagedTimeouts{Stall: MockServerSlowResponseSleep}))
+
+	stalled, err := http.NewRequest(http.MethodGet, server.URL+"/stalled", nil)
+	require.NoError(t, err, "no errors")
+	trickling, err := http.NewRequest(http.MethodGet, server.URL+"/trickling", nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{stalled, trickling}, 2, 2)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.EqualError(t, errs[0], "error while reading response body: "+ErrStalled.Error())
+	require.NoError(t, errs[1], "no errors")
+	body, _ := ioutil.ReadAll(responses[1].Body)
+	assert.Equal(t, strings.Repeat(".", 20), string(body))
+}
+
+func TestStageTrackerForgetsTheTimersOfStoppedStages(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	stages := &stageTracker{clock: clock, cancel: func() {}, timers: map[error]func() bool{}}
+
+	stages.start(ErrDialTimeout, time.Second)
+	stages.start(ErrTLSHandshakeTimeout, time.Second)
+	stages.stop(ErrDialTimeout)
+	stages.stop(ErrTLSHandshakeTimeout)
+
+	assert.Empty(t, stages.timers)
+	clock.Advance(time.Second)
+	assert.Nil(t, stages.expired())
+}
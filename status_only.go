@@ -0,0 +1,24 @@
+package meniscus
+
+//DoStatusOnly runs the bulk like Do but only returns the status code of every request, 0 for the ones that failed.
+//Bodies are never read beyond the few bytes drained to reuse connections, for pinging or validating many URLs.
+func (cl *BulkClient) DoStatusOnly(bulkRequest *RoundTrip) ([]int, []error) {
+	statusOnly := *cl
+	statusOnly.allowList = headerAllowList{enabled: true}
+
+	responses, errs := statusOnly.Do(bulkRequest)
+	if responses == nil {
+		// nothing was run by this call, the responses may be those of another Do still running the bulk
+		return nil, errs
+	}
+	defer bulkRequest.CloseAllResponses()
+
+	statuses := make([]int, len(responses))
+	for i, response := range responses {
+		if response != nil {
+			statuses[i] = response.StatusCode
+		}
+	}
+
+	return statuses, errs
+}
@@ -0,0 +1,64 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDoStatusOnlyReturnsTheStatusOfEveryRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+	found, err := http.NewRequest(http.MethodGet, server.URL+"/found", nil)
+	require.NoError(t, err, "no errors")
+	missing, err := http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+	require.NoError(t, err, "no errors")
+	unreachable, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:0/", nil)
+	require.NoError(t, err, "no errors")
+
+	statuses, errs := client.DoStatusOnly(NewBulkRequest([]*http.Request{found, missing, unreachable}, 3, 3))
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusNotFound, 0}, statuses)
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Error(t, errs[2])
+	assert.False(t, client.allowList.enabled)
+}
+
+func TestDoStatusOnlyLeavesTheResponsesOfARunningBulkAlone(t *testing.T) {
+	release := make(chan struct{})
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		<-release
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithUnbufferedResponses())
+
+	bulkRequest := newBulkClientWithNRequests(1, "http://example.com")
+	done := make(chan []*http.Response)
+	go func() {
+		responses, _ := client.Do(bulkRequest)
+		done <- responses
+	}()
+	assert.Eventually(t, func() bool { return bulkRequest.State().Requests[StateFiring] == 1 }, time.Second, time.Millisecond)
+
+	statuses, errs := client.DoStatusOnly(bulkRequest)
+	assert.Nil(t, statuses)
+	assert.Equal(t, []error{ErrAlreadyExecuting}, errs)
+
+	close(release)
+	responses := <-done
+	defer bulkRequest.CloseAllResponses()
+	_, err := ioutil.ReadAll(responses[0].Body)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,121 @@
+package meniscus
+
+import (
+	"net/http"
+	"sync"
+)
+
+//DoStream runs bulkRequest like Do, sending the result of every request on the returned channel as soon as it is
+//processed, so that fast responses can be handled while slow ones are still in flight. Requests that are not sent,
+//ignored at the deadline or that fail with the whole bulk, e.g. with ErrAlreadyExecuting, follow once Do returns.
+//The channel is closed after the last result, it is buffered for the whole bulk so a slow consumer does not hold
+//the bulk back. Responses must be closed as with Do, with a Reducer results are sent once reduced,
+//without their response or value. The results of a phase added with Then are not streamed live, they follow
+//once Do returns. A consumer that stops reading cancels the context of the bulk, see SetContext:
+//the results it did not receive are then dropped, their responses closed, and the channel is closed.
+func (cl *BulkClient) DoStream(bulkRequest *RoundTrip) <-chan Result {
+	stream := &resultStream{
+		results: make(chan Result, len(bulkRequest.requests)),
+		done:    bulkRequest.parentContext().Done(),
+		sent:    map[int]bool{},
+	}
+	go func() {
+		defer close(stream.results)
+
+		responses, errs := cl.run(bulkRequest, stream)
+		if responses == nil {
+			for i, request := range bulkRequest.requests {
+				stream.send(Result{Index: bulkRequest.origin(i), Request: request, Err: errs[0]})
+			}
+			return
+		}
+
+		for _, result := range bulkRequest.Results() {
+			if !stream.wasSent(result.Index) {
+				stream.send(result)
+			}
+		}
+	}()
+
+	return stream.results
+}
+
+// resultStream carries the results of a bulk run with DoStream
+type resultStream struct {
+	results chan Result
+	done    <-chan struct{} // closed once the consumer left
+	mu      sync.Mutex
+	sent    map[int]bool // indices of the results already sent
+}
+
+// send hands result to the consumer, or drops it once the consumer left
+func (s *resultStream) send(result Result) {
+	s.mu.Lock()
+	s.sent[result.Index] = true
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		closeResponse(result.Response)
+		return
+	default:
+	}
+
+	select {
+	case s.results <- result:
+	case <-s.done:
+		closeResponse(result.Response)
+	}
+}
+
+func (s *resultStream) wasSent(index int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sent[index]
+}
+
+// run runs the bulk, streaming its results as they are collected when stream is not nil
+func (cl *BulkClient) run(bulkRequest *RoundTrip, stream *resultStream) ([]*http.Response, []error) {
+	done, ok := bulkRequest.run()
+	if !ok {
+		return nil, []error{ErrAlreadyExecuting}
+	}
+	defer done()
+
+	bulkRequest.setStream(stream)
+	defer bulkRequest.setStream(nil)
+
+	bulkRequest.aggregate = nil
+	var responses []*http.Response
+	var errs []error
+	if bulkRequest.nextPhase != nil {
+		responses, errs = cl.doPhases(bulkRequest)
+	} else {
+		responses, errs = cl.do(bulkRequest.parentContext(), bulkRequest)
+	}
+
+	if cl.errorHandler != nil && responses != nil {
+		cl.handleErrors(bulkRequest)
+	}
+
+	if cl.registry != nil && responses != nil {
+		cl.registry.record(cl.registryName, bulkRequest.Results())
+	}
+
+	if cl.logger != nil {
+		var err error
+		if responses == nil {
+			err = errs[0]
+		}
+		cl.logBulk(bulkRequest, err)
+	}
+
+	return responses, errs
+}
+
+func (r *RoundTrip) setStream(stream *resultStream) {
+	r.mu.Lock()
+	r.stream = stream
+	r.mu.Unlock()
+}
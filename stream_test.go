@@ -0,0 +1,151 @@
+package meniscus
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoStreamSendsFastResultsBeforeSlowOnesComplete(t *testing.T) {
+	release := make(chan struct{})
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/slow" {
+			<-release
+		}
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 2, 2).
+		AddGet("http://example.com/slow", nil).
+		AddGet("http://example.com/fast", nil)
+	results := client.DoStream(bulkRequest)
+
+	fast := <-results
+	assert.Equal(t, 1, fast.Index)
+	assert.NoError(t, fast.Err)
+	fast.Response.Body.Close()
+
+	close(release)
+	slow := <-results
+	assert.Equal(t, 0, slow.Index)
+	slow.Response.Body.Close()
+
+	_, open := <-results
+	assert.False(t, open)
+}
+
+func TestDoStreamSendsEveryRequestOnce(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/stuck" {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return syntheticResponse(req, http.StatusOK), nil
+	}), 50*time.Millisecond)
+
+	bulkRequest := NewBulkRequest(nil, 3, 3).
+		AddGet("http://example.com/ok", nil).
+		AddGet("http://example.com/stuck", nil).
+		AddRequestIf(mustRequest(t, "http://example.com/skipped"), func() bool { return false })
+
+	errs := map[int]error{}
+	for result := range client.DoStream(bulkRequest) {
+		_, seen := errs[result.Index]
+		assert.False(t, seen)
+		errs[result.Index] = result.Err
+	}
+	bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, map[int]error{0: nil, 1: ErrRequestIgnored, 2: ErrRequestSkipped}, errs)
+}
+
+func TestDoStreamFailsEveryRequestWithTheBulk(t *testing.T) {
+	release := make(chan struct{})
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		<-release
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+	bulkRequest := newBulkClientWithNRequests(2, "http://example.com")
+	running := client.DoStream(bulkRequest)
+
+	assert.Eventually(t, func() bool { return bulkRequest.State().Requests[StateFiring] == 2 }, time.Second, time.Millisecond)
+	var errs []error
+	for result := range client.DoStream(bulkRequest) {
+		errs = append(errs, result.Err)
+	}
+	assert.Equal(t, []error{ErrAlreadyExecuting, ErrAlreadyExecuting}, errs)
+
+	close(release)
+	for result := range running {
+		result.Response.Body.Close()
+	}
+}
+
+func TestStreamingAResultDoesNotHoldTheBulk(t *testing.T) {
+	bulkRequest := newBulkClientWithNRequests(1, "http://example.com")
+	bulkRequest.start(func() {})
+	stream := &resultStream{results: make(chan Result), sent: map[int]bool{}}
+	bulkRequest.setStream(stream)
+
+	go bulkRequest.recordProgress(Result{Index: 0})
+	assert.Eventually(t, func() bool { return stream.wasSent(0) }, time.Second, time.Millisecond)
+
+	answered := make(chan struct{})
+	go func() {
+		bulkRequest.State()
+		bulkRequest.Cancel()
+		close(answered)
+	}()
+
+	select {
+	case <-answered:
+	case <-time.After(time.Second):
+		t.Fatal("the bulk is blocked by a consumer that did not receive its result yet")
+	}
+	assert.Equal(t, 0, (<-stream.results).Index)
+}
+
+// closeCounter counts the bodies closed
+type closeCounter struct {
+	io.Reader
+	closed *int32
+}
+
+func (c closeCounter) Close() error {
+	atomic.AddInt32(c.closed, 1)
+	return nil
+}
+
+func TestDoStreamStopsSendingOnceTheConsumerLeaves(t *testing.T) {
+	var closed int32
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = closeCounter{Reader: strings.NewReader("ok"), closed: &closed}
+		return resp, nil
+	}), NonFailingTimeoutValue, WithUnbufferedResponses())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bulkRequest := newBulkClientWithNRequests(1, "http://example.com").SetContext(ctx).
+		Then(func([]Result) []*http.Request {
+			return []*http.Request{mustRequest(t, "http://example.com/1"), mustRequest(t, "http://example.com/2")}
+		})
+	results := client.DoStream(bulkRequest)
+
+	// more results than the channel holds, and nobody reading them
+	assert.Eventually(t, func() bool { return len(bulkRequest.Results()) == 3 }, time.Second, time.Millisecond)
+	cancel()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&closed) == 2 }, time.Second, time.Millisecond,
+		"the results not received are dropped")
+	received := 0
+	for result := range results {
+		received++
+		result.Response.Body.Close()
+	}
+	assert.Equal(t, 1, received)
+}
@@ -0,0 +1,66 @@
+package meniscus
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+//AddStreamingRequest adds request to the bulk with a body written by produce while the request is being sent,
+//e.g. a generated CSV export, without buffering it first. produce starts once a worker picks the request up,
+//the error it returns fails the request. Body ciphers and UploadFixedLength still buffer the body to transform it.
+func (r *RoundTrip) AddStreamingRequest(request *http.Request, produce func(io.Writer) error) *RoundTrip {
+	if r.streams == nil {
+		r.streams = map[int]func(io.Writer) error{}
+	}
+
+	r.streams[len(r.requests)] = produce
+	r.requests = append(r.requests, request)
+	return r
+}
+
+// bodyStream runs the producer of a streamed request body
+type bodyStream struct {
+	reader *io.PipeReader
+	done   chan struct{}
+	err    error
+}
+
+// stream returns a copy of req whose body is written by produce in its own goroutine
+func stream(req *http.Request, produce func(io.Writer) error) (*http.Request, *bodyStream) {
+	reader, writer := io.Pipe()
+	s := &bodyStream{reader: reader, done: make(chan struct{})}
+
+	go func() {
+		s.err = produce(writer)
+		writer.CloseWithError(s.err)
+		close(s.done)
+	}()
+
+	streamed := req.WithContext(req.Context())
+	streamed.Body = reader
+	streamed.GetBody = nil
+	streamed.ContentLength = -1
+	return streamed, s
+}
+
+// wait returns the error of the producer once it is done, aborting it with cause when the request failed before
+// reading its whole body. A producer stopped because the body was no longer read is not an error of its own.
+func (s *bodyStream) wait(ctx context.Context, cause error) error {
+	if cause != nil {
+		s.reader.CloseWithError(cause)
+	}
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		s.reader.CloseWithError(ctx.Err())
+		return nil
+	}
+
+	if s.err == io.ErrClosedPipe || (cause != nil && s.err == cause) {
+		return nil
+	}
+
+	return s.err
+}
@@ -0,0 +1,122 @@
+package meniscus
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamingRequestsSendTheBodyTheirProducerWrites(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Header().Set("X-Transfer-Encoding", fmt.Sprint(req.TransferEncoding))
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+	req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	require.NoError(t, err, "no errors")
+
+	exportCSV := func(w io.Writer) error {
+		for i := 1; i <= 3; i++ {
+			if _, err := fmt.Fprintf(w, "driver-%d,%d\n", i, i*10); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddStreamingRequest(req, exportCSV)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	require.NoError(t, errs[0], "no errors")
+	body, _ := ioutil.ReadAll(responses[0].Body)
+	assert.Equal(t, "driver-1,10\ndriver-2,20\ndriver-3,30\n", string(body))
+	assert.Equal(t, "[chunked]", responses[0].Header.Get("X-Transfer-Encoding"))
+}
+
+func TestStreamingRequestsFailWithTheErrorOfTheirProducer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ioutil.ReadAll(req.Body)
+	}))
+	defer server.Close()
+
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+	req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	require.NoError(t, err, "no errors")
+
+	exportFailure := errors.New("export query failed")
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddStreamingRequest(req, func(w io.Writer) error {
+		w.Write([]byte("driver-1,10\n"))
+		return exportFailure
+	})
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Nil(t, responses[0])
+	assert.EqualError(t, errs[0], "error while streaming request body: export query failed")
+}
+
+func TestStreamingRequestsFailingToConnectStopTheirProducer(t *testing.T) {
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+	req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1:0/", nil)
+	require.NoError(t, err, "no errors")
+
+	stopped := make(chan error, 1)
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddStreamingRequest(req, func(w io.Writer) error {
+		for {
+			if _, err := w.Write([]byte("row\n")); err != nil {
+				stopped <- err
+				return err
+			}
+		}
+	})
+	_, errs := client.Do(bulkRequest)
+
+	assert.Contains(t, errs[0].Error(), "http client error")
+	assert.Error(t, <-stopped)
+}
+
+func TestStreamingRequestsFailingToBeFramedFailAlone(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithUploadPolicy(UploadFixedLength))
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddStreamingRequest(req, func(w io.Writer) error {
+		return errors.New("export query failed")
+	})
+	responses, errs := client.Do(bulkRequest)
+
+	assert.Nil(t, responses[0])
+	assert.EqualError(t, errs[0], "error while streaming request body: export query failed")
+}
+
+func TestStreamingRequestsFailingToBeEncryptedFailAlone(t *testing.T) {
+	cipher := BodyCipher{
+		Encrypt: func(req *http.Request, plaintext []byte) ([]byte, error) { return nil, errors.New("no key") },
+	}
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithBodyCipher(cipher))
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddStreamingRequest(req, func(w io.Writer) error {
+		_, err := w.Write([]byte("driver-1,10\n"))
+		return err
+	})
+	responses, errs := client.Do(bulkRequest)
+
+	assert.Nil(t, responses[0])
+	assert.Contains(t, errs[0].Error(), "error while encrypting request body: no key")
+}
@@ -0,0 +1,24 @@
+package meniscus
+
+import "sort"
+
+//Tags label a request with logical dimensions such as service, endpoint or tier.
+//They are carried to the Result of the request, its profiler labels and the loadgen report,
+//so performance can be broken down by endpoint rather than by raw URL.
+type Tags map[string]string
+
+// pairs returns the tags as sorted key value pairs, the form pprof.Labels expects
+func (t Tags) pairs(prefix string) []string {
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, prefix+key, t[key])
+	}
+
+	return pairs
+}
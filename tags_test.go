@@ -0,0 +1,41 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestTagsAreCarriedToTheResults(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/drivers/42", nil)
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		AddTaggedRequest(req, Tags{"service": "drivers", "endpoint": "get-driver"}).
+		AddRequest(req.Clone(req.Context()))
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	results := bulkRequest.Results()
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, Tags{"service": "drivers", "endpoint": "get-driver"}, results[0].Tags)
+	assert.Nil(t, results[1].Tags)
+}
+
+func TestTagsAreAddedToTheProfilerLabels(t *testing.T) {
+	var endpointLabel string
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		endpointLabel, _ = pprof.Label(req.Context(), "meniscus_tag_endpoint")
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithProfilerLabels())
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/drivers/42", nil)
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddTaggedRequest(req, Tags{"endpoint": "get-driver"})
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, "get-driver", endpointLabel)
+}
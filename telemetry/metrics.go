@@ -0,0 +1,130 @@
+// Package telemetry reports the requests fired by a meniscus.BulkClient to OpenTelemetry
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"github.com/gojektech/meniscus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// instrumentationName identifies the meters and tracers of this package
+const instrumentationName = "github.com/gojektech/meniscus/telemetry"
+
+// Outcomes recorded in the meniscus.outcome attribute
+const (
+	OutcomeSuccess   = "success"    // a response with a status below 400
+	OutcomeHTTPError = "http_error" // a response with a 4xx or 5xx status
+	OutcomeError     = "error"      // no response at all
+)
+
+//Metrics returns a middleware recording the duration and outcome of every request in the
+//meniscus.request.duration histogram of provider, so they are exported with the provider's OTLP exporter,
+//and how long requests waited for a fire worker in meniscus.request.queue_wait. Use it with meniscus.WithMiddleware.
+//The requests of a client built meniscus.WithName carry its name in the meniscus.client attribute, as do their spans.
+//Their tags are recorded as meniscus.tag.<key> attributes, and their route as meniscus.route when the client has
+//a meniscus.RouteNormalizer. The URL path is never recorded, to keep the number of series bounded,
+//so tags should only take a few distinct values.
+func Metrics(provider metric.MeterProvider) (meniscus.Middleware, error) {
+	duration, err := provider.Meter(instrumentationName).Float64Histogram("meniscus.request.duration",
+		metric.WithDescription("Duration of the requests fired by bulks, up to receiving the response headers"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating request duration histogram: %s", err)
+	}
+
+	queueWait, err := provider.Meter(instrumentationName).Float64Histogram("meniscus.request.queue_wait",
+		metric.WithDescription("Time the requests of bulks waited for a fire worker to pick them up"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating queue wait histogram: %s", err)
+	}
+
+	return func(next meniscus.HTTPClient) meniscus.HTTPClient {
+		return meniscus.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if scope := meniscus.ScopeOf(req.Context()); scope != nil && firstAttempt(scope) {
+				queueWait.Record(context.Background(), scope.QueueWait.Seconds(), metric.WithAttributes(append(
+					scopeAttributes(scope),
+					attribute.String("http.request.method", req.Method),
+					attribute.String("server.address", req.URL.Hostname()))...))
+			}
+
+			start := time.Now()
+			resp, err := next.Do(req)
+			elapsed := time.Since(start)
+
+			duration.Record(context.Background(), elapsed.Seconds(), metric.WithAttributes(requestAttributes(req, resp, err)...))
+			return resp, err
+		})
+	}, nil
+}
+
+// queueWaitKey marks the scope of a request whose queue wait was recorded
+type queueWaitKey struct{}
+
+// firstAttempt tells whether this is the first time the request of scope goes through the middleware
+func firstAttempt(scope *meniscus.RequestScope) bool {
+	first := false
+	scope.Update(queueWaitKey{}, func(recorded interface{}) interface{} {
+		first = recorded == nil
+		return true
+	})
+
+	return first
+}
+
+// clientAttributes holds the meniscus.client attribute naming the client of scope, when it has a name
+func clientAttributes(scope *meniscus.RequestScope) []attribute.KeyValue {
+	if scope == nil || len(scope.Client) == 0 {
+		return nil
+	}
+
+	return []attribute.KeyValue{attribute.String("meniscus.client", scope.Client)}
+}
+
+// scopeAttributes adds the normalized route and the tags of the request of scope to its client attributes
+func scopeAttributes(scope *meniscus.RequestScope) []attribute.KeyValue {
+	attributes := clientAttributes(scope)
+	if scope == nil {
+		return attributes
+	}
+
+	if len(scope.Route) != 0 {
+		attributes = append(attributes, attribute.String("meniscus.route", scope.Route))
+	}
+
+	for key, value := range scope.Tags {
+		attributes = append(attributes, attribute.String("meniscus.tag."+key, value))
+	}
+
+	return attributes
+}
+
+// requestAttributes describes a request following the OpenTelemetry HTTP client conventions
+func requestAttributes(req *http.Request, resp *http.Response, err error) []attribute.KeyValue {
+	attributes := append(scopeAttributes(meniscus.ScopeOf(req.Context())),
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Hostname()))
+
+	switch {
+	case err != nil:
+		attributes = append(attributes,
+			attribute.String("error.type", fmt.Sprintf("%T", err)),
+			attribute.String("meniscus.outcome", OutcomeError))
+	case resp.StatusCode >= 400:
+		attributes = append(attributes,
+			attribute.Int("http.response.status_code", resp.StatusCode),
+			attribute.String("error.type", strconv.Itoa(resp.StatusCode)),
+			attribute.String("meniscus.outcome", OutcomeHTTPError))
+	default:
+		attributes = append(attributes,
+			attribute.Int("http.response.status_code", resp.StatusCode),
+			attribute.String("meniscus.outcome", OutcomeSuccess))
+	}
+
+	return attributes
+}
@@ -0,0 +1,109 @@
+package telemetry
+
+import (
+	"context"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMetricsRecordsTheDurationAndOutcomeOfEveryRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	reader := sdkmetric.NewManualReader()
+	metrics, err := Metrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err, "no errors")
+
+	client := meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second, meniscus.WithMiddleware(metrics))
+	bulkRequest := meniscus.NewBulkRequest(nil, 3, 3).
+		AddGet(server.URL+"/found", nil).
+		AddGet(server.URL+"/found", nil).
+		AddGet(server.URL+"/missing", nil)
+	client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	var collected metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &collected), "no errors")
+	require.Len(t, collected.ScopeMetrics, 1)
+	require.Len(t, collected.ScopeMetrics[0].Metrics, 2)
+	histogram := collected.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "meniscus.request.duration", histogram.Name)
+
+	queueWait := collected.ScopeMetrics[0].Metrics[1]
+	assert.Equal(t, "meniscus.request.queue_wait", queueWait.Name)
+	assert.Equal(t, uint64(3), queueWait.Data.(metricdata.Histogram[float64]).DataPoints[0].Count)
+
+	counts := map[string]uint64{}
+	for _, point := range histogram.Data.(metricdata.Histogram[float64]).DataPoints {
+		outcome, _ := point.Attributes.Value(attribute.Key("meniscus.outcome"))
+		counts[outcome.AsString()] += point.Count
+		assert.True(t, point.Sum > 0)
+	}
+	assert.Equal(t, map[string]uint64{OutcomeSuccess: 2, OutcomeHTTPError: 1}, counts)
+}
+
+func TestRequestAttributesNameTheErrorType(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/drivers", nil)
+
+	attributes := attribute.NewSet(requestAttributes(req, nil, meniscus.ErrInjectedFault)...)
+
+	errorType, _ := attributes.Value("error.type")
+	outcome, _ := attributes.Value("meniscus.outcome")
+	assert.Equal(t, "*errors.errorString", errorType.AsString())
+	assert.Equal(t, OutcomeError, outcome.AsString())
+	assert.False(t, attributes.HasValue("http.response.status_code"))
+}
+
+func TestRequestAttributesNameTheClient(t *testing.T) {
+	var attributes []attribute.Set
+	capture := func(next meniscus.HTTPClient) meniscus.HTTPClient {
+		return meniscus.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			resp := &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}
+			attributes = append(attributes, attribute.NewSet(requestAttributes(req, resp, nil)...))
+			return resp, nil
+		})
+	}
+
+	for _, opts := range [][]meniscus.ClientOption{{meniscus.WithName("pricing-fanout")}, nil} {
+		client := meniscus.NewBulkHTTPClient(&http.Client{}, time.Second, append(opts, meniscus.WithMiddleware(capture))...)
+		client.Do(meniscus.NewBulkRequest(nil).AddGet("http://example.com", nil))
+	}
+
+	require.Len(t, attributes, 2)
+	name, _ := attributes[0].Value("meniscus.client")
+	assert.Equal(t, "pricing-fanout", name.AsString())
+	assert.False(t, attributes[1].HasValue("meniscus.client"))
+}
+
+func TestRequestAttributesCarryTheRouteAndTags(t *testing.T) {
+	var attributes attribute.Set
+	capture := func(next meniscus.HTTPClient) meniscus.HTTPClient {
+		return meniscus.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			resp := &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}
+			attributes = attribute.NewSet(requestAttributes(req, resp, nil)...)
+			return resp, nil
+		})
+	}
+
+	client := meniscus.NewBulkHTTPClient(&http.Client{}, time.Second,
+		meniscus.WithRouteNormalizer(meniscus.NormalizeIDs), meniscus.WithMiddleware(capture))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/drivers/42", nil)
+	client.Do(meniscus.NewBulkRequest(nil).AddTaggedRequest(req, meniscus.Tags{"endpoint": "get-driver"}))
+
+	route, _ := attributes.Value("meniscus.route")
+	endpoint, _ := attributes.Value("meniscus.tag.endpoint")
+	assert.Equal(t, "/drivers/:id", route.AsString())
+	assert.Equal(t, "get-driver", endpoint.AsString())
+}
@@ -0,0 +1,193 @@
+package telemetry
+
+import (
+	"github.com/gojektech/meniscus"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"math"
+	"net/http"
+	"time"
+)
+
+//TracingOption configures Tracing
+type TracingOption func(*tracing)
+
+type tracing struct {
+	propagator propagation.TextMapPropagator
+	sampleRate float64
+	random     meniscus.Random
+}
+
+//WithPropagator sets how span contexts are written to the requests, defaulting to W3C traceparent
+func WithPropagator(propagator propagation.TextMapPropagator) TracingOption {
+	return func(t *tracing) {
+		t.propagator = propagator
+	}
+}
+
+//WithB3 propagates span contexts with Zipkin B3 headers instead of W3C traceparent,
+//either the single b3 header or the multiple X-B3-* headers
+func WithB3(single bool) TracingOption {
+	encoding := b3.B3MultipleHeader
+	if single {
+		encoding = b3.B3SingleHeader
+	}
+
+	return WithPropagator(b3.New(b3.WithInjectEncoding(encoding)))
+}
+
+//WithSampleRate traces only fraction of the requests of every bulk, spread evenly over the bulk, to bound the overhead
+//of tracing huge bulks. Requests left out but failing, with an error or a 4xx or 5xx status, are still traced
+//once they fail, without their span being propagated downstream.
+func WithSampleRate(fraction float64) TracingOption {
+	return func(t *tracing) {
+		t.sampleRate = fraction
+	}
+}
+
+//WithRandom sets the source of randomness sampling requests fired outside of a bulk, see meniscus.SeededRandom
+func WithRandom(random meniscus.Random) TracingOption {
+	return func(t *tracing) {
+		t.random = random
+	}
+}
+
+//Tracing returns a middleware starting a client span from provider for every request and propagating it downstream.
+//Spans end once the response headers are received. Use it with meniscus.WithMiddleware, and RoundTrip.SetContext
+//to make the spans children of the current one.
+//
+//When a middleware wrapping Tracing makes several attempts at a request, e.g. retries or hedges,
+//every attempt gets its own span linked to the spans of the earlier attempts, see WinningSpan.
+func Tracing(provider trace.TracerProvider, opts ...TracingOption) meniscus.Middleware {
+	config := &tracing{propagator: propagation.TraceContext{}, sampleRate: 1, random: meniscus.GlobalRandom()}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	tracer := provider.Tracer(instrumentationName)
+	return func(next meniscus.HTTPClient) meniscus.HTTPClient {
+		return meniscus.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			scope := meniscus.ScopeOf(req.Context())
+			if !config.sampled(scope) {
+				return traceFailure(tracer, next, req, scope)
+			}
+
+			ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+				trace.WithSpanKind(trace.SpanKindClient), trace.WithLinks(attemptLinks(scope)...))
+			defer span.End()
+			attempt := recordAttempt(scope, span)
+
+			traced := req.WithContext(ctx)
+			traced.Header = req.Header.Clone()
+			config.propagator.Inject(ctx, propagation.HeaderCarrier(traced.Header))
+
+			resp, err := next.Do(traced)
+			describe(span, req, resp, err)
+
+			if err == nil && scope != nil {
+				scope.Update(winnerKey{}, func(interface{}) interface{} { return attempt })
+			}
+
+			return resp, err
+		})
+	}
+}
+
+// sampled tells whether the request of scope is traced, picking exactly sampleRate of the requests of a bulk
+// by index so that they are spread evenly over it
+func (t *tracing) sampled(scope *meniscus.RequestScope) bool {
+	switch {
+	case t.sampleRate >= 1:
+		return true
+	case t.sampleRate <= 0:
+		return false
+	case scope == nil:
+		return t.random() < t.sampleRate
+	}
+
+	index := float64(scope.Index)
+	return math.Floor((index+1)*t.sampleRate) > math.Floor(index*t.sampleRate)
+}
+
+// traceFailure sends a request left out by sampling, recording its span after the fact if it fails
+func traceFailure(tracer trace.Tracer, next meniscus.HTTPClient, req *http.Request, scope *meniscus.RequestScope) (*http.Response, error) {
+	start := time.Now()
+	resp, err := next.Do(req)
+	if err == nil && resp.StatusCode < 400 {
+		return resp, err
+	}
+
+	_, span := tracer.Start(req.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithLinks(attemptLinks(scope)...), trace.WithTimestamp(start))
+	attempt := recordAttempt(scope, span)
+	describe(span, req, resp, err)
+	span.End()
+
+	if err == nil && scope != nil {
+		scope.Update(winnerKey{}, func(interface{}) interface{} { return attempt })
+	}
+
+	return resp, err
+}
+
+// describe sets the attributes and status of the span of a request once it is done
+func describe(span trace.Span, req *http.Request, resp *http.Response, err error) {
+	span.SetAttributes(requestAttributes(req, resp, err)...)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case resp.StatusCode >= 400:
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+}
+
+// attemptsKey holds the span contexts of the attempts at a request in its scope, in the order they started
+type attemptsKey struct{}
+
+// winnerKey holds the span context of the last attempt at a request that received a response
+type winnerKey struct{}
+
+//WinningSpan returns the span context of the attempt whose response the result holds,
+//the last one to receive a response when several did. It is invalid when no attempt was traced.
+func WinningSpan(result meniscus.Result) trace.SpanContext {
+	if result.Scope == nil {
+		return trace.SpanContext{}
+	}
+
+	winner, _ := result.Scope.Load(winnerKey{}).(trace.SpanContext)
+	return winner
+}
+
+func attemptLinks(scope *meniscus.RequestScope) []trace.Link {
+	if scope == nil {
+		return nil
+	}
+
+	attempts, _ := scope.Load(attemptsKey{}).([]trace.SpanContext)
+	links := make([]trace.Link, len(attempts))
+	for i, attempt := range attempts {
+		links[i] = trace.Link{SpanContext: attempt, Attributes: []attribute.KeyValue{attribute.Int("meniscus.attempt", i+1)}}
+	}
+
+	return links
+}
+
+// recordAttempt adds the span of an attempt to the scope of its request and returns its span context
+func recordAttempt(scope *meniscus.RequestScope, span trace.Span) trace.SpanContext {
+	spanContext := span.SpanContext()
+	if scope == nil {
+		return spanContext
+	}
+
+	attempts := scope.Update(attemptsKey{}, func(attempts interface{}) interface{} {
+		previous, _ := attempts.([]trace.SpanContext)
+		return append(previous[:len(previous):len(previous)], spanContext)
+	}).([]trace.SpanContext)
+
+	span.SetAttributes(attribute.Int("meniscus.attempt", len(attempts)))
+	return spanContext
+}
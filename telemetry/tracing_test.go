@@ -0,0 +1,165 @@
+package telemetry
+
+import (
+	"context"
+	"github.com/gojektech/meniscus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// traceHeaders fires a single request through Tracing and returns the span recorded and the headers the server received
+func traceHeaders(t *testing.T, path string, opts ...TracingOption) (sdktrace.ReadOnlySpan, http.Header) {
+	received := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received <- req.Header
+		if path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second,
+		meniscus.WithMiddleware(Tracing(provider, opts...)))
+
+	bulkRequest := meniscus.NewBulkRequest(nil, 1, 1).AddGet(server.URL+path, nil)
+	_, errs := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+	require.NoError(t, errs[0], "no errors")
+
+	require.Len(t, recorder.Ended(), 1)
+	return recorder.Ended()[0], <-received
+}
+
+func TestTracingPropagatesW3CTraceparentByDefault(t *testing.T) {
+	span, headers := traceHeaders(t, "/found")
+
+	assert.Equal(t, "HTTP GET", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+	assert.Equal(t, "00-"+span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-01",
+		headers.Get("Traceparent"))
+}
+
+func TestTracingPropagatesB3SingleHeader(t *testing.T) {
+	span, headers := traceHeaders(t, "/missing", WithB3(true))
+
+	assert.Equal(t, span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-1", headers.Get("B3"))
+	assert.Empty(t, headers.Get("Traceparent"))
+	assert.Equal(t, codes.Error, span.Status().Code)
+}
+
+func TestTracingPropagatesB3MultipleHeaders(t *testing.T) {
+	span, headers := traceHeaders(t, "/found", WithB3(false))
+
+	assert.Equal(t, span.SpanContext().TraceID().String(), headers.Get("X-B3-Traceid"))
+	assert.Equal(t, span.SpanContext().SpanID().String(), headers.Get("X-B3-Spanid"))
+	assert.Equal(t, "1", headers.Get("X-B3-Sampled"))
+	assert.Empty(t, headers.Get("B3"))
+}
+
+func TestTracingLinksTheAttemptsOfARetriedRequest(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	retryOnce := func(next meniscus.HTTPClient) meniscus.HTTPClient {
+		return meniscus.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.Do(req)
+			if err == nil && resp.StatusCode == http.StatusServiceUnavailable {
+				resp.Body.Close()
+				return next.Do(req)
+			}
+			return resp, err
+		})
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second,
+		meniscus.WithMiddleware(retryOnce, Tracing(provider)))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "sync drivers")
+	bulkRequest := meniscus.NewBulkRequest(nil, 1, 1).AddGet(server.URL, nil).SetContext(ctx)
+	_, errs := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+	parent.End()
+	require.NoError(t, errs[0], "no errors")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	first, second := spans[0], spans[1]
+	assert.Equal(t, parent.SpanContext().SpanID(), first.Parent().SpanID())
+	assert.Equal(t, parent.SpanContext().SpanID(), second.Parent().SpanID())
+	assert.Empty(t, first.Links())
+	require.Len(t, second.Links(), 1)
+	assert.Equal(t, first.SpanContext(), second.Links()[0].SpanContext)
+	assert.Contains(t, second.Attributes(), attribute.Int("meniscus.attempt", 2))
+
+	assert.Equal(t, second.SpanContext(), WinningSpan(bulkRequest.Results()[0]))
+}
+
+func TestSampleRateTracesAFractionOfEveryBulkAndAllFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/failing" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := meniscus.NewBulkHTTPClient(&http.Client{Timeout: time.Second}, time.Second,
+		meniscus.WithMiddleware(Tracing(provider, WithSampleRate(0.25))))
+
+	bulkRequest := meniscus.NewBulkRequest(nil, 4, 4)
+	for i := 0; i < 8; i++ {
+		bulkRequest.AddGet(server.URL+"/ok", nil)
+	}
+	bulkRequest.AddGet(server.URL+"/failing", nil)
+	client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	traced := map[int]bool{}
+	for _, result := range bulkRequest.Results() {
+		traced[result.Index] = WinningSpan(result).IsValid()
+	}
+	assert.Equal(t, map[int]bool{0: false, 1: false, 2: false, 3: true, 4: false, 5: false, 6: false, 7: true, 8: true}, traced)
+
+	require.Len(t, recorder.Ended(), 3)
+	var failures int
+	for _, span := range recorder.Ended() {
+		if span.Status().Code == codes.Error {
+			failures++
+		}
+	}
+	assert.Equal(t, 1, failures)
+}
+
+func TestSampleRateDrawsRequestsOutsideOfABulkFromTheRandomSource(t *testing.T) {
+	sample := func(draw float64) bool {
+		config := &tracing{}
+		for _, opt := range []TracingOption{WithSampleRate(0.5), WithRandom(func() float64 { return draw })} {
+			opt(config)
+		}
+		return config.sampled(nil)
+	}
+
+	assert.True(t, sample(0.1))
+	assert.False(t, sample(0.9))
+}
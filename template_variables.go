@@ -0,0 +1,148 @@
+package meniscus
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+)
+
+var placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+//Variables holds the values substituted for the {name} placeholders in the URLs and headers of the requests
+//of a bulk, see RoundTrip.SetVariables. It is safe for concurrent use.
+type Variables struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+//NewVariables returns a map of variables holding values
+func NewVariables(values map[string]string) *Variables {
+	vars := &Variables{values: map[string]string{}}
+	for name, value := range values {
+		vars.values[name] = value
+	}
+
+	return vars
+}
+
+//Set sets the variable name to value, for the requests dispatched from now on
+func (v *Variables) Set(name, value string) {
+	v.mu.Lock()
+	v.values[name] = value
+	v.mu.Unlock()
+}
+
+//Get returns the value of the variable name and whether it is set
+func (v *Variables) Get(name string) (string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	value, ok := v.values[name]
+	return value, ok
+}
+
+//Capture extracts the value of a variable from the result of a request, false leaves the variable unset
+type Capture func(Result) (string, bool)
+
+//FromHeader captures the value of a response header, e.g. a token issued by an auth endpoint
+func FromHeader(header string) Capture {
+	return func(result Result) (string, bool) {
+		value := result.Response.Header.Get(header)
+		return value, len(value) > 0
+	}
+}
+
+//SetVariables resolves the {name} placeholders in the URL path, query and header values of every request of the bulk,
+//and of its later phases, from vars when a worker picks the request up, so that requests can use values fetched
+//by the requests before them, see AddCapturingRequest and Then. Path values are escaped as needed, query values
+//are query escaped. Requests using a variable not set by then fail with ErrUnresolvedVariable.
+func (r *RoundTrip) SetVariables(vars *Variables) *RoundTrip {
+	r.variables = vars
+	return r
+}
+
+//AddCapturingRequest adds request to the bulk and, once it got a response, sets the variable name
+//to what capture returns from its result. A bulk without variables is given an empty set.
+func (r *RoundTrip) AddCapturingRequest(request *http.Request, name string, capture Capture) *RoundTrip {
+	if r.variables == nil {
+		r.variables = NewVariables(nil)
+	}
+
+	if r.captures == nil {
+		r.captures = map[int]variableCapture{}
+	}
+
+	r.captures[len(r.requests)] = variableCapture{name: name, capture: capture}
+	r.requests = append(r.requests, request)
+	return r
+}
+
+//VariablesOf returns the variables of the bulk a request belongs to from its context, for middlewares to read
+//or set, and nil when the bulk has none
+func VariablesOf(ctx context.Context) *Variables {
+	vars, _ := ctx.Value(variablesKey{}).(*Variables)
+	return vars
+}
+
+type variablesKey struct{}
+
+type variableCapture struct {
+	name    string
+	capture Capture
+}
+
+// captureVariable sets the variable captured from result, if any
+func (r *RoundTrip) captureVariable(result Result) {
+	capture, ok := r.captures[result.Index]
+	if !ok || result.Err != nil || result.Response == nil {
+		return
+	}
+
+	if value, ok := capture.capture(result); ok {
+		r.variables.Set(capture.name, value)
+	}
+}
+
+// resolve returns a copy of req with its placeholders replaced by the values of the variables
+func (v *Variables) resolve(req *http.Request) (*http.Request, error) {
+	missing := false
+	substitute := func(text string, escape func(string) string) string {
+		return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+			value, ok := v.Get(match[1 : len(match)-1])
+			if !ok {
+				missing = true
+				return match
+			}
+			return escape(value)
+		})
+	}
+	verbatim := func(value string) string { return value }
+
+	if req.URL == nil {
+		return req, &codedError{code: CodeClientErr, msg: "http client error: request without a URL"}
+	}
+
+	resolved := req.WithContext(req.Context())
+	u := *req.URL
+	u.Path = substitute(u.Path, verbatim)
+	u.RawPath = ""
+	u.RawQuery = substitute(u.RawQuery, url.QueryEscape)
+	resolved.URL = &u
+
+	resolved.Header = make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		substituted := make([]string, len(values))
+		for i, value := range values {
+			substituted[i] = substitute(value, verbatim)
+		}
+		resolved.Header[key] = substituted
+	}
+
+	if missing {
+		return req, ErrUnresolvedVariable
+	}
+
+	return resolved, nil
+}
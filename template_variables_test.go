@@ -0,0 +1,101 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestVariablesCapturedInTheFirstPhaseAreResolvedInTheNext(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("X-Token", "s3cr3t")
+			return
+		}
+
+		mu.Lock()
+		seen = append(seen, r.URL.Path+"?"+r.URL.RawQuery+" "+r.Header.Get("Authorization"))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	token, err := http.NewRequest(http.MethodGet, server.URL+"/token", nil)
+	require.NoError(t, err)
+	fanout, err := http.NewRequest(http.MethodGet, server.URL+"/drivers/{city}?name={name}", nil)
+	require.NoError(t, err)
+	fanout.Header.Set("Authorization", "Bearer {token}")
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		SetVariables(NewVariables(map[string]string{"city": "jakarta", "name": "a&b"})).
+		AddCapturingRequest(token, "token", FromHeader("X-Token")).
+		Then(func([]Result) []*http.Request { return []*http.Request{fanout} })
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue)
+
+	_, errs := client.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, []string{"/drivers/jakarta?name=a%26b Bearer s3cr3t"}, seen)
+}
+
+func TestRequestsUsingUnsetVariablesAreNotSent(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).SetVariables(NewVariables(nil)).AddGet("http://example.com/{missing}", nil)
+	_, errs := client.Do(bulkRequest)
+
+	assert.Equal(t, []error{ErrUnresolvedVariable}, errs)
+	assert.Empty(t, calls)
+}
+
+func TestRequestsWithoutAURLFailAlone(t *testing.T) {
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue)
+
+	bulkRequest := NewBulkRequest([]*http.Request{{Method: http.MethodGet, Header: http.Header{}}}, 1, 1).
+		SetVariables(NewVariables(nil)).
+		AddGet("http://example.com/", nil)
+	_, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, CodeClientErr, Code(errs[0]))
+	assert.NoError(t, errs[1])
+}
+
+func TestMiddlewaresCanSetVariables(t *testing.T) {
+	var seen []string
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		seen = append(seen, req.URL.Path)
+		return syntheticResponse(req, http.StatusOK), nil
+	}), NonFailingTimeoutValue, WithMiddleware(func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			VariablesOf(req.Context()).Set("page", "2")
+			return next.Do(req)
+		})
+	}))
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).
+		SetVariables(NewVariables(map[string]string{"page": "1"})).
+		AddGet("http://example.com/pages/{page}", nil).
+		AddGet("http://example.com/pages/{page}", nil)
+	client.Do(bulkRequest)
+
+	assert.Equal(t, []string{"/pages/1", "/pages/2"}, seen)
+}
+
+func TestPlaceholdersAreLeftAloneWithoutVariables(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+
+	_, errs := client.Do(NewBulkRequest(nil, 1, 1).AddGet("http://example.com/{id}", nil))
+
+	assert.Equal(t, []error{nil}, errs)
+	assert.Equal(t, map[string]int{"/{id}": 1}, calls)
+}
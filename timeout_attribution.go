@@ -0,0 +1,124 @@
+package meniscus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+)
+
+//TimeoutSource tells which timeout a TimeoutError ran into
+type TimeoutSource int
+
+const (
+	//BulkDeadline is the timeout of the whole bulk given to NewBulkHTTPClient
+	BulkDeadline TimeoutSource = iota
+	//RequestTimeout is one of the StagedTimeouts of the request
+	RequestTimeout
+	//ClientTimeout is the Timeout of the underlying http.Client
+	ClientTimeout
+)
+
+func (s TimeoutSource) String() string {
+	switch s {
+	case BulkDeadline:
+		return "bulk deadline"
+	case RequestTimeout:
+		return "request timeout"
+	default:
+		return "http client timeout"
+	}
+}
+
+// Phases of a request reported by TimeoutError
+const (
+	PhaseQueued          = "queued" // waiting for a fire worker
+	PhaseConnecting      = "connecting"
+	PhaseWritingRequest  = "writing request"
+	PhaseAwaitingHeaders = "awaiting headers"
+	PhaseReadingBody     = "reading body"
+)
+
+//TimeoutError is returned, with WithTimeoutAttribution, for a request that failed at a timeout.
+//It tells which timeout fired, in which phase and after how long.
+type TimeoutError struct {
+	Source  TimeoutSource
+	Phase   string
+	Elapsed time.Duration // since a fire worker picked the request up, or since the bulk was dispatched when still queued
+	Err     error         // the error returned without attribution, e.g. ErrRequestIgnored or ErrResponseHeaderTimeout
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s exceeded after %s while %s: %s", e.Source, e.Elapsed, e.Phase, e.Err)
+}
+
+//Unwrap returns the error returned without attribution
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// stagePhases are the phases the staged timeouts bound, ErrRequestTimeout bounds them all
+var stagePhases = map[error]string{
+	ErrDialTimeout:           PhaseConnecting,
+	ErrTLSHandshakeTimeout:   PhaseConnecting,
+	ErrResponseHeaderTimeout: PhaseAwaitingHeaders,
+	ErrStalled:               PhaseReadingBody,
+}
+
+// tracePhases follows the phase of the request of scope as it is sent
+func tracePhases(req *http.Request, scope *RequestScope) *http.Request {
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn:              func(httptrace.GotConnInfo) { scope.enter(PhaseWritingRequest) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { scope.enter(PhaseAwaitingHeaders) },
+		GotFirstResponseByte: func() { scope.enter(PhaseReadingBody) },
+	}))
+}
+
+// attributeTimeout returns the TimeoutError for err if it comes from a staged or http.Client timeout, nil otherwise
+func (cl *BulkClient) attributeTimeout(req *http.Request, err error) error {
+	scope := scopeOfRequest(req)
+	if !cl.timeoutAttribution || scope == nil {
+		return nil
+	}
+
+	now := cl.clock.Now()
+	phase, elapsed := scope.progress(now, now)
+	if stagePhase, ok := stagePhases[err]; ok {
+		return &TimeoutError{Source: RequestTimeout, Phase: stagePhase, Elapsed: elapsed, Err: err}
+	}
+
+	if err == ErrRequestTimeout {
+		return &TimeoutError{Source: RequestTimeout, Phase: phase, Elapsed: elapsed, Err: err}
+	}
+
+	if timeout, ok := err.(interface{ Timeout() bool }); ok && timeout.Timeout() && strings.Contains(err.Error(), "Client.Timeout") {
+		return &TimeoutError{Source: ClientTimeout, Phase: phase, Elapsed: elapsed, Err: err}
+	}
+
+	return nil
+}
+
+// attributeDeadline replaces ErrRequestIgnored by a TimeoutError for the requests left unanswered by a bulk
+// that ran out of time, rather than being cancelled
+func (cl *BulkClient) attributeDeadline(ctx context.Context, bulkRequest *RoundTrip) {
+	if !cl.timeoutAttribution || ctx.Err() != context.DeadlineExceeded {
+		return
+	}
+
+	now := cl.clock.Now()
+	deadline, _ := ctx.Deadline()
+	for i, err := range bulkRequest.errors {
+		scope := scopeOfRequest(bulkRequest.requests[i])
+		if err != ErrRequestIgnored || scope == nil {
+			continue
+		}
+
+		phase, elapsed := scope.progress(now, deadline)
+		if phase == PhaseQueued {
+			elapsed = now.Sub(bulkRequest.dispatched)
+		}
+		bulkRequest.errors[i] = &TimeoutError{Source: BulkDeadline, Phase: phase, Elapsed: elapsed, Err: err}
+	}
+}
@@ -0,0 +1,74 @@
+package meniscus
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func slowRequests(t *testing.T, serverURL string, n int) []*http.Request {
+	query := url.Values{}
+	query.Set("kind", "slow")
+
+	var requests []*http.Request
+	for i := 0; i < n; i++ {
+		req, err := http.NewRequest(http.MethodGet, encodeURL(serverURL, "", query), nil)
+		require.NoError(t, err, "no errors")
+		requests = append(requests, req)
+	}
+
+	return requests
+}
+
+func TestTimeoutAttributionNamesTheBulkDeadlineAndThePhase(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, FailingTimeoutValue, WithTimeoutAttribution())
+
+	_, errs := client.Do(NewBulkRequest(slowRequests(t, server.URL, 2), 1, 1))
+
+	inFlight, ok := errs[0].(*TimeoutError)
+	require.True(t, ok, "error is a timeout error")
+	assert.Equal(t, BulkDeadline, inFlight.Source)
+	assert.Equal(t, PhaseAwaitingHeaders, inFlight.Phase)
+	assert.True(t, inFlight.Elapsed > 0)
+	assert.True(t, errors.Is(inFlight, ErrRequestIgnored))
+	assert.Contains(t, inFlight.Error(), "bulk deadline exceeded after ")
+	assert.Contains(t, inFlight.Error(), " while awaiting headers: request ignored")
+
+	queued, ok := errs[1].(*TimeoutError)
+	require.True(t, ok, "error is a timeout error")
+	assert.Equal(t, PhaseQueued, queued.Phase)
+	assert.True(t, queued.Elapsed >= FailingTimeoutValue)
+}
+
+func TestTimeoutAttributionNamesTheHTTPClientTimeout(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: FailingTimeoutValue}, NonFailingTimeoutValue, WithTimeoutAttribution())
+
+	_, errs := client.Do(NewBulkRequest(slowRequests(t, server.URL, 1), 1, 1))
+
+	timeout, ok := errs[0].(*TimeoutError)
+	require.True(t, ok, "error is a timeout error")
+	assert.Equal(t, ClientTimeout, timeout.Source)
+	assert.Equal(t, PhaseAwaitingHeaders, timeout.Phase)
+}
+
+func TestTimeoutAttributionNamesTheStagedTimeout(t *testing.T) {
+	server := StartMockServer()
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue,
+		WithStagedTimeouts(StagedTimeouts{ResponseHeader: FailingTimeoutValue}), WithTimeoutAttribution())
+
+	_, errs := client.Do(NewBulkRequest(slowRequests(t, server.URL, 1), 1, 1))
+
+	timeout, ok := errs[0].(*TimeoutError)
+	require.True(t, ok, "error is a timeout error")
+	assert.Equal(t, RequestTimeout, timeout.Source)
+	assert.Equal(t, PhaseAwaitingHeaders, timeout.Phase)
+	assert.Equal(t, ErrResponseHeaderTimeout, timeout.Err)
+}
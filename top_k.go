@@ -0,0 +1,60 @@
+package meniscus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sort"
+)
+
+//ScoredResult is a result kept by TopK with its score
+type ScoredResult struct {
+	Result
+	Score float64
+}
+
+//TopK returns a reducer keeping the k results with the highest score, as a []ScoredResult from the best down.
+//Failed requests are not scored. The bodies of the kept responses are copied in memory, as the client
+//closes every response once reduced, while the others are discarded as soon as they are scored or evicted.
+func TopK(k int, score func(Result) float64) Reducer {
+	return func(acc interface{}, result Result) interface{} {
+		top, _ := acc.([]ScoredResult)
+		if result.Err != nil || result.Response == nil {
+			return top
+		}
+
+		body, err := ioutil.ReadAll(result.Response.Body)
+		if err != nil {
+			return top
+		}
+
+		result.Response = withBody(result.Response, body)
+		scored := ScoredResult{Score: score(result)}
+		result.Response.Body = memoryBody{bytes.NewReader(body)} // rewound after scoring
+		scored.Result = result
+
+		if len(top) == k && (k == 0 || scored.Score <= top[k-1].Score) {
+			return top
+		}
+
+		position := sort.Search(len(top), func(i int) bool { return top[i].Score < scored.Score })
+		top = append(top, ScoredResult{})
+		copy(top[position+1:], top[position:])
+		top[position] = scored
+		if len(top) > k {
+			// the evicted result is cleared so the backing array does not hold on to its body
+			top[k] = ScoredResult{}
+			top = top[:k]
+		}
+
+		return top
+	}
+}
+
+// withBody returns a copy of resp reading body
+func withBody(resp *http.Response, body []byte) *http.Response {
+	copied := *resp
+	copied.Body = memoryBody{bytes.NewReader(body)}
+	copied.ContentLength = int64(len(body))
+	return &copied
+}
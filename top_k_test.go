@@ -0,0 +1,55 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTopKKeepsTheBestScoredResults(t *testing.T) {
+	prices := []string{"30", "10", "50", "20", "40"}
+	client := NewBulkHTTPClient(HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		resp := syntheticResponse(req, http.StatusOK)
+		resp.Body = ioutil.NopCloser(strings.NewReader(strings.TrimPrefix(req.URL.Path, "/")))
+		return resp, nil
+	}), NonFailingTimeoutValue, WithReducer(TopK(2, func(result Result) float64 {
+		body, _ := ioutil.ReadAll(result.Response.Body)
+		price, _ := strconv.Atoi(string(body))
+		return -float64(price)
+	})))
+
+	bulkRequest := NewBulkRequest(nil, 2, 2)
+	for _, price := range prices {
+		bulkRequest.AddGet("http://example.com/"+price, nil)
+	}
+	bulkRequest.AddGet("http://exa mple.com", nil)
+
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	top := bulkRequest.Aggregate().([]ScoredResult)
+	assert.Len(t, top, 2)
+	assert.Equal(t, []int{1, 3}, []int{top[0].Index, top[1].Index})
+	assert.Equal(t, float64(-10), top[0].Score)
+	body, _ := ioutil.ReadAll(top[1].Response.Body)
+	assert.Equal(t, "20", string(body))
+}
+
+func TestTopKDropsTheResultsItEvicts(t *testing.T) {
+	reduce := TopK(1, func(result Result) float64 { return float64(result.Index) })
+
+	var acc interface{}
+	for i := 0; i < 3; i++ {
+		req := mustRequest(t, "http://example.com/"+strconv.Itoa(i))
+		acc = reduce(acc, Result{Index: i, Request: req, Response: syntheticResponse(req, http.StatusOK)})
+	}
+
+	top := acc.([]ScoredResult)
+	assert.Equal(t, 2, top[0].Index)
+	for _, evicted := range top[1:cap(top)] {
+		assert.Nil(t, evicted.Response)
+	}
+}
@@ -0,0 +1,58 @@
+package meniscus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+//UploadPolicy chooses how request bodies are framed on the wire
+type UploadPolicy int
+
+const (
+	//UploadAsIs leaves framing to net/http: Content-Length when the length of the body is known, chunked otherwise
+	UploadAsIs UploadPolicy = iota
+	//UploadFixedLength always sends a Content-Length, buffering bodies of unknown length to measure them.
+	//Some gateways reject chunked uploads.
+	UploadFixedLength
+	//UploadChunked always streams bodies with chunked transfer encoding
+	UploadChunked
+)
+
+// frame returns a copy of req with its body framed according to the policy
+func (p UploadPolicy) frame(req *http.Request) (*http.Request, error) {
+	if p == UploadAsIs || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	framed := *req
+	switch p {
+	case UploadChunked:
+		framed.ContentLength = -1
+		framed.TransferEncoding = []string{"chunked"}
+	case UploadFixedLength:
+		if req.ContentLength > 0 {
+			return req, nil
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return req, fmt.Errorf("error while reading request body: %s", err)
+		}
+
+		framed.Body = ioutil.NopCloser(bytes.NewReader(body))
+		framed.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		framed.ContentLength = int64(len(body))
+		framed.TransferEncoding = nil
+		if len(body) == 0 {
+			framed.Body = http.NoBody
+		}
+	}
+
+	return &framed, nil
+}
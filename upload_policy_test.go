@@ -0,0 +1,43 @@
+package meniscus
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadPolicyFramesRequestBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		w.Write([]byte(strings.Join(req.TransferEncoding, ",") + "|" + req.Header.Get("Content-Length") + "|" + string(body)))
+	}))
+	defer server.Close()
+
+	upload := func(policy UploadPolicy, body func() *http.Request) string {
+		client := NewBulkHTTPClient(&http.Client{Timeout: NonFailingTimeoutValue}, NonFailingTimeoutValue, WithUploadPolicy(policy))
+		bulkRequest := NewBulkRequest([]*http.Request{body()}, 1, 1)
+		responses, errs := client.Do(bulkRequest)
+		defer bulkRequest.CloseAllResponses()
+
+		assert.Equal(t, []error{nil}, errs)
+		received, _ := ioutil.ReadAll(responses[0].Body)
+		return string(received)
+	}
+	streamed := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, ioutil.NopCloser(strings.NewReader("payload")))
+		return req
+	}
+	sized := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte("payload")))
+		return req
+	}
+
+	assert.Equal(t, "chunked||payload", upload(UploadAsIs, streamed))
+	assert.Equal(t, "|7|payload", upload(UploadFixedLength, streamed))
+	assert.Equal(t, "chunked||payload", upload(UploadChunked, sized))
+	assert.Equal(t, "|7|payload", upload(UploadAsIs, sized))
+}
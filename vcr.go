@@ -0,0 +1,179 @@
+package meniscus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+//Interaction is a single recorded request and the response or error it produced
+type Interaction struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody []byte      `json:"request_body,omitempty"`
+	StatusCode  int         `json:"status_code,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	Error       string      `json:"error,omitempty"`
+}
+
+//Cassette holds the interactions captured by RecordMiddleware and served back by ReplayClient
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	mu     sync.Mutex
+	served map[string]int
+}
+
+//NewCassette returns an empty cassette ready for recording
+func NewCassette() *Cassette {
+	return &Cassette{}
+}
+
+//LoadCassette reads a cassette previously written with Save
+func LoadCassette(path string) (*Cassette, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{}
+	if err := json.Unmarshal(bs, cassette); err != nil {
+		return nil, fmt.Errorf("error while decoding cassette: %s", err)
+	}
+
+	return cassette, nil
+}
+
+//Save writes all recorded interactions to path
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	bs, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, bs, 0644)
+}
+
+func (c *Cassette) record(interaction Interaction) {
+	c.mu.Lock()
+	c.Interactions = append(c.Interactions, interaction)
+	c.mu.Unlock()
+}
+
+// next returns the interactions matching the request in the order they were recorded.
+// Once all matching interactions have been served, the last one is served again.
+func (c *Cassette) next(method, url string, body []byte) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matches []Interaction
+	for _, interaction := range c.Interactions {
+		if interaction.Method == method && interaction.URL == url && bytes.Equal(interaction.RequestBody, body) {
+			matches = append(matches, interaction)
+		}
+	}
+
+	if len(matches) == 0 {
+		return Interaction{}, false
+	}
+
+	if c.served == nil {
+		c.served = map[string]int{}
+	}
+
+	key := method + " " + url + " " + string(body)
+	index := c.served[key]
+	if index >= len(matches) {
+		index = len(matches) - 1
+	}
+	c.served[key] = index + 1
+
+	return matches[index], true
+}
+
+//RecordMiddleware captures every request going through the bulk client into cassette
+func RecordMiddleware(cassette *Cassette) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			reqBody, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+
+			interaction := Interaction{Method: req.Method, URL: req.URL.String(), RequestBody: reqBody}
+
+			resp, err := next.Do(req)
+			if err != nil {
+				interaction.Error = err.Error()
+				cassette.record(interaction)
+				return nil, err
+			}
+
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			interaction.StatusCode = resp.StatusCode
+			interaction.Header = resp.Header
+			interaction.Body = body
+			cassette.record(interaction)
+
+			return resp, nil
+		})
+	}
+}
+
+//ReplayClient serves responses recorded in cassette without touching the network.
+//Requests without a recorded interaction fail with ErrInteractionNotFound.
+func ReplayClient(cassette *Cassette) HTTPClient {
+	return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		reqBody, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+
+		interaction, found := cassette.next(req.Method, req.URL.String(), reqBody)
+		if !found {
+			return nil, ErrInteractionNotFound
+		}
+
+		if len(interaction.Error) != 0 {
+			return nil, errors.New(interaction.Error)
+		}
+
+		resp := syntheticResponse(req, interaction.StatusCode)
+		if interaction.Header != nil {
+			resp.Header = interaction.Header
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(interaction.Body))
+		resp.ContentLength = int64(len(interaction.Body))
+
+		return resp, nil
+	})
+}
+
+// readRequestBody reads the request body and puts back an unread copy on the request
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	bs, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading request body: %s", err)
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(bs))
+	return bs, nil
+}
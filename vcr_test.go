@@ -0,0 +1,72 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordedBulkCanBeReplayedWithoutTheServer(t *testing.T) {
+	server := StartMockServer()
+	httpclient := &http.Client{Timeout: NonFailingTimeoutValue}
+	cassette := NewCassette()
+	recordingClient := NewBulkHTTPClient(httpclient, NonFailingTimeoutValue, WithMiddleware(RecordMiddleware(cassette)))
+
+	newRequests := func() []*http.Request {
+		queryFast := url.Values{}
+		queryFast.Set("kind", "fast")
+		querySlow := url.Values{}
+		querySlow.Set("kind", "slow")
+
+		reqOne, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", queryFast), nil)
+		require.NoError(t, err, "no errors")
+		reqTwo, err := http.NewRequest(http.MethodGet, encodeURL(server.URL, "", querySlow), nil)
+		require.NoError(t, err, "no errors")
+		return []*http.Request{reqOne, reqTwo}
+	}
+
+	bulkRequest := NewBulkRequest(newRequests(), 2, 2)
+	_, errs := recordingClient.Do(bulkRequest)
+	bulkRequest.CloseAllResponses()
+	assert.Equal(t, []error{nil, nil}, errs)
+
+	dir, err := ioutil.TempDir("", "cassette")
+	require.NoError(t, err, "no errors")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bulk.json")
+	require.NoError(t, cassette.Save(path))
+
+	replayRequests := newRequests()
+	server.Close()
+
+	loaded, err := LoadCassette(path)
+	require.NoError(t, err, "no errors")
+	assert.Len(t, loaded.Interactions, 2)
+
+	replayClient := NewBulkHTTPClient(ReplayClient(loaded), NonFailingTimeoutValue)
+	replayBulkRequest := NewBulkRequest(replayRequests, 2, 2)
+	responses, errs := replayClient.Do(replayBulkRequest)
+	defer replayBulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	responseOne, _ := ioutil.ReadAll(responses[0].Body)
+	responseTwo, _ := ioutil.ReadAll(responses[1].Body)
+	assert.Equal(t, "fast", string(responseOne))
+	assert.Equal(t, "slow", string(responseTwo))
+}
+
+func TestReplayClientFailsForUnknownRequests(t *testing.T) {
+	client := NewBulkHTTPClient(ReplayClient(NewCassette()), NonFailingTimeoutValue)
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err, "no errors")
+
+	bulkRequest := NewBulkRequest([]*http.Request{req}, 1, 1)
+	_, errs := client.Do(bulkRequest)
+
+	assert.EqualError(t, errs[0], "http client error: "+ErrInteractionNotFound.Error())
+}
@@ -0,0 +1,183 @@
+package meniscus
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// connectTo sends requests to other addresses than the hosts of their URLs
+type connectTo struct {
+	hosts  map[string]string // by lower cased host name
+	routed bool              // the http client can dial the addresses
+}
+
+//WithConnectTo connects requests for the hosts of overrides to the address each host maps to, e.g. "10.0.0.7:8443"
+//or a sidecar at "127.0.0.1:15001", instead of the address the host resolves to. Requests keep the Host header
+//and TLS server name (SNI) of their URL, and certificates are still checked against the host.
+//Addresses without a port use the port of the URL. It also lets requests added with AddRequestVia be routed.
+//
+//Only an *http.Client using an *http.Transport can be routed, requests that would be routed by other clients
+//fail with ErrConnectToUnsupported. Addresses are checked against the DestinationPolicy along with the URL.
+func WithConnectTo(overrides map[string]string) ClientOption {
+	return func(cl *BulkClient) {
+		cl.connectTo.hosts = map[string]string{}
+		for host, address := range overrides {
+			cl.connectTo.hosts[strings.ToLower(host)] = address
+		}
+	}
+}
+
+//AddRequestVia adds request to the bulk, connected to address instead of the host of its URL, keeping its Host header
+//and TLS server name. Redirects to other hosts are not affected. It needs a client built WithConnectTo.
+func (r *RoundTrip) AddRequestVia(request *http.Request, address string) *RoundTrip {
+	if r.via == nil {
+		r.via = map[int]viaAddress{}
+	}
+
+	r.via[len(r.requests)] = viaAddress{host: strings.ToLower(request.URL.Hostname()), address: address}
+	r.requests = append(r.requests, request)
+	return r
+}
+
+type viaAddress struct {
+	host    string
+	address string
+}
+
+type viaKey struct{}
+
+// address returns the address req is connected to, with its port, or empty when it is connected to its host
+func (c connectTo) address(req *http.Request) string {
+	if req.URL == nil {
+		return ""
+	}
+
+	host := strings.ToLower(req.URL.Hostname())
+	address, ok := c.hosts[host]
+	if via, isVia := req.Context().Value(viaKey{}).(viaAddress); isVia && via.host == host {
+		address, ok = via.address, true
+	}
+
+	if !ok {
+		return ""
+	}
+
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+
+	port := req.URL.Port()
+	if len(port) == 0 {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	return net.JoinHostPort(address, port)
+}
+
+// client returns a copy of client dialing the overridden addresses, or client itself when it cannot be routed
+func (c *connectTo) client(client HTTPClient) HTTPClient {
+	httpClient, ok := client.(*http.Client)
+	if !ok {
+		return client
+	}
+
+	roundTripper := httpClient.Transport
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+
+	var transport http.RoundTripper
+	switch base := roundTripper.(type) {
+	case *http.Transport:
+		transport = c.route(base)
+	case *pinnedTransport:
+		pinned := &pinnedTransport{base: c.routeAny(base.base), transports: map[string]http.RoundTripper{}}
+		for host, hostTransport := range base.transports {
+			pinned.transports[host] = c.routeAny(hostTransport)
+		}
+		transport = pinned
+	default:
+		return client
+	}
+
+	c.routed = true
+	routed := *httpClient
+	routed.Transport = transport
+	return &routed
+}
+
+func (c connectTo) route(base *http.Transport) *routedTransport {
+	return &routedTransport{connectTo: c, base: base, transports: map[string]*http.Transport{}}
+}
+
+// routeAny routes roundTripper when it is an *http.Transport, the members of a pinnedTransport always are
+func (c connectTo) routeAny(roundTripper http.RoundTripper) http.RoundTripper {
+	if transport, ok := roundTripper.(*http.Transport); ok {
+		return c.route(transport)
+	}
+
+	return roundTripper
+}
+
+// routedTransport sends the requests connected to another address through a copy of base dialing that address,
+// so that their connections are pooled apart from those to the host itself
+type routedTransport struct {
+	connectTo connectTo
+	base      *http.Transport
+
+	mu         sync.Mutex
+	transports map[string]*http.Transport // by address
+}
+
+func (t *routedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	address := t.connectTo.address(req)
+	if len(address) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	return t.transport(address).RoundTrip(req)
+}
+
+func (t *routedTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, transport := range t.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+func (t *routedTransport) transport(address string) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if transport, ok := t.transports[address]; ok {
+		return transport
+	}
+
+	transport := t.base.Clone()
+	transport.Proxy = nil
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return dial(ctx, network, address)
+	}
+	if dialTLS := transport.DialTLSContext; dialTLS != nil {
+		transport.DialTLSContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialTLS(ctx, network, address)
+		}
+	}
+
+	t.transports[address] = transport
+	return transport
+}
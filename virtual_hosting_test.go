@@ -0,0 +1,72 @@
+package meniscus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// hostEcho answers every request with the Host header and TLS server name it was received with
+func hostEcho() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Host", r.Host)
+		if r.TLS != nil {
+			w.Header().Set("X-Server-Name", r.TLS.ServerName)
+		}
+	}
+}
+
+func TestConnectToKeepsTheHostHeaderAndServerName(t *testing.T) {
+	server := httptest.NewTLSServer(hostEcho())
+	defer server.Close()
+
+	// the test certificate is valid for example.com, which must not be resolved
+	client := NewBulkHTTPClient(server.Client(), NonFailingTimeoutValue,
+		WithConnectTo(map[string]string{"Example.com": server.Listener.Addr().String()}))
+
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddGet("https://example.com/drivers", nil)
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil}, errs)
+	assert.Equal(t, "example.com", responses[0].Header.Get("X-Host"))
+	assert.Equal(t, "example.com", responses[0].Header.Get("X-Server-Name"))
+}
+
+func TestAddRequestViaConnectsOneRequestToAnotherAddress(t *testing.T) {
+	server := httptest.NewServer(hostEcho())
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{}, NonFailingTimeoutValue, WithConnectTo(nil))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://orders.internal:8080/orders", nil)
+	bulkRequest := NewBulkRequest(nil, 1, 1).AddRequestVia(req, server.Listener.Addr().String())
+	responses, errs := client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	assert.Equal(t, []error{nil}, errs)
+	assert.Equal(t, "orders.internal:8080", responses[0].Header.Get("X-Host"))
+}
+
+func TestConnectToChecksTheAddressAgainstTheDestinationPolicy(t *testing.T) {
+	server := httptest.NewServer(hostEcho())
+	defer server.Close()
+	client := NewBulkHTTPClient(&http.Client{}, NonFailingTimeoutValue,
+		WithConnectTo(map[string]string{"orders.internal": server.Listener.Addr().String()}),
+		WithDestinationPolicy(DestinationPolicy{AllowedHosts: []string{"orders.internal"}}))
+
+	_, errs := client.Do(NewBulkRequest(nil, 1, 1).AddGet("http://orders.internal/orders", nil))
+
+	assert.Equal(t, []error{ErrDestinationNotAllowed}, errs)
+}
+
+func TestRequestsThatCannotBeRoutedAreNotSent(t *testing.T) {
+	calls := map[string]int{}
+	client := NewBulkHTTPClient(countingClient(calls), NonFailingTimeoutValue)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://orders.internal/orders", nil)
+	_, errs := client.Do(NewBulkRequest(nil, 1, 1).AddRequestVia(req, "127.0.0.1:1"))
+
+	assert.Equal(t, []error{ErrConnectToUnsupported}, errs)
+	assert.Empty(t, calls)
+}
@@ -0,0 +1,146 @@
+package meniscus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//WebhookVerificationOptions configures VerifyWebhooks, zero values get the defaults
+type WebhookVerificationOptions struct {
+	Client  HTTPClient     // defaults to an http.Client with Timeout as its timeout
+	Method  string         // GET sends the challenge as a query parameter, POST as a JSON body, defaults to GET
+	Param   string         // name of the query parameter or JSON field carrying the challenge, defaults to challenge
+	Timeout time.Duration  // for the whole batch, defaults to 5 seconds
+	Workers int            // defaults to one per endpoint, up to 50
+	Options []ClientOption // applied to the BulkClient sending the challenges
+}
+
+//WebhookVerification is the outcome of challenging one endpoint
+type WebhookVerification struct {
+	URL        string
+	Verified   bool
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+}
+
+// maxChallengeResponse bounds how much of a response is read looking for the echoed challenge
+const maxChallengeResponse = 64 << 10
+
+//VerifyWebhooks sends every endpoint its own random challenge and reports which echoed it back,
+//either as the whole response body or as the Param field of a JSON body, in the same order as urls
+func VerifyWebhooks(urls []string, opts WebhookVerificationOptions) []WebhookVerification {
+	opts = opts.withDefaults(len(urls))
+	client := NewBulkHTTPClient(opts.Client, opts.Timeout, opts.Options...)
+
+	challenges := make([]string, len(urls))
+	bulkRequest := NewBulkRequest(nil, opts.Workers, opts.Workers)
+	for i, endpoint := range urls {
+		challenge, err := newChallenge()
+		if err != nil {
+			bulkRequest.addInvalid(opts.Method, err)
+			continue
+		}
+
+		challenges[i] = challenge
+		if opts.Method == http.MethodGet {
+			bulkRequest.addBuilt(opts.Method, withQueryParam(endpoint, opts.Param, challenge), nil, nil)
+			continue
+		}
+		bulkRequest.addJSON(opts.Method, endpoint, map[string]string{opts.Param: challenge})
+	}
+
+	client.Do(bulkRequest)
+	defer bulkRequest.CloseAllResponses()
+
+	report := make([]WebhookVerification, len(urls))
+	for _, result := range bulkRequest.Results() {
+		verification := WebhookVerification{URL: urls[result.Index], Latency: result.Latency, Err: result.Err}
+		if result.Response != nil {
+			verification.StatusCode = result.Response.StatusCode
+			verification.Verified, verification.Err = echoed(result.Response, opts.Param, challenges[result.Index])
+		}
+		report[result.Index] = verification
+	}
+
+	return report
+}
+
+func (opts WebhookVerificationOptions) withDefaults(endpoints int) WebhookVerificationOptions {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	if len(opts.Method) == 0 {
+		opts.Method = http.MethodGet
+	}
+
+	if len(opts.Param) == 0 {
+		opts.Param = "challenge"
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = endpoints
+		if opts.Workers > 50 {
+			opts.Workers = 50
+		}
+	}
+
+	return opts
+}
+
+func newChallenge() (string, error) {
+	token := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// withQueryParam adds the param to endpoint, leaving endpoint as is when it cannot be parsed so building the request fails
+func withQueryParam(endpoint, param, value string) string {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+
+	query := parsed.Query()
+	query.Set(param, value)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// echoed tells whether res is a success carrying challenge, as its whole body or as the param field of its JSON body
+func echoed(res *http.Response, param, challenge string) (bool, error) {
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return false, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxChallengeResponse))
+	if err != nil {
+		return false, err
+	}
+
+	if strings.TrimSpace(string(body)) == challenge {
+		return true, nil
+	}
+
+	var fields map[string]interface{}
+	if json.Unmarshal(body, &fields) == nil && fields[param] == challenge {
+		return true, nil
+	}
+
+	return false, nil
+}
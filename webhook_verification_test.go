@@ -0,0 +1,54 @@
+package meniscus
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyWebhooksReportsWhichEndpointsEchoTheirChallenge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/echo":
+			w.Write([]byte(req.URL.Query().Get("challenge")))
+		case "/json":
+			json.NewEncoder(w).Encode(map[string]string{"challenge": req.URL.Query().Get("challenge")})
+		case "/stale":
+			w.Write([]byte("0123456789abcdef0123456789abcdef"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	report := VerifyWebhooks([]string{server.URL + "/echo", server.URL + "/json?tenant=1", server.URL + "/stale", server.URL + "/gone"},
+		WebhookVerificationOptions{})
+
+	assert.True(t, report[0].Verified)
+	assert.Equal(t, server.URL+"/echo", report[0].URL)
+	assert.True(t, report[1].Verified)
+	assert.False(t, report[2].Verified)
+	assert.Equal(t, http.StatusOK, report[2].StatusCode)
+	assert.False(t, report[3].Verified)
+	assert.Equal(t, http.StatusNotFound, report[3].StatusCode)
+	assert.NoError(t, report[3].Err)
+}
+
+func TestVerifyWebhooksPostsTheChallengeAsJSON(t *testing.T) {
+	var challenges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		json.NewDecoder(req.Body).Decode(&body)
+		challenges = append(challenges, body["token"])
+		w.Write([]byte(body["token"]))
+	}))
+	defer server.Close()
+
+	report := VerifyWebhooks([]string{server.URL}, WebhookVerificationOptions{Method: http.MethodPost, Param: "token"})
+
+	assert.True(t, report[0].Verified)
+	assert.Len(t, challenges, 1)
+	assert.Len(t, challenges[0], 32)
+}